@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"strings"
+
+	"github.com/monochromegane/stamp/internal/stamp"
+)
+
+// batchRecord is one line of --sheets-from-stdin input
+type batchRecord struct {
+	line   int
+	sheets []string
+	dest   string
+	vars   map[string]string
+}
+
+// parseBatchRecord parses "sheet[,sheet...]|dest|KEY=VALUE ..."
+// The variables field is optional
+func parseBatchRecord(line string) (batchRecord, error) {
+	fields := strings.Split(line, "|")
+	if len(fields) < 2 || len(fields) > 3 {
+		return batchRecord{}, fmt.Errorf("want 'sheet[,sheet...]|dest|KEY=VALUE ...', got %q", line)
+	}
+
+	var rec batchRecord
+	for _, sheet := range strings.Split(fields[0], ",") {
+		if sheet = strings.TrimSpace(sheet); sheet != "" {
+			rec.sheets = append(rec.sheets, sheet)
+		}
+	}
+	if len(rec.sheets) == 0 {
+		return batchRecord{}, fmt.Errorf("no sheets in %q", line)
+	}
+
+	rec.dest = strings.TrimSpace(fields[1])
+	if rec.dest == "" {
+		return batchRecord{}, fmt.Errorf("no destination in %q", line)
+	}
+
+	rec.vars = make(map[string]string)
+	if len(fields) == 3 {
+		for _, assignment := range strings.Fields(fields[2]) {
+			key, value, ok := strings.Cut(assignment, "=")
+			if !ok {
+				return batchRecord{}, fmt.Errorf("variable %q is not in KEY=VALUE format", assignment)
+			}
+			rec.vars[key] = value
+		}
+	}
+	return rec, nil
+}
+
+// runBatch presses every record read from r in order
+// Record variables override variables given on the command line
+// Without --keep-going the first failing record stops the batch
+func (c *PressCmd) runBatch(r io.Reader, logger stamp.Logger) error {
+	var report []string
+	records, failed := 0, 0
+
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		records++
+
+		err := c.pressRecord(line, logger)
+		if err == nil {
+			report = append(report, fmt.Sprintf("  line %d: ok", lineNo))
+			continue
+		}
+		failed++
+		report = append(report, fmt.Sprintf("  line %d: failed: %v", lineNo, err))
+		if !c.KeepGoing {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read batch records: %w", err)
+	}
+
+	if !c.Quiet || failed > 0 {
+		fmt.Fprintf(os.Stdout, "Batch results:\n%s\n", strings.Join(report, "\n"))
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d batch records failed", failed, records)
+	}
+	return nil
+}
+
+// pressRecord presses a single batch record with the command's other options
+func (c *PressCmd) pressRecord(line string, logger stamp.Logger) error {
+	rec, err := parseBatchRecord(line)
+	if err != nil {
+		return err
+	}
+
+	run := *c
+	run.SheetsFromStdin = false
+	run.Watch = false
+	run.SheetsFile = ""
+	run.StatsJSON = ""
+	run.Quiet = true
+	run.Sheet = rec.sheets
+	run.Dest = []string{rec.dest}
+	run.Vars = maps.Clone(c.Vars)
+	if run.Vars == nil {
+		run.Vars = make(map[string]string)
+	}
+	maps.Copy(run.Vars, rec.vars)
+	_, err = run.press(logger)
+	return err
+}
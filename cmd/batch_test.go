@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// withStdin replaces os.Stdin with input for the duration of the test
+func withStdin(t *testing.T, input string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "stdin")
+	if err := os.WriteFile(path, []byte(input), 0644); err != nil {
+		t.Fatalf("failed to write stdin file: %v", err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open stdin file: %v", err)
+	}
+	oldStdin := os.Stdin
+	os.Stdin = f
+	t.Cleanup(func() {
+		os.Stdin = oldStdin
+		f.Close()
+	})
+}
+
+func TestPressCmd_SheetsFromStdin(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "greet", map[string]string{"hello.txt.stamp": "Hello {{.name}} from {{.org}}"})
+	createSheet(t, configDir, "extra", map[string]string{"extra.txt": "extra"})
+
+	destA := filepath.Join(t.TempDir(), "a")
+	destB := filepath.Join(t.TempDir(), "b")
+	withStdin(t, "# batch\n"+
+		"greet|"+destA+"|name=alice\n"+
+		"\n"+
+		"greet,extra|"+destB+"|name=bob org=beta\n")
+
+	output, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"-c", configDir, "--sheets-from-stdin", "org=acme"})
+	})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v\n%s", err, output)
+	}
+
+	assertContent(t, filepath.Join(destA, "hello.txt"), "Hello alice from acme")
+	assertContent(t, filepath.Join(destB, "hello.txt"), "Hello bob from beta")
+	assertContent(t, filepath.Join(destB, "extra.txt"), "extra")
+	for _, want := range []string{"line 2: ok", "line 4: ok"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output = %q, want %q", output, want)
+		}
+	}
+}
+
+func TestPressCmd_SheetsFromStdinMalformed(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "greet", map[string]string{"hello.txt.stamp": "Hello {{.name}}"})
+	dest := filepath.Join(t.TempDir(), "out")
+	input := "not a record\ngreet|" + dest + "|name=alice\n"
+
+	// Without --keep-going the batch stops at the malformed record
+	withStdin(t, input)
+	output, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"-c", configDir, "--sheets-from-stdin"})
+	})
+	if err == nil || !strings.Contains(err.Error(), "1 of 1 batch records failed") {
+		t.Fatalf("Execute() error = %v, want the batch to stop after one failure", err)
+	}
+	if !strings.Contains(output, "line 1: failed") {
+		t.Errorf("output = %q, want line 1 reported as failed", output)
+	}
+
+	// With --keep-going the remaining records still run
+	withStdin(t, input)
+	_, err = captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"-c", configDir, "--sheets-from-stdin", "--keep-going"})
+	})
+	if err == nil || !strings.Contains(err.Error(), "1 of 2 batch records failed") {
+		t.Fatalf("Execute() error = %v, want one of two records failed", err)
+	}
+	assertContent(t, filepath.Join(dest, "hello.txt"), "Hello alice")
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/monochromegane/stamp/internal/stamp"
+)
+
+// check renders the sheets into a scratch directory and lists every file of the destination
+// that is missing or differs from the rendered output, without writing to the destination
+// Drift is reported as an error so scripts can rely on the exit status
+func (c *PressCmd) check(w io.Writer, logger stamp.Logger) error {
+	if c.DryRun || c.Watch || c.SheetsFromStdin || c.Dest[0] == stdoutDest {
+		return fmt.Errorf("--check can't be used with --dry-run, --watch, --sheets-from-stdin or -d %s", stdoutDest)
+	}
+
+	srcDirs, _, vars, err := c.prepare(logger)
+	if err != nil {
+		return err
+	}
+
+	scratch, err := os.MkdirTemp("", "stamp-check-")
+	if err != nil {
+		return fmt.Errorf("failed to create scratch directory: %w", err)
+	}
+	defer os.RemoveAll(scratch)
+
+	if _, err := c.newStamper(vars, logger, stamp.OverwriteError).ExecuteMultiple(srcDirs, scratch); err != nil {
+		return fmt.Errorf("stamp failed: %w", err)
+	}
+
+	drifted, err := checkTrees(w, scratch, c.Dest[0])
+	if err != nil {
+		return err
+	}
+	if drifted > 0 {
+		return fmt.Errorf("%d files in %s do not match the sheets", drifted, c.Dest[0])
+	}
+	if !c.Quiet {
+		fmt.Fprintf(w, "Destination %s matches the sheets\n", c.Dest[0])
+	}
+	return nil
+}
+
+// checkTrees writes a line for every regular file under rendered that is missing from dest
+// or differs from the file at the same path, and returns the number of such files
+func checkTrees(w io.Writer, rendered, dest string) (int, error) {
+	changes, err := compareTrees(rendered, dest)
+	if err != nil {
+		return 0, err
+	}
+	for _, change := range changes {
+		if change.missing {
+			fmt.Fprintf(w, "missing: %s\n", change.path)
+		} else {
+			fmt.Fprintf(w, "differs: %s\n", change.path)
+		}
+	}
+	return len(changes), nil
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPressCmd_Check(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "app", map[string]string{
+		"config.yaml.stamp": "name: {{.name}}\n",
+		"docs/README.md":    "docs\n",
+	})
+
+	destDir := t.TempDir()
+	if err := NewCLI().Execute([]string{"-s", "app", "-d", destDir, "-c", configDir, "-q", "name=alice"}); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	t.Run("matches", func(t *testing.T) {
+		output, err := captureStdout(t, func() error {
+			return NewCLI().Execute([]string{"-s", "app", "-d", destDir, "-c", configDir, "--check", "name=alice"})
+		})
+		if err != nil {
+			t.Fatalf("Execute() failed: %v", err)
+		}
+		if !strings.Contains(output, "matches the sheets") {
+			t.Errorf("output = %q, want a match message", output)
+		}
+	})
+
+	t.Run("drifted", func(t *testing.T) {
+		if err := os.WriteFile(filepath.Join(destDir, "config.yaml"), []byte("name: bob\n"), 0644); err != nil {
+			t.Fatalf("failed to edit destination: %v", err)
+		}
+		if err := os.Remove(filepath.Join(destDir, "docs", "README.md")); err != nil {
+			t.Fatalf("failed to remove destination file: %v", err)
+		}
+
+		output, err := captureStdout(t, func() error {
+			return NewCLI().Execute([]string{"-s", "app", "-d", destDir, "-c", configDir, "--check", "name=alice"})
+		})
+		if err == nil || !strings.Contains(err.Error(), "2 files") {
+			t.Errorf("Execute() error = %v, want 2 drifted files", err)
+		}
+		for _, want := range []string{"differs: config.yaml\n", "missing: docs/README.md\n"} {
+			if !strings.Contains(output, want) {
+				t.Errorf("output = %q, want it to contain %q", output, want)
+			}
+		}
+		// The destination is left as it was
+		assertContent(t, filepath.Join(destDir, "config.yaml"), "name: bob\n")
+	})
+
+	t.Run("conflicts", func(t *testing.T) {
+		err := NewCLI().Execute([]string{"-s", "app", "-d", "-", "-c", configDir, "--check", "name=alice"})
+		if err == nil || !strings.Contains(err.Error(), "--check can't be used") {
+			t.Errorf("Execute() error = %v, want --check to conflict with -d -", err)
+		}
+	})
+}
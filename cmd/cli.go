@@ -1,10 +1,24 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"maps"
 	"os"
+	"os/exec"
+	"path"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/alecthomas/kong"
 	"github.com/monochromegane/stamp/internal/config"
@@ -14,197 +28,594 @@ import (
 
 const cmdName = "stamp"
 
+// renderFlags decide which sheets are rendered and how, and are shared by press, diff and vars
+// so that diff always previews what press would write and vars lists what it would read
+type renderFlags struct {
+	Sheet                 []string `optional:"" help:"Sheet name(s) or glob patterns like 'web-*' from config directory (can specify multiple)" short:"s"`
+	SheetsFile            string   `optional:"" help:"File listing sheet names one per line, appended after -s sheets" type:"existingfile"`
+	Config                string   `optional:"" help:"Config directory path (overrides default)" short:"c"`
+	ConfigFile            string   `optional:"" placeholder:"PATH" help:"Global config file to load instead of stamp.yaml and stamp.toml (relative to the config directory unless absolute)"`
+	ConfigPrecedence      string   `optional:"" default:"global-wins" enum:"global-wins,sheet-wins" help:"Whether the global config or the sheets' defaults win when both set a variable: global-wins or sheet-wins"`
+	Ext                   string   `optional:"" default:".stamp" help:"Stamp file extension (default: .stamp)" short:"e"`
+	KeepExtension         bool     `optional:"" help:"Keep the stamp extension in the names of rendered files"`
+	NoopSuffix            string   `optional:"" default:".noop" help:"Suffix after the stamp extension that marks files copied verbatim (default: .noop)"`
+	IncludeSpecial        bool     `optional:"" help:"Recreate named pipes instead of skipping special files"`
+	MergeStrategy         string   `optional:"" default:"overwrite" enum:"overwrite,merge" help:"How to handle a file written by more than one sheet: overwrite or merge (line-union for ignore files, deep-merge for JSON/YAML)"`
+	VarFile               []string `optional:"" sep:"none" type:"path" placeholder:"PATH" help:"Load variables from a YAML or JSON file; repeatable, later files win"`
+	EnvVar                []string `optional:"" name:"env-var" sep:"none" placeholder:"NAME[=ENVNAME]" help:"Read variable NAME from environment variable ENVNAME (default: NAME); repeatable"`
+	SheetRoot             string   `optional:"" default:"sheets" env:"STAMP_SHEET_ROOT" help:"Config subdirectory that holds sheets (default: sheets)"`
+	SkipEmpty             bool     `optional:"" aliases:"prune-empty" help:"Do not create files whose template renders empty or whitespace-only output"`
+	SkipToolCheck         bool     `optional:"" help:"Do not check that tools required by the sheets are installed"`
+	StrictKeys            bool     `optional:"" help:"Fail rendering when a template reads a key that is not provided instead of writing <no value>"`
+	TypedVars             bool     `optional:"" help:"Pass values such as true, false or 8080 to templates as booleans and numbers instead of strings"`
+	TrimBlocks            bool     `optional:"" aliases:"trim" help:"Remove lines holding only control actions such as {{if}} or {{end}} from the output instead of leaving them blank"`
+	CopyBinary            bool     `optional:"" help:"Copy files with the template extension that look binary as is instead of failing"`
+	LeftDelim             string   `optional:"" default:"{{" help:"Opening delimiter of template actions (default: {{)"`
+	RightDelim            string   `optional:"" default:"}}" help:"Closing delimiter of template actions (default: }})"`
+	OutputPrefix          string   `optional:"" aliases:"dest-template" help:"Nest all output under this subdirectory of the destination (may use template variables)"`
+	Only                  []string `optional:"" sep:"none" placeholder:"GLOB" help:"Only write files whose destination path matches a glob pattern (repeatable)"`
+	LineEndings           string   `optional:"" default:"keep" enum:"keep,lf,crlf" help:"Convert line endings of written text files: keep, lf or crlf (overridden per path by eol in .stampattributes)"`
+	Dereference           bool     `optional:"" help:"Copy the targets of symlinks instead of recreating the links"`
+	AllowExternalSymlinks bool     `optional:"" help:"With --dereference, copy targets of symlinks that point outside the sheet instead of skipping them"`
+}
+
 type PressCmd struct {
-	Sheet  []string          `required:"" help:"Sheet name(s) from config directory (can specify multiple)" short:"s"`
-	Dest   string            `optional:"" default:"." help:"Destination directory to copy to (default: current directory)" short:"d"`
-	Config string            `optional:"" help:"Config directory path (overrides default)" short:"c"`
-	Ext    string            `optional:"" default:".stamp" help:"Stamp file extension (default: .stamp)" short:"e"`
-	Vars   map[string]string `arg:"" optional:"" help:"Template variables in KEY=VALUE format"`
+	renderFlags
+	Dest            []string          `optional:"" default:"." sep:"none" help:"Destination directory to copy to (default: current directory); repeat to press into several, or - to write a single-file sheet to stdout" short:"d"`
+	Quiet           bool              `optional:"" xor:"verbosity" help:"Suppress the success message and summary" short:"q"`
+	Verbose         bool              `optional:"" xor:"verbosity" help:"Print where each variable came from and every file as it is templated, copied, or skipped" short:"v"`
+	StatsJSON       string            `optional:"" name:"stats-json" help:"Write per-phase timing statistics as JSON to this path" type:"path"`
+	Manifest        string            `optional:"" placeholder:"PATH" help:"Write a JSON manifest of the pressed files to this path (relative to the destination unless absolute)"`
+	KeepGoing       bool              `optional:"" help:"Report files that cannot be written and continue with the rest"`
+	Jobs            int               `optional:"" default:"0" help:"Number of files of a sheet to process concurrently (default: GOMAXPROCS)" short:"j"`
+	Timeout         time.Duration     `optional:"" help:"Abort the press if it runs longer than this duration (e.g. 30s)"`
+	NoHooks         bool              `optional:"" help:"Do not run the post commands declared by the sheets"`
+	SheetsFromStdin bool              `optional:"" xor:"stdin" help:"Read batch records 'sheet[,sheet...]|dest|KEY=VALUE ...' from stdin and press each"`
+	Check           bool              `optional:"" help:"Compare the destination with what the sheets would generate and fail listing missing or differing files, without writing"`
+	DryRun          bool              `optional:"" help:"Print the diff against the destination and the post hooks that would run, without writing files or running hooks"`
+	Watch           bool              `optional:"" help:"Re-render into a scratch directory whenever the sheets change and print the content diff, without writing to the destination (Ctrl-C to stop)"`
+	Force           bool              `optional:"" xor:"overwrite" help:"Overwrite files that already exist in the destination" short:"f"`
+	SkipExisting    bool              `optional:"" xor:"overwrite" help:"Keep files that already exist in the destination and warn instead of failing"`
+	Overwrite       string            `optional:"" default:"error" enum:"error,always,never,if-changed" help:"How to handle files that already exist in the destination: error, always, never, or if-changed (only when the content differs)"`
+	Interactive     bool              `optional:"" xor:"stdin" help:"Prompt for missing variables when stdin is a terminal" short:"i"`
+	StrictUnused    bool              `optional:"" help:"Fail instead of warning when config files or arguments set variables no template uses"`
+	Vars            map[string]string `arg:"" optional:"" help:"Template variables in KEY=VALUE format"`
+
+	varSources map[string]string // Config file or command line that set each variable, for unused warnings
+	resolved   map[string]string // Layer that supplied the final value of each variable, for --verbose
 }
 
-func (c *PressCmd) Run(ctx *kong.Context) error {
-	// 1. Resolve config directory
-	configDir, err := configdir.GetConfigDirWithOverride(c.Config)
-	if err != nil {
-		return err
+func (c *PressCmd) Run(ctx *kong.Context, logger stamp.Logger) error {
+	if c.Verbose {
+		logger = &verboseLogger{Logger: logger, w: os.Stdout}
 	}
-
-	// 2. Resolve ALL sheet directories upfront
-	srcDirs, err := configdir.ResolveTemplateDirs(configDir, c.Sheet)
-	if err != nil {
+	if c.Overwrite != stamp.OverwriteError && (c.Force || c.SkipExisting) {
+		return fmt.Errorf("--overwrite can't be used with --force or --skip-existing")
+	}
+	if err := c.checkOnly(); err != nil {
 		return err
 	}
-
-	// 3. Build merged variables with priority: CLI args > last sheet > ... > first sheet > global
-	mergedVars, err := c.buildVariablesForMultipleTemplates(configDir)
-	if err != nil {
+	if err := c.checkDests(); err != nil {
 		return err
 	}
-
-	// 4. Execute stamper with multiple sheets
-	stamper := stamp.New(mergedVars, c.Ext)
-	if err := stamper.ExecuteMultiple(srcDirs, c.Dest); err != nil {
-		return fmt.Errorf("stamp failed: %w", err)
+	if c.Check {
+		return c.check(os.Stdout, logger)
+	}
+	if c.DryRun {
+		if c.Watch || c.SheetsFromStdin || c.Dest[0] == stdoutDest {
+			return fmt.Errorf("--dry-run can't be used with --watch, --sheets-from-stdin or -d %s", stdoutDest)
+		}
+		return c.dryRun(os.Stdout, logger)
 	}
+	if c.Dest[0] == stdoutDest {
+		return c.pressToStdout(os.Stdout, logger)
+	}
+	if c.SheetsFromStdin {
+		return c.runBatch(os.Stdin, logger)
+	}
+	if c.Watch {
+		return c.watch(logger)
+	}
+	_, err := c.press(logger)
+	return err
+}
 
-	// 5. Print success message
-	if len(c.Sheet) == 1 {
-		fmt.Fprintf(os.Stdout, "Successfully stamped sheet '%s' to %s\n", c.Sheet[0], c.Dest)
-	} else {
-		fmt.Fprintf(os.Stdout, "Successfully stamped sheets %v to %s\n", c.Sheet, c.Dest)
+// checkOnly rejects malformed --only patterns before anything is resolved
+func (f *renderFlags) checkOnly() error {
+	for _, pattern := range f.Only {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid --only pattern %q: %w", pattern, err)
+		}
 	}
 	return nil
 }
 
-// buildVariablesForMultipleTemplates implements hierarchical priority:
-// 1. CLI args (highest priority)
-// 2. Last sheet's config
-// 3. Middle sheets' configs
-// 4. First sheet's config
-// 5. Global config (lowest priority)
-func (c *PressCmd) buildVariablesForMultipleTemplates(configDir string) (map[string]string, error) {
-	// Load hierarchical configs: global + all sheets (in order)
-	mergedVars, err := config.LoadHierarchicalMultiple(configDir, c.Sheet)
-	if err != nil {
-		return nil, fmt.Errorf("config error: %w", err)
+// checkDests rejects repeated destinations and modes that work on a single destination
+func (c *PressCmd) checkDests() error {
+	if len(c.Dest) < 2 {
+		return nil
+	}
+	if c.Check || c.DryRun || c.Watch || c.SheetsFromStdin || slices.Contains(c.Dest, stdoutDest) {
+		return fmt.Errorf("-d can't be repeated with --check, --dry-run, --watch, --sheets-from-stdin or -d %s", stdoutDest)
+	}
+	if filepath.IsAbs(c.Manifest) {
+		return fmt.Errorf("-d can't be repeated with an absolute --manifest path")
 	}
+	for i, dest := range c.Dest {
+		if slices.Contains(c.Dest[:i], dest) {
+			return fmt.Errorf("destination %s is given more than once", dest)
+		}
+	}
+	return nil
+}
 
-	// Override with CLI args (highest priority)
-	maps.Copy(mergedVars, c.Vars)
+// resolveSheets returns the config directories searched for sheets and the directories of all requested sheets
+func (c *PressCmd) resolveSheets() ([]string, []string, error) {
+	// Append sheets listed in --sheets-file after any -s flags, once
+	if c.SheetsFile != "" {
+		sheets, err := readSheetsFile(c.SheetsFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		c.Sheet = append(c.Sheet, sheets...)
+		c.SheetsFile = ""
+	}
+	if len(c.Sheet) == 0 {
+		return nil, nil, fmt.Errorf("no sheets specified: use -s <sheet> or --sheets-file <path>")
+	}
+	if err := configdir.ValidateSheetRoot(c.SheetRoot); err != nil {
+		return nil, nil, err
+	}
 
-	return mergedVars, nil
+	configDir, err := configdir.GetConfigDirWithOverride(c.Config)
+	if err != nil {
+		return nil, nil, err
+	}
+	configDirs := configdir.SearchPath(configDir)
+	if c.Sheet, err = configdir.ExpandSheetGlobs(configDirs, c.SheetRoot, c.Sheet); err != nil {
+		return nil, nil, err
+	}
+	srcDirs, err := configdir.ResolveTemplateDirsInRoots(configDirs, c.SheetRoot, c.Sheet)
+	if err != nil {
+		return nil, nil, err
+	}
+	return configDirs, srcDirs, nil
 }
 
-type CollectCmd struct {
-	Sheet     string `required:"" help:"Sheet name to create" short:"s"`
-	Source    string `arg:"" optional:"" default:"." help:"Source file or directory to collect (default: current directory)"`
-	Config    string `optional:"" help:"Config directory path (overrides default)" short:"c"`
-	Template  bool   `optional:"" help:"Treat collected files as templates (add .stamp extension)" short:"t"`
-	Ext       string `optional:"" default:".stamp" help:"Template extension to add when --template is set (default: .stamp)" short:"e"`
-	Recursive bool   `optional:"" default:"true" negatable:"" help:"Recursively copy directories (default: true, use --no-recursive to disable)" short:"r"`
-}
+// press stamps the configured sheets into each destination
+// Variables are resolved and templates validated once; the Results are in destination order
+func (c *PressCmd) press(logger stamp.Logger) ([]*stamp.Result, error) {
+	start := time.Now()
 
-func (c *CollectCmd) Run(ctx *kong.Context) error {
-	// 1. Resolve config directory
-	configDir, err := configdir.GetConfigDirWithOverride(c.Config)
+	runCtx := context.Background()
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(runCtx, c.Timeout)
+		defer cancel()
+	}
+
+	// 1-2. Resolve sheets and build merged variables
+	srcDirs, sheets, mergedVars, err := c.prepare(logger)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// 2. Validate source path exists
-	srcInfo, err := os.Stat(c.Source)
+	resolution := time.Since(start)
+
+	// 3. Execute stamper with multiple sheets
+	stamper := c.newStamper(mergedVars, logger, c.overwritePolicy())
+	results, err := stamper.ExecuteMultipleToMany(runCtx, srcDirs, c.Dest)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return nil, fmt.Errorf("stamp timed out after %s: %w", c.Timeout, err)
+	}
+	if errors.Is(err, stamp.ErrExists) {
+		return nil, fmt.Errorf("stamp failed: %w (use --force to overwrite or --skip-existing to keep it)", err)
+	}
 	if err != nil {
-		if os.IsNotExist(err) {
-			return fmt.Errorf("source path not found: %s", c.Source)
+		return nil, fmt.Errorf("stamp failed: %w", err)
+	}
+
+	if c.StatsJSON != "" {
+		if err := c.writeStats(resolution, time.Since(start), results); err != nil {
+			return nil, err
 		}
-		return fmt.Errorf("failed to stat source: %w", err)
 	}
 
-	// 3. Build destination: {configDir}/sheets/{Sheet}/
-	destDir := filepath.Join(configDir, "sheets", c.Sheet)
+	for i, dest := range c.Dest {
+		if c.Manifest != "" {
+			if err := c.writeManifest(results[i], dest); err != nil {
+				return nil, err
+			}
+		}
 
-	// 4. Check if sheet already exists
-	if _, err := os.Stat(destDir); !os.IsNotExist(err) {
-		return fmt.Errorf("sheet '%s' already exists at %s", c.Sheet, destDir)
+		// 4. Print success message and summary
+		if !c.Quiet {
+			if len(c.Sheet) == 1 {
+				fmt.Fprintf(os.Stdout, "Successfully stamped sheet '%s' to %s\n", c.Sheet[0], dest)
+			} else {
+				fmt.Fprintf(os.Stdout, "Successfully stamped sheets %v to %s\n", c.Sheet, dest)
+			}
+			fmt.Fprintf(os.Stdout, "Summary: %s\n", results[i])
+		}
 	}
 
-	// 5. Create destination directory
-	if err := os.MkdirAll(destDir, 0755); err != nil {
-		return fmt.Errorf("failed to create sheet directory: %w", err)
+	// 5. Run the sheets' post hooks where each destination received the output
+	if !c.NoHooks {
+		for _, result := range results {
+			if err := c.runHooks(sheets, result.OutputDir); err != nil {
+				return nil, err
+			}
+		}
 	}
+	return results, nil
+}
 
-	// 6. Copy files
-	if srcInfo.IsDir() {
-		if err := c.copyDirWithSkip(c.Source, destDir); err != nil {
-			return err
-		}
-	} else {
-		destPath := filepath.Join(destDir, filepath.Base(c.Source))
-		if err := c.copyFileWithTemplate(c.Source, destPath); err != nil {
-			return err
+// prepare resolves the requested sheets, checks their settings, and builds the merged variables
+func (c *PressCmd) prepare(logger stamp.Logger) ([]string, []*config.Sheet, map[string]string, error) {
+	if err := validateVarKeys(c.Vars); err != nil {
+		return nil, nil, nil, err
+	}
+
+	// 1. Resolve config directory and ALL sheet directories upfront
+	configDirs, srcDirs, err := c.resolveSheets()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	for i, dir := range srcDirs {
+		logger.Log(stamp.Event{Event: stamp.EventSheetResolved, Path: dir, Sheet: c.Sheet[i]})
+	}
+	sheets, err := c.checkSheets(srcDirs, version)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// 2. Build merged variables with priority: CLI args > environment > global > sheet defaults
+	mergedVars, err := c.buildVariablesForMultipleTemplates(configDirs, sheets)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if c.Verbose {
+		c.printResolved(os.Stdout)
+	}
+	if err := c.checkUnusedVars(srcDirs, logger); err != nil {
+		return nil, nil, nil, err
+	}
+	if c.Interactive && stdinIsTerminal() {
+		if err := c.promptMissingVars(os.Stdin, os.Stderr, srcDirs, mergedVars); err != nil {
+			return nil, nil, nil, err
 		}
 	}
+	if err := c.checkSchemas(srcDirs, mergedVars); err != nil {
+		return nil, nil, nil, err
+	}
+	return srcDirs, sheets, mergedVars, nil
+}
 
-	// 7. Print success message
-	fmt.Fprintf(os.Stdout, "Successfully collected to sheet '%s' at %s\n", c.Sheet, destDir)
-	return nil
+// newStamper builds a Stamper configured by the press flags
+func (c *PressCmd) newStamper(vars map[string]string, logger stamp.Logger, overwrite string) *stamp.Stamper {
+	return stamp.New(vars, c.Ext,
+		stamp.WithLogger(logger),
+		stamp.WithIncludeSpecial(c.IncludeSpecial),
+		stamp.WithKeepGoing(c.KeepGoing),
+		stamp.WithJobs(c.Jobs),
+		stamp.WithMergeStrategy(c.MergeStrategy),
+		stamp.WithSkipEmpty(c.SkipEmpty),
+		stamp.WithStrictKeys(c.StrictKeys),
+		stamp.WithTypedVars(c.TypedVars),
+		stamp.WithTrimBlocks(c.TrimBlocks),
+		stamp.WithCopyBinary(c.CopyBinary),
+		stamp.WithDelims(c.LeftDelim, c.RightDelim),
+		stamp.WithNoopSuffix(c.NoopSuffix),
+		stamp.WithKeepExtension(c.KeepExtension),
+		stamp.WithOutputPrefix(c.OutputPrefix),
+		stamp.WithOnly(c.Only),
+		stamp.WithLineEndings(c.LineEndings),
+		stamp.WithDereference(c.Dereference),
+		stamp.WithAllowExternalSymlinks(c.AllowExternalSymlinks),
+		stamp.WithFuncs(stamp.StringFuncs()),
+		stamp.WithOverwrite(overwrite),
+	)
 }
 
-func (c *CollectCmd) copyDirWithSkip(src, dest string) error {
-	// Non-recursive mode: only copy files directly in src directory
-	if !c.Recursive {
-		entries, err := os.ReadDir(src)
-		if err != nil {
-			return fmt.Errorf("failed to read directory: %w", err)
+// runStats is the --stats-json document
+type runStats struct {
+	Phases struct {
+		ResolutionMS float64 `json:"resolution_ms"`
+		ValidationMS float64 `json:"validation_ms"`
+		ProcessingMS float64 `json:"processing_ms"`
+		TotalMS      float64 `json:"total_ms"`
+	} `json:"phases"`
+	Sheets []sheetStats `json:"sheets"`
+}
+
+type sheetStats struct {
+	Sheet      string  `json:"sheet"`
+	Files      int     `json:"files"`
+	DurationMS float64 `json:"duration_ms"`
+}
+
+// writeStats writes per-phase timings and per-sheet file counts to --stats-json
+// With several destinations, processing times and file counts are summed over them
+func (c *PressCmd) writeStats(resolution, total time.Duration, results []*stamp.Result) error {
+	var stats runStats
+	stats.Phases.ResolutionMS = milliseconds(resolution)
+	stats.Phases.ValidationMS = milliseconds(results[0].Validation)
+	stats.Phases.TotalMS = milliseconds(total)
+	stats.Sheets = []sheetStats{}
+	for i := range results[0].Sheets {
+		stats.Sheets = append(stats.Sheets, sheetStats{Sheet: c.Sheet[i]})
+	}
+	for _, result := range results {
+		stats.Phases.ProcessingMS += milliseconds(result.Processing)
+		for i, sheet := range result.Sheets {
+			stats.Sheets[i].Files += sheet.Files
+			stats.Sheets[i].DurationMS += milliseconds(sheet.Duration)
 		}
+	}
 
-		for _, entry := range entries {
-			// Skip .git
-			if entry.Name() == ".git" {
-				continue
-			}
+	data, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode stats: %w", err)
+	}
+	if err := os.WriteFile(c.StatsJSON, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write stats file: %w", err)
+	}
+	return nil
+}
 
-			// Skip directories in non-recursive mode
-			if entry.IsDir() {
-				continue
-			}
+// milliseconds converts d to fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// buildVariablesForMultipleTemplates implements hierarchical priority:
+// 1. CLI args (highest priority)
+// 2. Environment variables requested with --env-var
+// 3. Files given with --var-file, later files first
+// 4. STAMP_VAR_* environment variables
+// 5. Global config, earlier config directories first, or the file given with --config-file
+// 6. Last sheet's defaults
+// 7. Earlier sheets' defaults (lowest priority)
+// With --config-precedence sheet-wins, the sheets' defaults rank above the global config
+func (c *PressCmd) buildVariablesForMultipleTemplates(configDirs []string, sheets []*config.Sheet) (map[string]string, error) {
+	c.varSources, c.resolved = nil, nil
+	globalVars, globalSources, err := c.loadGlobalVars(configDirs)
+	if err != nil {
+		return nil, fmt.Errorf("config error: %w", err)
+	}
 
-			srcPath := filepath.Join(src, entry.Name())
-			destPath := filepath.Join(dest, entry.Name())
-			if err := c.copyFileWithTemplate(srcPath, destPath); err != nil {
-				return err
+	// Start from the sheet defaults and the global config, the winner applied last
+	mergedVars := make(map[string]string)
+	applyDefaults := func() {
+		maps.Copy(mergedVars, config.MergeDefaults(sheets))
+		for i, sheet := range sheets {
+			for k := range sheet.Defaults {
+				c.recordResolved(k, "default of sheet "+c.Sheet[i])
 			}
 		}
-		return nil
+	}
+	applyGlobal := func() {
+		maps.Copy(mergedVars, globalVars)
+		for k, source := range globalSources {
+			c.recordResolved(k, source)
+		}
+	}
+	if c.ConfigPrecedence == precedenceSheetWins {
+		applyGlobal()
+		applyDefaults()
+	} else {
+		applyDefaults()
+		applyGlobal()
+	}
+	if c.ConfigFile != "" {
+		c.recordSources(globalVars, "--config-file "+c.ConfigFile)
+	} else {
+		c.recordSources(globalVars, sourceConfig)
 	}
 
-	// Recursive mode: use filepath.Walk
-	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+	// Override with STAMP_VAR_* environment variables
+	envVars := make(map[string]string)
+	if err := applyPrefixedEnv(envVars, os.Environ()); err != nil {
+		return nil, err
+	}
+	maps.Copy(mergedVars, envVars)
+	for k := range envVars {
+		c.recordResolved(k, "environment "+envVarPrefix+k)
+	}
+
+	// Override with variable files, merged left to right
+	for _, path := range c.VarFile {
+		fileVars, err := config.Load(path)
 		if err != nil {
-			return err
+			return nil, fmt.Errorf("--var-file: %w", err)
+		}
+		if err := validateVarKeys(fileVars); err != nil {
+			return nil, fmt.Errorf("--var-file %s: %w", path, err)
 		}
+		maps.Copy(mergedVars, fileVars)
+		c.recordSources(fileVars, "--var-file "+path)
+		for k := range fileVars {
+			c.recordResolved(k, "--var-file "+path)
+		}
+	}
 
-		relPath, err := filepath.Rel(src, path)
+	// Override with requested environment variables
+	if err := c.applyEnvVars(mergedVars); err != nil {
+		return nil, err
+	}
+
+	// Override with CLI args (highest priority)
+	maps.Copy(mergedVars, c.Vars)
+	c.recordSources(c.Vars, sourceArgs)
+	for k := range c.Vars {
+		c.recordResolved(k, sourceArgs)
+	}
+
+	return mergedVars, nil
+}
+
+// precedenceSheetWins is the --config-precedence value that ranks sheet defaults above the global config
+const precedenceSheetWins = "sheet-wins"
+
+// loadGlobalVars loads the global config layer and the file that supplied each key
+// --config-file replaces the stamp.yaml and stamp.toml files of every config directory
+func (c *PressCmd) loadGlobalVars(configDirs []string) (map[string]string, map[string]string, error) {
+	if c.ConfigFile != "" {
+		vars, err := config.LoadGlobalFile(configDirs[0], c.ConfigFile)
 		if err != nil {
-			return fmt.Errorf("failed to get relative path: %w", err)
+			return nil, nil, err
 		}
+		sources := make(map[string]string, len(vars))
+		for k := range vars {
+			sources[k] = "--config-file " + c.ConfigFile
+		}
+		return vars, sources, nil
+	}
+	return config.LoadGlobalWithSources(configDirs)
+}
 
-		// Skip .git (both directory and file for git worktree support)
-		if info.Name() == ".git" {
-			if info.IsDir() {
-				return filepath.SkipDir
-			}
-			return nil // Skip file
+// envVarPrefix marks environment variables that provide template variables
+const envVarPrefix = "STAMP_VAR_"
+
+// applyPrefixedEnv copies STAMP_VAR_<name>=<value> entries of environ into vars
+// The name after the prefix is used verbatim and must be a valid variable name
+func applyPrefixedEnv(vars map[string]string, environ []string) error {
+	for _, kv := range environ {
+		key, value, _ := strings.Cut(kv, "=")
+		name, ok := strings.CutPrefix(key, envVarPrefix)
+		if !ok {
+			continue
+		}
+		if !varKeyPattern.MatchString(name) {
+			return fmt.Errorf("invalid variable name %q in environment variable %s", name, key)
 		}
+		vars[name] = value
+	}
+	return nil
+}
 
-		destPath := filepath.Join(dest, relPath)
+// applyEnvVars copies the variables requested with --env-var from the environment
+// An unset environment variable is an error unless config or CLI args provide the variable
+func (c *PressCmd) applyEnvVars(vars map[string]string) error {
+	for _, spec := range c.EnvVar {
+		name, envName, found := strings.Cut(spec, "=")
+		if !found {
+			envName = name
+		}
+		if !varKeyPattern.MatchString(name) || envName == "" {
+			return fmt.Errorf("invalid --env-var %q: want NAME or NAME=ENVNAME", spec)
+		}
 
-		if info.IsDir() {
-			return os.MkdirAll(destPath, 0755)
+		value, ok := os.LookupEnv(envName)
+		if ok {
+			vars[name] = value
+			c.recordResolved(name, "environment "+envName)
+			continue
 		}
+		_, inConfig := vars[name]
+		_, inArgs := c.Vars[name]
+		if !inConfig && !inArgs {
+			return fmt.Errorf("environment variable %s is not set (requested by --env-var %s)", envName, spec)
+		}
+	}
+	return nil
+}
 
-		return c.copyFileWithTemplate(path, destPath)
-	})
+// overwritePolicy maps --force, --skip-existing and --overwrite to a stamp overwrite policy
+func (c *PressCmd) overwritePolicy() string {
+	switch {
+	case c.Force || c.Overwrite == "always":
+		return stamp.OverwriteForce
+	case c.SkipExisting || c.Overwrite == "never":
+		return stamp.OverwriteSkip
+	case c.Overwrite == "if-changed":
+		return stamp.OverwriteIfChanged
+	}
+	return stamp.OverwriteError
 }
 
-func (c *CollectCmd) copyFileWithTemplate(src, dest string) error {
-	content, err := os.ReadFile(src)
-	if err != nil {
-		return fmt.Errorf("failed to read file %s: %w", src, err)
+// checkSheets loads each sheet's settings and enforces min_version and, unless skipped, requires_tools
+func (c *PressCmd) checkSheets(srcDirs []string, running string) ([]*config.Sheet, error) {
+	sheets := make([]*config.Sheet, len(srcDirs))
+	for i, dir := range srcDirs {
+		sheet, err := config.LoadSheet(dir)
+		if err != nil {
+			return nil, err
+		}
+		if err := sheet.CheckMinVersion(running); err != nil {
+			return nil, fmt.Errorf("sheet '%s': %w", c.Sheet[i], err)
+		}
+		if err := validateVarKeys(sheet.Defaults); err != nil {
+			return nil, fmt.Errorf("sheet '%s': defaults: %w", c.Sheet[i], err)
+		}
+		sheets[i] = sheet
+	}
+	if !c.SkipToolCheck {
+		if err := c.checkRequiredTools(sheets); err != nil {
+			return nil, err
+		}
 	}
+	return sheets, nil
+}
 
-	// Add extension if template flag is set
-	if c.Template {
-		dest = dest + c.Ext
+// checkRequiredTools fails if any tool listed in a sheet's requires_tools is not on PATH
+func (c *PressCmd) checkRequiredTools(sheets []*config.Sheet) error {
+	var missing []string
+	for i, sheet := range sheets {
+		for _, tool := range sheet.RequiresTools {
+			if _, err := exec.LookPath(tool); err != nil {
+				missing = append(missing, fmt.Sprintf("  - %s (required by sheet '%s')", tool, c.Sheet[i]))
+			}
+		}
 	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing required tools:\n%s\n\nInstall them, or pass --skip-tool-check to stamp anyway", strings.Join(missing, "\n"))
+	}
+	return nil
+}
 
-	if err := os.WriteFile(dest, content, 0644); err != nil {
-		return fmt.Errorf("failed to write file %s: %w", dest, err)
+// varKeyPattern matches valid positional variable names, with "." separating nested names
+// Nested parts may also be list indexes, as in items.0
+var varKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*(\.([A-Za-z_][A-Za-z0-9_-]*|[0-9]+))*$`)
+
+// validateVarKeys rejects positional variables whose keys are not identifiers
+// This catches mistyped flags such as -dest=x that would otherwise become variables
+func validateVarKeys(vars map[string]string) error {
+	keys := make([]string, 0, len(vars))
+	for k := range vars {
+		keys = append(keys, k)
 	}
+	sort.Strings(keys)
 
+	for _, k := range keys {
+		if strings.HasPrefix(k, "-") {
+			return fmt.Errorf("variable %q looks like a misspelled flag; flags must come before variables and use a known name (see --help)", k)
+		}
+		if !varKeyPattern.MatchString(k) {
+			return fmt.Errorf("invalid variable name %q: each '.'-separated part must start with a letter or underscore and contain only letters, digits, '_' or '-', or be a list index after the first part", k)
+		}
+	}
 	return nil
 }
 
+// readSheetsFile reads sheet names one per line, ignoring blank lines and # comments
+func readSheetsFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sheets file: %w", err)
+	}
+
+	var sheets []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		sheets = append(sheets, line)
+	}
+	return sheets, nil
+}
+
 type ConfigDirCmd struct {
 	Config string `optional:"" help:"Config directory path (overrides default)" short:"c"`
 }
@@ -219,19 +630,94 @@ func (c *ConfigDirCmd) Run(ctx *kong.Context) error {
 	return nil
 }
 
+type VersionCmd struct {
+	JSON bool `optional:"" help:"Print build metadata as JSON"`
+}
+
+// buildMetadata describes the running binary
+type buildMetadata struct {
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	Revision  string `json:"revision"`
+	GoVersion string `json:"go_version"`
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+}
+
+// currentBuild returns metadata from ldflags, falling back to debug.ReadBuildInfo
+func currentBuild() buildMetadata {
+	meta := buildMetadata{
+		Name:      cmdName,
+		Version:   version,
+		Revision:  revision,
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+	}
+
+	// revision is only set via ldflags; use the VCS stamp from the build otherwise
+	if info, ok := debug.ReadBuildInfo(); ok && meta.Revision == "HEAD" {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" && setting.Value != "" {
+				meta.Revision = setting.Value
+				if len(meta.Revision) > 7 {
+					meta.Revision = meta.Revision[:7]
+				}
+			}
+		}
+	}
+	return meta
+}
+
+func (c *VersionCmd) Run(ctx *kong.Context) error {
+	meta := currentBuild()
+	if c.JSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(meta)
+	}
+
+	fmt.Fprintf(os.Stdout, "%s v%s\n", meta.Name, meta.Version)
+	fmt.Fprintf(os.Stdout, "revision:   %s\n", meta.Revision)
+	fmt.Fprintf(os.Stdout, "go version: %s\n", meta.GoVersion)
+	fmt.Fprintf(os.Stdout, "platform:   %s/%s\n", meta.OS, meta.Arch)
+	return nil
+}
+
 type CLI struct {
-	Version   kong.VersionFlag `help:"Show version"`
-	Press     PressCmd         `cmd:"" default:"withargs" help:"Copy directory structure with template expansion"`
-	Collect   CollectCmd       `cmd:"" help:"Collect directory or files as a new sheet"`
-	ConfigDir ConfigDirCmd     `cmd:"" help:"Print config directory path"`
+	Version       kong.VersionFlag `help:"Show version"`
+	JSONLogs      bool             `name:"json-logs" help:"Emit structured JSON log events on stderr"`
+	FailOnWarning bool             `help:"Exit with an error if any warning was emitted"`
+	Press         PressCmd         `cmd:"" default:"withargs" help:"Copy directory structure with template expansion"`
+	Collect       CollectCmd       `cmd:"" help:"Collect directory or files as a new sheet"`
+	List          ListCmd          `cmd:"" help:"List available sheets"`
+	Delete        DeleteCmd        `cmd:"" help:"Delete a sheet from the config directory"`
+	Show          ShowCmd          `cmd:"" help:"Print the file tree of a sheet"`
+	Diff          DiffCmd          `cmd:"" help:"Show how pressing sheets would change an existing destination"`
+	Vars          VarsCmd          `cmd:"" help:"List the variables used by sheets and whether config provides them"`
+	Validate      ValidateCmd      `cmd:"" help:"Check that sheet templates parse, without pressing anything"`
+	ConfigDir     ConfigDirCmd     `cmd:"" help:"Print config directory path"`
+	VersionCmd    VersionCmd       `cmd:"" name:"version" help:"Print build metadata"`
+	Debug         DebugCmd         `cmd:"" hidden:"" help:"Debugging aids for sheet authors"`
+
+	stderr io.Writer // Destination for log events
 }
 
 func NewCLI() *CLI {
-	return &CLI{}
+	return &CLI{stderr: os.Stderr}
 }
 
-func (c *CLI) Execute(args []string) error {
-	parser := kong.Must(c,
+// logger returns the Logger selected by the global flags
+func (c *CLI) logger() stamp.Logger {
+	if c.JSONLogs {
+		return stamp.NewJSONLogger(c.stderr)
+	}
+	return stamp.NewTextLogger(c.stderr)
+}
+
+// parser builds the kong parser for the CLI
+func (c *CLI) parser() *kong.Kong {
+	return kong.Must(c,
 		kong.Name(cmdName),
 		kong.Description("A CLI tool for copying directory structures with Go template expansion"),
 		kong.UsageOnError(),
@@ -239,9 +725,64 @@ func (c *CLI) Execute(args []string) error {
 			"version": fmt.Sprintf("%s v%s (rev:%s)", cmdName, version, revision),
 		},
 	)
-	ctx, err := parser.Parse(args)
+}
+
+func (c *CLI) Execute(args []string) error {
+	ctx, err := c.parser().Parse(args)
 	if err != nil {
 		return err
 	}
-	return ctx.Run()
+	logger := &warningCounter{Logger: c.logger()}
+	ctx.BindTo(logger, (*stamp.Logger)(nil))
+	if err := ctx.Run(); err != nil {
+		return err
+	}
+
+	// The operation completes and all warnings are printed before failing
+	if c.FailOnWarning && logger.warnings > 0 {
+		return fmt.Errorf("%d warning(s) emitted (--fail-on-warning)", logger.warnings)
+	}
+	return nil
+}
+
+// warningCounter wraps a Logger and counts warning events
+type warningCounter struct {
+	stamp.Logger
+	mu       sync.Mutex
+	warnings int
+}
+
+func (w *warningCounter) Log(e stamp.Event) {
+	if e.Event == stamp.EventWarning {
+		w.mu.Lock()
+		w.warnings++
+		w.mu.Unlock()
+	}
+	w.Logger.Log(e)
+}
+
+// verboseLogger wraps a Logger and prints every written or skipped file to w
+// Files that replace one written by an earlier sheet name both sheets
+type verboseLogger struct {
+	stamp.Logger
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (v *verboseLogger) Log(e stamp.Event) {
+	switch e.Event {
+	case stamp.EventFileWritten:
+		v.mu.Lock()
+		if e.Overwrote != "" {
+			fmt.Fprintf(v.w, "%-9s %s from sheet %s (overwrote sheet %s)\n", e.Action, e.Path, e.Sheet, e.Overwrote)
+		} else {
+			fmt.Fprintf(v.w, "%-9s %s\n", e.Action, e.Path)
+		}
+		v.mu.Unlock()
+	case stamp.EventFileSkipped:
+		v.mu.Lock()
+		fmt.Fprintf(v.w, "%-9s %s (%s)\n", "skipped", e.Path, e.Message)
+		v.mu.Unlock()
+	}
+	v.Logger.Log(e)
 }
@@ -2,11 +2,18 @@ package cmd
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"maps"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/monochromegane/stamp/internal/config"
 )
 
 func TestNewCLI(t *testing.T) {
@@ -736,3 +743,1345 @@ func TestCollectCmd_NonRecursive(t *testing.T) {
 		t.Error("subdir/file2.txt should not exist in non-recursive mode")
 	}
 }
+
+func TestPressCmd_Summary(t *testing.T) {
+	configDir := t.TempDir()
+	destDir := t.TempDir()
+
+	// Create a mixed sheet: one template, one static file, one noop file
+	sheetDir := filepath.Join(configDir, "sheets", "mixed")
+	if err := os.MkdirAll(sheetDir, 0755); err != nil {
+		t.Fatalf("failed to create sheet dir: %v", err)
+	}
+	files := map[string]string{
+		"hello.txt.stamp":    "Hello {{.name}}!",
+		"static.txt":         "static",
+		"raw.txt.stamp.noop": "{{.raw}}",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(sheetDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to create %s: %v", name, err)
+		}
+	}
+
+	// Pre-existing destination file is overwritten
+	if err := os.WriteFile(filepath.Join(destDir, "static.txt"), []byte("old"), 0644); err != nil {
+		t.Fatalf("failed to create existing file: %v", err)
+	}
+
+	output, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"-s", "mixed", "-d", destDir, "-c", configDir, "--force", "name=alice"})
+	})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	// "Hello alice!" (12) + "static" (6) + "{{.raw}}" (8)
+	want := "Summary: 3 files written (1 templated, 2 copied), 0 skipped, 1 overwritten, 26 bytes"
+	if !strings.Contains(output, want) {
+		t.Errorf("output = %q, want it to contain %q", output, want)
+	}
+}
+
+func TestPressCmd_QuietSuppressesSummary(t *testing.T) {
+	configDir := t.TempDir()
+	destDir := t.TempDir()
+
+	sheetDir := filepath.Join(configDir, "sheets", "basic")
+	if err := os.MkdirAll(sheetDir, 0755); err != nil {
+		t.Fatalf("failed to create sheet dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(sheetDir, "a.txt"), []byte("a"), 0644); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+
+	output, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"-s", "basic", "-d", destDir, "-c", configDir, "-q"})
+	})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if output != "" {
+		t.Errorf("output = %q, want empty output with --quiet", output)
+	}
+}
+
+func TestPressCmd_VerboseListsFiles(t *testing.T) {
+	configDir := t.TempDir()
+	destDir := t.TempDir()
+	createSheet(t, configDir, "basic", map[string]string{
+		"a.txt":           "a",
+		"b.txt.stamp":     "{{.name}}",
+		"ci.yaml.stamp":   "{{if .ci}}ci: true{{end}}",
+		"sub/c.txt.stamp": "c",
+	})
+
+	output, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"-s", "basic", "-d", destDir, "-c", configDir, "-v", "--skip-empty", "name=x", "ci="})
+	})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	for _, want := range []string{
+		"copied    a.txt\n",
+		"templated b.txt\n",
+		"templated sub/c.txt\n",
+		"skipped   ci.yaml (empty output)\n",
+		"Successfully stamped sheet 'basic'",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output = %q, want it to contain %q", output, want)
+		}
+	}
+
+	err = NewCLI().Execute([]string{"-s", "basic", "-d", destDir, "-c", configDir, "-q", "-v"})
+	if err == nil || !strings.Contains(err.Error(), "can't be used together") {
+		t.Errorf("Execute() error = %v, want --quiet and --verbose to conflict", err)
+	}
+}
+
+func TestPressCmd_VerboseListsSkippedExisting(t *testing.T) {
+	configDir := t.TempDir()
+	destDir := t.TempDir()
+	createSheet(t, configDir, "basic", map[string]string{"a.txt": "a", "b.txt": "b"})
+	if err := os.WriteFile(filepath.Join(destDir, "b.txt"), []byte("old"), 0644); err != nil {
+		t.Fatalf("failed to write existing file: %v", err)
+	}
+
+	output, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"-s", "basic", "-d", destDir, "-c", configDir, "-v", "--skip-existing"})
+	})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	for _, want := range []string{"copied    a.txt\n", "skipped   b.txt (exists)\n"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output = %q, want it to contain %q", output, want)
+		}
+	}
+	assertContent(t, filepath.Join(destDir, "b.txt"), "old")
+}
+
+func TestPressCmd_VerboseReportsWinningSheet(t *testing.T) {
+	configDir := t.TempDir()
+	destDir := t.TempDir()
+	createSheet(t, configDir, "base", map[string]string{"config.yaml.stamp": "name: {{.name}}\n", "a.txt": "a"})
+	createSheet(t, configDir, "extra", map[string]string{"config.yaml.stamp": "name: {{.name}}\nextra: true\n"})
+
+	output, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"-s", "base", "-s", "extra", "-d", destDir, "-c", configDir, "-v", "name=x"})
+	})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	for _, want := range []string{
+		"templated config.yaml\n",
+		"templated config.yaml from sheet extra (overwrote sheet base)\n",
+		"copied    a.txt\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output = %q, want it to contain %q", output, want)
+		}
+	}
+	assertContent(t, filepath.Join(destDir, "config.yaml"), "name: x\nextra: true\n")
+}
+
+func TestPressCmd_VerboseReportsVariableSources(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "base", map[string]string{
+		".stampsheet.yaml": "defaults:\n  license: MIT\n  branch: main\n",
+		"a.txt.stamp":      "{{.license}} {{.branch}} {{.org}} {{.name}} {{.token}} {{.region}}",
+	})
+	createSheet(t, configDir, "extra", map[string]string{".stampsheet.yaml": "defaults:\n  branch: trunk\n"})
+	globalConfig := filepath.Join(configDir, "stamp.yaml")
+	if err := os.WriteFile(globalConfig, []byte("org: acme\nname: from-config\n"), 0644); err != nil {
+		t.Fatalf("failed to write global config: %v", err)
+	}
+	t.Setenv("STAMP_VAR_region", "eu")
+	t.Setenv("CI_TOKEN", "secret")
+
+	destDir := t.TempDir()
+	output, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"-s", "base", "-s", "extra", "-d", destDir, "-c", configDir, "-v", "--env-var", "token=CI_TOKEN", "name=app"})
+	})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	// Each variable names the layer that supplied its final value, and values are not printed
+	for _, want := range []string{
+		"Variables:\n",
+		"  branch   default of sheet extra\n",
+		"  license  default of sheet base\n",
+		"  name     command line\n",
+		"  org      " + globalConfig + "\n",
+		"  region   environment STAMP_VAR_region\n",
+		"  token    environment CI_TOKEN\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output = %q, want it to contain %q", output, want)
+		}
+	}
+	if strings.Contains(output, "secret") {
+		t.Errorf("output = %q, should not contain variable values", output)
+	}
+	assertContent(t, filepath.Join(destDir, "a.txt"), "MIT trunk acme app secret eu")
+}
+
+func TestPressCmd_SheetGlob(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "web-api", map[string]string{"owner.txt": "api", "api.txt": "api"})
+	createSheet(t, configDir, "web-ui", map[string]string{"owner.txt": "ui", "ui.txt": "ui"})
+	createSheet(t, configDir, "worker", map[string]string{"worker.txt": "worker"})
+
+	destDir := t.TempDir()
+	output, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"-s", "web-*", "-d", destDir, "-c", configDir})
+	})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	// Matched sheets are pressed in sorted order, so web-ui wins for owner.txt
+	assertContent(t, filepath.Join(destDir, "api.txt"), "api")
+	assertContent(t, filepath.Join(destDir, "ui.txt"), "ui")
+	assertContent(t, filepath.Join(destDir, "owner.txt"), "ui")
+	if _, err := os.Stat(filepath.Join(destDir, "worker.txt")); !os.IsNotExist(err) {
+		t.Errorf("worker.txt should not be written, stat error = %v", err)
+	}
+	if !strings.Contains(output, "sheets [web-api web-ui]") {
+		t.Errorf("output = %q, want it to name both matched sheets", output)
+	}
+}
+
+func TestPressCmd_DestIsFile(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "app", map[string]string{"a.txt": "a"})
+	dest := filepath.Join(t.TempDir(), "out")
+	if err := os.WriteFile(dest, []byte("file"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	err := NewCLI().Execute([]string{"-s", "app", "-d", dest, "-c", configDir, "-q"})
+	if err == nil || !strings.Contains(err.Error(), "destination is not a directory: "+dest) {
+		t.Errorf("Execute() error = %v, want destination is not a directory", err)
+	}
+}
+
+// captureStdout runs fn and returns what it wrote to os.Stdout
+func captureStdout(t *testing.T, fn func() error) (string, error) {
+	t.Helper()
+	oldStdout := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create pipe: %v", err)
+	}
+	os.Stdout = w
+
+	done := make(chan string)
+	go func() {
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+		done <- buf.String()
+	}()
+
+	fnErr := fn()
+	w.Close()
+	os.Stdout = oldStdout
+	return <-done, fnErr
+}
+
+func TestPressCmd_SheetsFile(t *testing.T) {
+	configDir := t.TempDir()
+	destDir := t.TempDir()
+
+	createSheet(t, configDir, "base", map[string]string{
+		"base.txt":   "base",
+		"shared.txt": "from base",
+	})
+	createSheet(t, configDir, "extra", map[string]string{
+		"extra.txt":  "extra",
+		"shared.txt": "from extra",
+	})
+
+	sheetsFile := filepath.Join(t.TempDir(), "sheets.txt")
+	content := "# sheets to apply\nbase\n\n  extra  \n"
+	if err := os.WriteFile(sheetsFile, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to create sheets file: %v", err)
+	}
+
+	cli := NewCLI()
+	err := cli.Execute([]string{"--sheets-file", sheetsFile, "-d", destDir, "-c", configDir, "-q"})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	for name, want := range map[string]string{
+		"base.txt":   "base",
+		"extra.txt":  "extra",
+		"shared.txt": "from extra", // later sheet in the file wins
+	} {
+		assertContent(t, filepath.Join(destDir, name), want)
+	}
+}
+
+func TestPressCmd_NoSheets(t *testing.T) {
+	cli := NewCLI()
+	err := cli.Execute([]string{"-d", t.TempDir(), "-c", t.TempDir()})
+	if err == nil {
+		t.Fatal("Execute() succeeded, want error")
+	}
+	if !strings.Contains(err.Error(), "no sheets specified") {
+		t.Errorf("error = %q, want error containing 'no sheets specified'", err.Error())
+	}
+}
+
+// assertContent fails unless the file at path has the given content
+func assertContent(t *testing.T, path, want string) {
+	t.Helper()
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", path, err)
+	}
+	if string(got) != want {
+		t.Errorf("%s = %q, want %q", path, got, want)
+	}
+}
+
+// createSheet creates a sheet directory under configDir with the given files
+func createSheet(t *testing.T, configDir, name string, files map[string]string) string {
+	t.Helper()
+	sheetDir := filepath.Join(configDir, "sheets", name)
+	for rel, content := range files {
+		path := filepath.Join(sheetDir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory for %s: %v", rel, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to create %s: %v", rel, err)
+		}
+	}
+	if err := os.MkdirAll(sheetDir, 0755); err != nil {
+		t.Fatalf("failed to create sheet dir: %v", err)
+	}
+	return sheetDir
+}
+
+func TestPressCmd_JSONLogs(t *testing.T) {
+	configDir := t.TempDir()
+	destDir := t.TempDir()
+	createSheet(t, configDir, "basic", map[string]string{
+		"hello.txt.stamp": "Hello {{.name}}!",
+	})
+
+	var stderr bytes.Buffer
+	cli := NewCLI()
+	cli.stderr = &stderr
+	err := cli.Execute([]string{"--json-logs", "-s", "basic", "-d", destDir, "-c", configDir, "-q", "name=alice"})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	var events []map[string]string
+	for _, line := range strings.Split(strings.TrimSpace(stderr.String()), "\n") {
+		var e map[string]string
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("log line %q is not JSON: %v", line, err)
+		}
+		events = append(events, e)
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3: %v", len(events), events)
+	}
+	if events[0]["event"] != "sheet_resolved" || events[0]["sheet"] != "basic" {
+		t.Errorf("events[0] = %v, want sheet_resolved for basic", events[0])
+	}
+	if events[1]["event"] != "validation_passed" {
+		t.Errorf("events[1] = %v, want validation_passed", events[1])
+	}
+	want := map[string]string{"event": "file_written", "path": "hello.txt", "action": "templated", "sheet": "basic"}
+	for k, v := range want {
+		if events[2][k] != v {
+			t.Errorf("events[2][%q] = %q, want %q", k, events[2][k], v)
+		}
+	}
+}
+
+func TestPressCmd_RejectsFlagLikeVariables(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "basic", map[string]string{"a.txt": "a"})
+
+	for _, arg := range []string{"-dest=x", "--foo=y", "1name=z"} {
+		cli := NewCLI()
+		err := cli.Execute([]string{"-s", "basic", "-d", t.TempDir(), "-c", configDir, "--", arg})
+		if err == nil {
+			t.Errorf("Execute() with positional %q succeeded, want error", arg)
+			continue
+		}
+		if !strings.Contains(err.Error(), "variable") {
+			t.Errorf("error for %q = %q, want variable name error", arg, err.Error())
+		}
+	}
+}
+
+func TestValidateVarKeys(t *testing.T) {
+	if err := validateVarKeys(map[string]string{"name": "a", "_org": "b", "my-var2": "c", "db.host": "d"}); err != nil {
+		t.Errorf("validateVarKeys() returned error for valid keys: %v", err)
+	}
+
+	if err := validateVarKeys(map[string]string{"db..host": "x"}); err == nil {
+		t.Error("validateVarKeys() accepted an empty dotted segment")
+	}
+
+	err := validateVarKeys(map[string]string{"-dest": "x"})
+	if err == nil || !strings.Contains(err.Error(), "misspelled flag") {
+		t.Errorf("validateVarKeys() error = %v, want misspelled flag error", err)
+	}
+}
+
+func TestVersionCmd_JSON(t *testing.T) {
+	output, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"version", "--json"})
+	})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	var meta map[string]string
+	if err := json.Unmarshal([]byte(output), &meta); err != nil {
+		t.Fatalf("output %q is not JSON: %v", output, err)
+	}
+	for _, key := range []string{"name", "version", "revision", "go_version", "os", "arch"} {
+		if meta[key] == "" {
+			t.Errorf("%s is empty in %v", key, meta)
+		}
+	}
+	if meta["version"] != version {
+		t.Errorf("version = %q, want %q", meta["version"], version)
+	}
+}
+
+func TestVersionCmd_Plain(t *testing.T) {
+	output, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"version"})
+	})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if !strings.HasPrefix(output, "stamp v"+version+"\n") {
+		t.Errorf("output = %q, want it to start with %q", output, "stamp v"+version)
+	}
+	if want := "revision:   " + currentBuild().Revision + "\n"; !strings.Contains(output, want) {
+		t.Errorf("output = %q, want it to contain %q", output, want)
+	}
+}
+
+func TestPressCmd_DeterministicLogs(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "many", map[string]string{
+		"z.txt":            "z",
+		"a/b.txt.stamp":    "{{.name}}",
+		"a-c.txt":          "c",
+		"m/n/o.txt.stamp":  "{{.name}}",
+		"m/raw.stamp.noop": "{{.raw}}",
+	})
+
+	run := func() string {
+		var stderr bytes.Buffer
+		cli := NewCLI()
+		cli.stderr = &stderr
+		err := cli.Execute([]string{"--json-logs", "-s", "many", "-d", t.TempDir(), "-c", configDir, "-q", "name=alice"})
+		if err != nil {
+			t.Fatalf("Execute() failed: %v", err)
+		}
+		return stderr.String()
+	}
+
+	first, second := run(), run()
+	if first != second {
+		t.Errorf("log output differs between runs:\n%s\n---\n%s", first, second)
+	}
+}
+
+func TestCLI_FailOnWarning(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "conditional", map[string]string{
+		"ci.yaml.stamp": "{{if .ci}}ci: true{{end}}\n",
+	})
+
+	// Without the flag the warning is printed but the run succeeds
+	destDir := t.TempDir()
+	var stderr bytes.Buffer
+	cli := NewCLI()
+	cli.stderr = &stderr
+	if err := cli.Execute([]string{"-s", "conditional", "-d", destDir, "-c", configDir, "-q", "ci="}); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if !strings.Contains(stderr.String(), "Warning: ci.yaml rendered empty output") {
+		t.Errorf("stderr = %q, want empty output warning", stderr.String())
+	}
+
+	// With the flag the run completes but returns an error
+	destDir = t.TempDir()
+	stderr.Reset()
+	cli = NewCLI()
+	cli.stderr = &stderr
+	err := cli.Execute([]string{"--fail-on-warning", "-s", "conditional", "-d", destDir, "-c", configDir, "-q", "ci="})
+	if err == nil {
+		t.Fatal("Execute() succeeded, want error with --fail-on-warning")
+	}
+	if !strings.Contains(err.Error(), "1 warning(s) emitted") {
+		t.Errorf("error = %q, want warning count", err.Error())
+	}
+	if !strings.Contains(stderr.String(), "Warning:") {
+		t.Errorf("stderr = %q, warnings should still be printed", stderr.String())
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "ci.yaml")); err != nil {
+		t.Errorf("operation should still complete: %v", err)
+	}
+}
+
+func TestPressCmd_StatsJSON(t *testing.T) {
+	configDir := t.TempDir()
+	destDir := t.TempDir()
+	createSheet(t, configDir, "base", map[string]string{"a.txt": "a", "b.txt.stamp": "{{.name}}"})
+	createSheet(t, configDir, "extra", map[string]string{"c.txt": "c"})
+
+	statsPath := filepath.Join(t.TempDir(), "stats.json")
+	cli := NewCLI()
+	err := cli.Execute([]string{"-s", "base", "-s", "extra", "-d", destDir, "-c", configDir, "-q", "--stats-json", statsPath, "name=alice"})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	data, err := os.ReadFile(statsPath)
+	if err != nil {
+		t.Fatalf("failed to read stats file: %v", err)
+	}
+	var stats struct {
+		Phases map[string]float64 `json:"phases"`
+		Sheets []struct {
+			Sheet      string  `json:"sheet"`
+			Files      int     `json:"files"`
+			DurationMS float64 `json:"duration_ms"`
+		} `json:"sheets"`
+	}
+	if err := json.Unmarshal(data, &stats); err != nil {
+		t.Fatalf("stats file is not JSON: %v", err)
+	}
+
+	for _, key := range []string{"resolution_ms", "validation_ms", "processing_ms", "total_ms"} {
+		v, ok := stats.Phases[key]
+		if !ok {
+			t.Errorf("phases missing %q", key)
+		}
+		if v < 0 {
+			t.Errorf("phases[%q] = %v, want non-negative", key, v)
+		}
+	}
+	if stats.Phases["total_ms"] < stats.Phases["processing_ms"] {
+		t.Errorf("total_ms %v should not be less than processing_ms %v", stats.Phases["total_ms"], stats.Phases["processing_ms"])
+	}
+
+	if len(stats.Sheets) != 2 {
+		t.Fatalf("got %d sheets, want 2", len(stats.Sheets))
+	}
+	if stats.Sheets[0].Sheet != "base" || stats.Sheets[0].Files != 2 {
+		t.Errorf("sheets[0] = %+v, want base with 2 files", stats.Sheets[0])
+	}
+	if stats.Sheets[1].Sheet != "extra" || stats.Sheets[1].Files != 1 {
+		t.Errorf("sheets[1] = %+v, want extra with 1 file", stats.Sheets[1])
+	}
+}
+
+func TestPressCmd_MergeStrategy(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "base", map[string]string{".gitignore": "*.log\n"})
+	createSheet(t, configDir, "node", map[string]string{".gitignore": "node_modules/\n"})
+
+	destDir := t.TempDir()
+	cli := NewCLI()
+	if err := cli.Execute([]string{"-s", "base", "-s", "node", "-d", destDir, "-c", configDir, "-q", "--merge-strategy", "merge"}); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	assertContent(t, filepath.Join(destDir, ".gitignore"), "*.log\nnode_modules/\n")
+
+	// Unknown strategies are rejected by the parser
+	cli = NewCLI()
+	if err := cli.Execute([]string{"-s", "base", "-d", destDir, "-c", configDir, "--merge-strategy", "append"}); err == nil {
+		t.Error("Execute() should reject an unknown merge strategy")
+	}
+}
+
+func TestPressCmd_Timeout(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "base", map[string]string{"a.txt": "a"})
+
+	cli := NewCLI()
+	err := cli.Execute([]string{"-s", "base", "-d", t.TempDir(), "-c", configDir, "-q", "--timeout", "1ns"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Execute() error = %v, want DeadlineExceeded", err)
+	}
+	if !strings.Contains(err.Error(), "timed out after 1ns") {
+		t.Errorf("error = %q, want timeout message", err.Error())
+	}
+}
+
+func TestPressCmd_EnvVar(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "secret", map[string]string{"token.txt.stamp": "{{.token}}/{{.region}}"})
+	t.Setenv("CI_TOKEN", "s3cr3t")
+	t.Setenv("region", "env-region")
+
+	destDir := t.TempDir()
+	cli := NewCLI()
+	err := cli.Execute([]string{"-s", "secret", "-d", destDir, "-c", configDir, "-q",
+		"--env-var", "token=CI_TOKEN", "--env-var", "region", "region=cli-region"})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	// CLI args take priority over the environment
+	assertContent(t, filepath.Join(destDir, "token.txt"), "s3cr3t/cli-region")
+}
+
+func TestPressCmd_PrefixedEnv(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "app", map[string]string{"app.txt.stamp": "{{.name}}/{{.org}}/{{.Region}}"})
+	if err := os.WriteFile(filepath.Join(configDir, "stamp.yaml"), []byte("org: config-org\nRegion: config-region\n"), 0644); err != nil {
+		t.Fatalf("failed to write global config: %v", err)
+	}
+	t.Setenv("STAMP_VAR_name", "env-name")
+	t.Setenv("STAMP_VAR_Region", "env-region")
+
+	// The environment satisfies validation and overrides config; CLI args override the environment
+	destDir := t.TempDir()
+	if err := NewCLI().Execute([]string{"-s", "app", "-d", destDir, "-c", configDir, "-q", "org=cli-org", "Region=cli-region"}); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	assertContent(t, filepath.Join(destDir, "app.txt"), "env-name/cli-org/cli-region")
+
+	destDir = t.TempDir()
+	if err := NewCLI().Execute([]string{"-s", "app", "-d", destDir, "-c", configDir, "-q"}); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	assertContent(t, filepath.Join(destDir, "app.txt"), "env-name/config-org/env-region")
+}
+
+func TestApplyPrefixedEnv(t *testing.T) {
+	vars := map[string]string{"keep": "config"}
+	environ := []string{"HOME=/root", "STAMP_VAR_name=a=b", "STAMP_VAR_empty=", "STAMP_VARX=ignored"}
+	if err := applyPrefixedEnv(vars, environ); err != nil {
+		t.Fatalf("applyPrefixedEnv() failed: %v", err)
+	}
+	want := map[string]string{"keep": "config", "name": "a=b", "empty": ""}
+	if !maps.Equal(vars, want) {
+		t.Errorf("vars = %v, want %v", vars, want)
+	}
+
+	if err := applyPrefixedEnv(vars, []string{"STAMP_VAR_=x"}); err == nil {
+		t.Error("applyPrefixedEnv() should reject an empty variable name")
+	}
+}
+
+func TestPressCmd_EnvVarMissing(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "secret", map[string]string{"token.txt.stamp": "{{.token}}"})
+	t.Setenv("STAMP_TEST_UNSET", "")
+	os.Unsetenv("STAMP_TEST_UNSET")
+
+	cli := NewCLI()
+	err := cli.Execute([]string{"-s", "secret", "-d", t.TempDir(), "-c", configDir, "-q", "--env-var", "token=STAMP_TEST_UNSET"})
+	if err == nil || !strings.Contains(err.Error(), "STAMP_TEST_UNSET is not set") {
+		t.Fatalf("Execute() error = %v, want unset environment variable error", err)
+	}
+
+	// A variable satisfied by CLI args does not need the environment
+	cli = NewCLI()
+	err = cli.Execute([]string{"-s", "secret", "-d", t.TempDir(), "-c", configDir, "-q", "--env-var", "token=STAMP_TEST_UNSET", "token=x"})
+	if err != nil {
+		t.Errorf("Execute() failed: %v", err)
+	}
+}
+
+func TestCLI_DefaultCommandRouting(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		command string
+		vars    map[string]string
+	}{
+		{"bare args route to press", []string{"-s", "base", "name=alice"}, "press", map[string]string{"name": "alice"}},
+		{"explicit press prefix", []string{"press", "-s", "base", "name=alice"}, "press", map[string]string{"name": "alice"}},
+		{"subcommand name as variable key", []string{"-s", "base", "collect=x", "config-dir=y"}, "press", map[string]string{"collect": "x", "config-dir": "y"}},
+		{"leading variable named like a subcommand", []string{"collect=x", "-s", "base"}, "press", map[string]string{"collect": "x"}},
+		{"collect subcommand", []string{"collect", "-s", "base"}, "collect", nil},
+		{"config-dir subcommand", []string{"config-dir"}, "config-dir", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := NewCLI()
+			ctx, err := cli.parser().Parse(tt.args)
+			if err != nil {
+				t.Fatalf("Parse(%v) failed: %v", tt.args, err)
+			}
+			if got := strings.Fields(ctx.Command())[0]; got != tt.command {
+				t.Errorf("command = %q, want %q", got, tt.command)
+			}
+			if tt.vars == nil {
+				return
+			}
+			if len(cli.Press.Vars) != len(tt.vars) {
+				t.Fatalf("vars = %v, want %v", cli.Press.Vars, tt.vars)
+			}
+			for k, v := range tt.vars {
+				if cli.Press.Vars[k] != v {
+					t.Errorf("vars[%q] = %q, want %q", k, cli.Press.Vars[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestCLI_BareSubcommandNameIsNotAVariable(t *testing.T) {
+	// A bare word matching a subcommand selects that subcommand, so it is never
+	// consumed as a variable; here collect then reports its own missing flag
+	cli := NewCLI()
+	_, err := cli.parser().Parse([]string{"-s", "base", "collect"})
+	if err == nil {
+		t.Fatal("Parse() succeeded, want error")
+	}
+	if !strings.Contains(err.Error(), "--sheet") {
+		t.Errorf("error = %q, want collect's missing --sheet flag", err.Error())
+	}
+}
+
+func TestSheetRoot(t *testing.T) {
+	configDir := t.TempDir()
+	srcDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(srcDir, "hello.txt"), []byte("Hello {{.name}}"), 0644); err != nil {
+		t.Fatalf("failed to create source file: %v", err)
+	}
+
+	// collect and press agree on a custom root given by flag
+	if _, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"collect", "-s", "greet", "-c", configDir, "-t", "--sheet-root", "stamp-sheets", srcDir})
+	}); err != nil {
+		t.Fatalf("collect failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(configDir, "stamp-sheets", "greet", "hello.txt.stamp")); err != nil {
+		t.Fatalf("sheet should be collected under the custom root: %v", err)
+	}
+
+	destDir := t.TempDir()
+	if err := NewCLI().Execute([]string{"-s", "greet", "-d", destDir, "-c", configDir, "-q", "--sheet-root", "stamp-sheets", "name=alice"}); err != nil {
+		t.Fatalf("press failed: %v", err)
+	}
+	assertContent(t, filepath.Join(destDir, "hello.txt"), "Hello alice")
+
+	// The environment variable selects the root too
+	t.Setenv("STAMP_SHEET_ROOT", "stamp-sheets")
+	if err := NewCLI().Execute([]string{"-s", "greet", "-d", t.TempDir(), "-c", configDir, "-q", "name=bob"}); err != nil {
+		t.Errorf("press with STAMP_SHEET_ROOT failed: %v", err)
+	}
+
+	if err := NewCLI().Execute([]string{"-s", "greet", "-c", configDir, "--sheet-root", "../outside"}); err == nil {
+		t.Error("press should reject a sheet root outside the config directory")
+	}
+}
+
+func TestPressCmd_RequiresTools(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "docker-app", map[string]string{
+		".stampsheet.yaml": "requires_tools: [stamp-test-no-such-tool]\n",
+		"Dockerfile":       "FROM scratch\n",
+	})
+
+	destDir := t.TempDir()
+	err := NewCLI().Execute([]string{"-s", "docker-app", "-d", destDir, "-c", configDir, "-q"})
+	if err == nil {
+		t.Fatal("Execute() succeeded, want missing tool error")
+	}
+	if !strings.Contains(err.Error(), "stamp-test-no-such-tool (required by sheet 'docker-app')") {
+		t.Errorf("error = %q, want the missing tool listed", err.Error())
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "Dockerfile")); !os.IsNotExist(err) {
+		t.Error("nothing should be written when a tool is missing")
+	}
+
+	// --skip-tool-check bypasses the check, and the settings file is not emitted
+	if err := NewCLI().Execute([]string{"-s", "docker-app", "-d", destDir, "-c", configDir, "-q", "--skip-tool-check"}); err != nil {
+		t.Fatalf("Execute() with --skip-tool-check failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "Dockerfile")); err != nil {
+		t.Errorf("Dockerfile should be written: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(destDir, ".stampsheet.yaml")); !os.IsNotExist(err) {
+		t.Error(".stampsheet.yaml should not be written to the destination")
+	}
+}
+
+func TestPressCmd_MinVersion(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "future", map[string]string{
+		".stampsheet.yaml": "min_version: 99.0.0\n",
+		"a.txt":            "a\n",
+	})
+	createSheet(t, configDir, "current", map[string]string{
+		".stampsheet.yaml": "min_version: " + version + "\n",
+		"b.txt":            "b\n",
+	})
+
+	destDir := t.TempDir()
+	if err := NewCLI().Execute([]string{"-s", "current", "-d", destDir, "-c", configDir, "-q"}); err != nil {
+		t.Fatalf("Execute() with a satisfied min_version failed: %v", err)
+	}
+
+	err := NewCLI().Execute([]string{"-s", "current", "-s", "future", "-d", destDir, "-c", configDir, "-q"})
+	if err == nil {
+		t.Fatal("Execute() succeeded, want min_version error")
+	}
+	want := "sheet 'future': sheet requires stamp >= 99.0.0, you have " + version
+	if err.Error() != want {
+		t.Errorf("error = %q, want %q", err.Error(), want)
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "a.txt")); !os.IsNotExist(err) {
+		t.Error("nothing should be written when min_version is not met")
+	}
+}
+
+func TestDebugTreeCmd(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.txt.stamp")
+	if err := os.WriteFile(path, []byte("{{with .owner}}{{.}}{{end}} {{.name}}"), 0644); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+
+	output, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"debug", "tree", path})
+	})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if !strings.Contains(output, "WithNode") || !strings.Contains(output, "Variables:\n  name\n  owner\n") {
+		t.Errorf("output = %q, want parse tree and variables", output)
+	}
+}
+
+func TestPressCmd_StringFuncs(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "funcs", map[string]string{
+		"{{.name | snakecase}}.txt.stamp": "{{.name | upper}} {{.org | default \"none\"}}\n",
+	})
+
+	destDir := t.TempDir()
+	if err := NewCLI().Execute([]string{"-s", "funcs", "-d", destDir, "-c", configDir, "-q", "name=MyApp"}); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	assertContent(t, filepath.Join(destDir, "my_app.txt"), "MYAPP none\n")
+}
+
+func TestPressCmd_ExistingFiles(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "app", map[string]string{"main.go": "package main\n", "README.md": "readme\n"})
+	destDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(destDir, "main.go"), []byte("mine\n"), 0644); err != nil {
+		t.Fatalf("failed to create existing file: %v", err)
+	}
+
+	err := NewCLI().Execute([]string{"-s", "app", "-d", destDir, "-c", configDir, "-q"})
+	if err == nil || !strings.Contains(err.Error(), "destination file already exists: main.go (use --force") {
+		t.Fatalf("Execute() error = %v, want existing file error", err)
+	}
+	assertContent(t, filepath.Join(destDir, "main.go"), "mine\n")
+
+	if err := NewCLI().Execute([]string{"-s", "app", "-d", destDir, "-c", configDir, "-q", "--skip-existing"}); err != nil {
+		t.Fatalf("Execute() with --skip-existing failed: %v", err)
+	}
+	assertContent(t, filepath.Join(destDir, "main.go"), "mine\n")
+	assertContent(t, filepath.Join(destDir, "README.md"), "readme\n")
+
+	if err := NewCLI().Execute([]string{"-s", "app", "-d", destDir, "-c", configDir, "-q", "-f"}); err != nil {
+		t.Fatalf("Execute() with -f failed: %v", err)
+	}
+	assertContent(t, filepath.Join(destDir, "main.go"), "package main\n")
+
+	if err := NewCLI().Execute([]string{"-s", "app", "-d", destDir, "-c", configDir, "-f", "--skip-existing"}); err == nil {
+		t.Error("--force and --skip-existing should be mutually exclusive")
+	}
+}
+
+func TestPressCmd_OverwritePolicy(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "app", map[string]string{"main.go": "package main\n", "README.md": "readme\n"})
+
+	tests := []struct {
+		policy string
+		want   string
+	}{
+		{"always", "package main\n"},
+		{"never", "mine\n"},
+		{"if-changed", "package main\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			destDir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(destDir, "main.go"), []byte("mine\n"), 0644); err != nil {
+				t.Fatalf("failed to create existing file: %v", err)
+			}
+			if err := NewCLI().Execute([]string{"-s", "app", "-d", destDir, "-c", configDir, "-q", "--overwrite", tt.policy}); err != nil {
+				t.Fatalf("Execute() with --overwrite %s failed: %v", tt.policy, err)
+			}
+			assertContent(t, filepath.Join(destDir, "main.go"), tt.want)
+			assertContent(t, filepath.Join(destDir, "README.md"), "readme\n")
+		})
+	}
+
+	destDir := t.TempDir()
+	if err := NewCLI().Execute([]string{"-s", "app", "-d", destDir, "-c", configDir, "--overwrite", "always", "-f"}); err == nil {
+		t.Error("--overwrite and --force should be mutually exclusive")
+	}
+	if err := NewCLI().Execute([]string{"-s", "app", "-d", destDir, "-c", configDir, "--overwrite", "sometimes"}); err == nil {
+		t.Error("--overwrite should reject unknown policies")
+	}
+}
+
+func TestPressCmd_SheetDefaults(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "lib", map[string]string{
+		".stampsheet.yaml": "defaults:\n  license: MIT\n  branch: main\n  suffix: \"\"\n",
+		"info.txt.stamp":   "{{.license}} {{.branch}} {{.org}}[{{.suffix}}]",
+	})
+	if err := os.WriteFile(filepath.Join(configDir, "stamp.yaml"), []byte("org: acme\nbranch: trunk\n"), 0644); err != nil {
+		t.Fatalf("failed to write global config: %v", err)
+	}
+
+	// Defaults satisfy validation; global config and CLI args override them
+	destDir := t.TempDir()
+	if err := NewCLI().Execute([]string{"-s", "lib", "-d", destDir, "-c", configDir, "-q"}); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	assertContent(t, filepath.Join(destDir, "info.txt"), "MIT trunk acme[]")
+
+	destDir = t.TempDir()
+	if err := NewCLI().Execute([]string{"-s", "lib", "-d", destDir, "-c", configDir, "-q", "license=Apache-2.0"}); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	assertContent(t, filepath.Join(destDir, "info.txt"), "Apache-2.0 trunk acme[]")
+}
+
+func TestPressCmd_ConfigPrecedence(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "lib", map[string]string{
+		".stampsheet.yaml": "defaults:\n  branch: main\n  license: MIT\n",
+		"info.txt.stamp":   "{{.branch}} {{.license}} {{.org}}",
+	})
+	if err := os.WriteFile(filepath.Join(configDir, "stamp.yaml"), []byte("org: acme\nbranch: trunk\n"), 0644); err != nil {
+		t.Fatalf("failed to write global config: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"default", nil, "trunk MIT acme"},
+		{"global-wins", []string{"--config-precedence", "global-wins"}, "trunk MIT acme"},
+		{"sheet-wins", []string{"--config-precedence", "sheet-wins"}, "main MIT acme"},
+		{"sheet-wins with CLI args", []string{"--config-precedence", "sheet-wins", "branch=dev"}, "dev MIT acme"},
+		{"global-wins with CLI args", []string{"--config-precedence", "global-wins", "branch=dev"}, "dev MIT acme"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			destDir := t.TempDir()
+			args := append([]string{"-s", "lib", "-d", destDir, "-c", configDir, "-q"}, tt.args...)
+			if err := NewCLI().Execute(args); err != nil {
+				t.Fatalf("Execute() failed: %v", err)
+			}
+			assertContent(t, filepath.Join(destDir, "info.txt"), tt.want)
+		})
+	}
+}
+
+func TestPressCmd_VarFile(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "app", map[string]string{"app.txt.stamp": "{{.name}}/{{.org}}/{{.port}}"})
+	varsDir := t.TempDir()
+	base := filepath.Join(varsDir, "base.yaml")
+	if err := os.WriteFile(base, []byte("name: app\norg: acme\nport: 80\n"), 0644); err != nil {
+		t.Fatalf("failed to write var file: %v", err)
+	}
+	override := filepath.Join(varsDir, "override.json")
+	if err := os.WriteFile(override, []byte(`{"port": "8080"}`), 0644); err != nil {
+		t.Fatalf("failed to write var file: %v", err)
+	}
+
+	// All variables come from files; later files win
+	destDir := t.TempDir()
+	if err := NewCLI().Execute([]string{"-s", "app", "-d", destDir, "-c", configDir, "-q", "--var-file", base, "--var-file", override}); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	assertContent(t, filepath.Join(destDir, "app.txt"), "app/acme/8080")
+
+	// Inline CLI args override files
+	destDir = t.TempDir()
+	if err := NewCLI().Execute([]string{"-s", "app", "-d", destDir, "-c", configDir, "-q", "--var-file", base, "org=cli"}); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	assertContent(t, filepath.Join(destDir, "app.txt"), "app/cli/80")
+
+	missing := filepath.Join(varsDir, "missing.yaml")
+	err := NewCLI().Execute([]string{"-s", "app", "-d", t.TempDir(), "-c", configDir, "-q", "--var-file", missing})
+	if err == nil || err.Error() != "--var-file: config file not found: "+missing {
+		t.Errorf("Execute() error = %v, want missing var file error", err)
+	}
+}
+
+func TestPressCmd_ConfigFile(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "app", map[string]string{"owner.txt.stamp": "{{.org}}/{{.email}}"})
+	for name, content := range map[string]string{
+		"stamp.yaml":    "org: default\nemail: me@default.example\n",
+		"work.yaml":     "org: acme\nemail: me@acme.example\n",
+		"personal.toml": "org = \"alice\"\nemail = \"alice@example.com\"\n",
+	} {
+		if err := os.WriteFile(filepath.Join(configDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	// A relative path is resolved against the -c config directory and replaces stamp.yaml
+	destDir := t.TempDir()
+	if err := NewCLI().Execute([]string{"-s", "app", "-d", destDir, "-c", configDir, "-q", "--config-file", "work.yaml"}); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	assertContent(t, filepath.Join(destDir, "owner.txt"), "acme/me@acme.example")
+
+	// An absolute path is used as is, still beneath CLI args
+	destDir = t.TempDir()
+	args := []string{"-s", "app", "-d", destDir, "-c", configDir, "-q", "--config-file", filepath.Join(configDir, "personal.toml"), "email=a@example.org"}
+	if err := NewCLI().Execute(args); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	assertContent(t, filepath.Join(destDir, "owner.txt"), "alice/a@example.org")
+
+	// Unlike stamp.yaml, a chosen config file must exist
+	err := NewCLI().Execute([]string{"-s", "app", "-d", t.TempDir(), "-c", configDir, "-q", "--config-file", "missing.yaml"})
+	if err == nil || !strings.Contains(err.Error(), "config file not found") {
+		t.Errorf("Execute() error = %v, want missing config file error", err)
+	}
+}
+
+func TestPressCmd_NestedConfig(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "app", map[string]string{"db.conf.stamp": "{{.db.host}}:{{.db.port}}"})
+	if err := os.WriteFile(filepath.Join(configDir, "stamp.yaml"), []byte("db:\n  host: localhost\n  port: 5432\n"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	// Nested config is flattened, and a dotted CLI argument overrides a single field
+	destDir := t.TempDir()
+	if err := NewCLI().Execute([]string{"-s", "app", "-d", destDir, "-c", configDir, "-q", "db.port=6543"}); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	assertContent(t, filepath.Join(destDir, "db.conf"), "localhost:6543")
+}
+
+func TestPressCmd_ListConfig(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "app", map[string]string{"hosts.txt.stamp": "{{range .hosts}}{{.}}\n{{end}}"})
+	if err := os.WriteFile(filepath.Join(configDir, "stamp.yaml"), []byte("hosts: [a.example, b.example]\n"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	destDir := t.TempDir()
+	if err := NewCLI().Execute([]string{"-s", "app", "-d", destDir, "-c", configDir, "-q"}); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	assertContent(t, filepath.Join(destDir, "hosts.txt"), "a.example\nb.example\n")
+
+	// An indexed CLI argument overrides a single item
+	if err := NewCLI().Execute([]string{"-s", "app", "-d", destDir, "-c", configDir, "-q", "-f", "hosts.1=c.example"}); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	assertContent(t, filepath.Join(destDir, "hosts.txt"), "a.example\nc.example\n")
+}
+
+func TestPressCmd_TypedVariables(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "app", map[string]string{"app.conf.stamp": "name: {{.name}}\n{{if .debug}}debug: on\n{{end}}"})
+
+	// Values are strings by default, so any non-empty value is true
+	destDir := t.TempDir()
+	if err := NewCLI().Execute([]string{"-s", "app", "-d", destDir, "-c", configDir, "-q", "name=app", "debug=false"}); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	assertContent(t, filepath.Join(destDir, "app.conf"), "name: app\ndebug: on\n")
+
+	if err := NewCLI().Execute([]string{"-s", "app", "-d", destDir, "-c", configDir, "-q", "-f", "--typed-vars", "name=app", "debug=false"}); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	assertContent(t, filepath.Join(destDir, "app.conf"), "name: app\n")
+
+	if err := NewCLI().Execute([]string{"-s", "app", "-d", destDir, "-c", configDir, "-q", "-f", "--typed-vars", "name=app", "debug=true"}); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	assertContent(t, filepath.Join(destDir, "app.conf"), "name: app\ndebug: on\n")
+}
+
+func TestPressCmd_PruneEmpty(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "app", map[string]string{
+		"ci.yaml.stamp": "{{if .ci}}ci: true{{end}}\n",
+		".keep":         "",
+	})
+
+	destDir := t.TempDir()
+	if err := NewCLI().Execute([]string{"-s", "app", "-d", destDir, "-c", configDir, "-q", "--prune-empty", "ci="}); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "ci.yaml")); !os.IsNotExist(err) {
+		t.Errorf("ci.yaml should not be created, stat error = %v", err)
+	}
+	// Non-template files that are empty are still copied
+	assertContent(t, filepath.Join(destDir, ".keep"), "")
+}
+
+func TestPressCmd_StrictKeys(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "app", map[string]string{"db.conf.stamp": "{{.db.host}}:{{.db.port}}"})
+
+	err := NewCLI().Execute([]string{"-s", "app", "-d", t.TempDir(), "-c", configDir, "-q", "--strict-keys", "db.host=localhost"})
+	if err == nil || !strings.Contains(err.Error(), `"port"`) {
+		t.Errorf("Execute() error = %v, want missing key error naming port", err)
+	}
+}
+
+func TestPressCmd_CustomDelims(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "app", map[string]string{"workflow.yml.stamp": "name: [[.name]]\nref: ${{ github.ref }}\n"})
+
+	destDir := t.TempDir()
+	if err := NewCLI().Execute([]string{"-s", "app", "-d", destDir, "-c", configDir, "-q", "--left-delim", "[[", "--right-delim", "]]", "name=ci"}); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	assertContent(t, filepath.Join(destDir, "workflow.yml"), "name: ci\nref: ${{ github.ref }}\n")
+}
+
+func TestPressCmd_NoopSuffix(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "app", map[string]string{
+		"main.go.stamp":         "package {{.pkg}}\n",
+		"template.go.stamp.raw": "package {{.other}}\n",
+	})
+
+	destDir := t.TempDir()
+	if err := NewCLI().Execute([]string{"-s", "app", "-d", destDir, "-c", configDir, "-q", "--noop-suffix", ".raw", "pkg=main"}); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	assertContent(t, filepath.Join(destDir, "main.go"), "package main\n")
+	assertContent(t, filepath.Join(destDir, "template.go.stamp"), "package {{.other}}\n")
+}
+
+func TestPressCmd_KeepExtension(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "app", map[string]string{"chart.yaml.tmpl": "name: {{.name}}\nimage: {{`{{ .Values.image }}`}}\n"})
+
+	destDir := t.TempDir()
+	if err := NewCLI().Execute([]string{"-s", "app", "-d", destDir, "-c", configDir, "-q", "-e", ".tmpl", "--keep-extension", "name=app"}); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	assertContent(t, filepath.Join(destDir, "chart.yaml.tmpl"), "name: app\nimage: {{ .Values.image }}\n")
+	if _, err := os.Stat(filepath.Join(destDir, "chart.yaml")); !os.IsNotExist(err) {
+		t.Errorf("chart.yaml should not be created, stat error = %v", err)
+	}
+}
+
+func TestPressCmd_DestTemplate(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "service", map[string]string{"main.go.stamp": "package {{.svc}}\n"})
+
+	destDir := t.TempDir()
+	if err := NewCLI().Execute([]string{"-s", "service", "-d", destDir, "-c", configDir, "-q", "--dest-template", "services/{{.svc}}", "svc=billing"}); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	assertContent(t, filepath.Join(destDir, "services", "billing", "main.go"), "package billing\n")
+
+	err := NewCLI().Execute([]string{"-s", "service", "-d", destDir, "-c", configDir, "-q", "--dest-template", "services/{{.team}}/{{.svc}}", "svc=billing"})
+	if err == nil || !strings.Contains(err.Error(), "team") {
+		t.Errorf("Execute() error = %v, want missing variable team", err)
+	}
+	err = NewCLI().Execute([]string{"-s", "service", "-d", destDir, "-c", configDir, "-q", "--dest-template", "services/{{.svc}}", "svc=../x"})
+	if err == nil || !strings.Contains(err.Error(), "..") {
+		t.Errorf("Execute() error = %v, want .. to be refused", err)
+	}
+}
+
+func TestPressCmd_Only(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "app", map[string]string{
+		"main.go.stamp":       "package {{.pkg}}\n",
+		"internal/util.go":    "package util\n",
+		"README.md.stamp":     "# {{.title}}\n",
+		"docs/guide.md.stamp": "{{.title}}\n",
+	})
+	destDir := t.TempDir()
+
+	if err := NewCLI().Execute([]string{"-s", "app", "-d", destDir, "-c", configDir, "-q", "--only", "*.go", "pkg=main"}); err != nil {
+		t.Fatalf("Execute() with --only failed: %v", err)
+	}
+	assertContent(t, filepath.Join(destDir, "main.go"), "package main\n")
+	assertContent(t, filepath.Join(destDir, "internal", "util.go"), "package util\n")
+	for _, name := range []string{"README.md", "docs"} {
+		if _, err := os.Stat(filepath.Join(destDir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s should not be written with --only '*.go'", name)
+		}
+	}
+
+	if err := NewCLI().Execute([]string{"-s", "app", "-d", destDir, "-c", configDir, "--only", "[", "pkg=main"}); err == nil {
+		t.Error("--only should reject malformed patterns")
+	}
+}
+
+func TestPressCmd_Jobs(t *testing.T) {
+	configDir := t.TempDir()
+	files := make(map[string]string)
+	for i := range 50 {
+		files[fmt.Sprintf("pkg%d/file%d.txt.stamp", i%5, i)] = fmt.Sprintf("{{.name}} %d\n", i)
+	}
+	createSheet(t, configDir, "many", files)
+
+	run := func(jobs string) string {
+		var stderr bytes.Buffer
+		cli := NewCLI()
+		cli.stderr = &stderr
+		destDir := t.TempDir()
+		if err := cli.Execute([]string{"--json-logs", "-s", "many", "-d", destDir, "-c", configDir, "-q", "-j", jobs, "name=alice"}); err != nil {
+			t.Fatalf("Execute() with -j %s failed: %v", jobs, err)
+		}
+		assertContent(t, filepath.Join(destDir, "pkg3", "file48.txt"), "alice 48\n")
+		return stderr.String()
+	}
+
+	if sequential, parallel := run("1"), run("4"); sequential != parallel {
+		t.Errorf("log output with -j 4 differs from -j 1:\n%s\n---\n%s", parallel, sequential)
+	}
+}
+
+func TestPressCmd_SearchPath(t *testing.T) {
+	configDir := t.TempDir()
+	sharedDir := t.TempDir()
+	createSheet(t, sharedDir, "company", map[string]string{"LICENSE.stamp": "{{.org}}\n"})
+	if err := os.WriteFile(filepath.Join(sharedDir, "stamp.yaml"), []byte("org: acme\n"), 0644); err != nil {
+		t.Fatalf("failed to write shared config: %v", err)
+	}
+	t.Setenv("STAMP_PATH", sharedDir)
+
+	destDir := t.TempDir()
+	if err := NewCLI().Execute([]string{"-s", "company", "-d", destDir, "-c", configDir, "-q"}); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	assertContent(t, filepath.Join(destDir, "LICENSE"), "acme\n")
+
+	output, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"list", "-c", configDir})
+	})
+	if err != nil || output != "company\n" {
+		t.Errorf("list output = %q, %v, want the sheet from STAMP_PATH", output, err)
+	}
+}
+
+func TestPressCmd_Schema(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "service", map[string]string{
+		"name.txt.stamp":  "{{.name}} {{.env}}",
+		config.SchemaFile: "strict: true\nvariables:\n  name:\n    pattern: '[a-z][a-z0-9-]*'\n  env:\n    enum: [dev, prod]\n",
+	})
+
+	destDir := t.TempDir()
+	err := NewCLI().Execute([]string{"-s", "service", "-d", destDir, "-c", configDir, "-q", "name=My App", "env=qa", "team=core"})
+	if err == nil {
+		t.Fatal("Execute() should reject variables that break the schema")
+	}
+	for _, want := range []string{
+		`name: "My App" does not match pattern [a-z][a-z0-9-]* (sheet 'service')`,
+		`env: "qa" is not one of dev, prod (sheet 'service')`,
+		"team: not declared in stamp.schema.yaml (command line)",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Execute() error = %v, want it to contain %q", err, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "name.txt")); !os.IsNotExist(err) {
+		t.Errorf("name.txt should not be written, stat error = %v", err)
+	}
+
+	if err := NewCLI().Execute([]string{"-s", "service", "-d", destDir, "-c", configDir, "-q", "name=my-app", "env=prod"}); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	assertContent(t, filepath.Join(destDir, "name.txt"), "my-app prod")
+	if _, err := os.Stat(filepath.Join(destDir, config.SchemaFile)); !os.IsNotExist(err) {
+		t.Errorf("%s should not be written, stat error = %v", config.SchemaFile, err)
+	}
+}
+
+func TestPressCmd_TrimBlocks(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "app", map[string]string{"a.txt.stamp": "{{if .debug}}\ndebug\n{{end}}\nname={{.name}}\n"})
+
+	destDir := t.TempDir()
+	if err := NewCLI().Execute([]string{"-s", "app", "-d", destDir, "-c", configDir, "-q", "--trim-blocks", "debug=true", "name=app"}); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	assertContent(t, filepath.Join(destDir, "a.txt"), "debug\nname=app\n")
+}
+
+func TestPressCmd_MultipleDests(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "app", map[string]string{"hello.txt.stamp": "Hello {{.name}}!"})
+
+	first := t.TempDir()
+	second := filepath.Join(t.TempDir(), "second")
+	if err := NewCLI().Execute([]string{"-s", "app", "-d", first, "-d", second, "-c", configDir, "-q", "--manifest", "manifest.json", "name=alice"}); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	for _, dest := range []string{first, second} {
+		assertContent(t, filepath.Join(dest, "hello.txt"), "Hello alice!")
+		if _, err := os.Stat(filepath.Join(dest, "manifest.json")); err != nil {
+			t.Errorf("manifest missing in %s: %v", dest, err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"duplicate destination", []string{"-d", first, "-d", first}},
+		{"with check", []string{"-d", first, "-d", second, "--check"}},
+		{"with stdout", []string{"-d", first, "-d", "-"}},
+		{"with absolute manifest", []string{"-d", first, "-d", second, "--manifest", filepath.Join(t.TempDir(), "m.json")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"-s", "app", "-c", configDir, "-q", "name=alice"}, tt.args...)
+			if err := NewCLI().Execute(args); err == nil {
+				t.Errorf("Execute(%v) succeeded, want error", tt.args)
+			}
+		})
+	}
+}
+
+func TestPressCmd_CopyBinary(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "app", map[string]string{"logo.png.stamp": "\x89PNG\x00{{"})
+
+	err := NewCLI().Execute([]string{"-s", "app", "-d", t.TempDir(), "-c", configDir, "-q"})
+	if err == nil || !strings.Contains(err.Error(), "binary file has template extension") {
+		t.Fatalf("Execute() error = %v, want binary file error", err)
+	}
+
+	destDir := t.TempDir()
+	if err := NewCLI().Execute([]string{"-s", "app", "-d", destDir, "-c", configDir, "-q", "--copy-binary"}); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	assertContent(t, filepath.Join(destDir, "logo.png"), "\x89PNG\x00{{")
+}
@@ -0,0 +1,551 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alecthomas/kong"
+	"github.com/monochromegane/stamp/internal/config"
+	"github.com/monochromegane/stamp/internal/configdir"
+	"github.com/monochromegane/stamp/internal/stamp"
+)
+
+type CollectCmd struct {
+	Sheet          string   `required:"" help:"Sheet name to create" short:"s"`
+	Source         string   `arg:"" optional:"" default:"." help:"Source file or directory to collect (default: current directory)"`
+	Config         string   `optional:"" help:"Config directory path (overrides default)" short:"c"`
+	Template       bool     `optional:"" help:"Treat collected files as templates (add .stamp extension)" short:"t"`
+	AutoTemplate   bool     `optional:"" help:"Add the template extension only to files that contain {{ and }}; binary files are never templates"`
+	Ext            string   `optional:"" default:".stamp" help:"Template extension to add when --template or --auto-template is set (default: .stamp)" short:"e"`
+	Recursive      bool     `optional:"" default:"true" negatable:"" help:"Recursively copy directories (default: true, use --no-recursive to disable)" short:"r"`
+	DryRun         bool     `optional:"" help:"Print what would be collected without writing anything"`
+	List           bool     `optional:"" aliases:"list-files" help:"Print the source paths that would be collected, one per line, without writing anything"`
+	IncludeSpecial bool     `optional:"" help:"Recreate named pipes instead of skipping special files"`
+	Dotfiles       bool     `optional:"" default:"true" negatable:"" help:"Include entries whose name starts with '.' (default: true, use --no-dotfiles to skip them)"`
+	SheetRoot      string   `optional:"" default:"sheets" env:"STAMP_SHEET_ROOT" help:"Config subdirectory that holds sheets (default: sheets)"`
+	Exclude        []string `optional:"" sep:"none" help:"Skip files and directories matching a glob pattern (repeatable)"`
+	Detect         []string `optional:"" sep:"none" placeholder:"KEY=VALUE" help:"Replace whole-word occurrences of VALUE with {{.KEY}} and add the template extension to changed files (repeatable)"`
+	Merge          bool     `optional:"" help:"Add files to an existing sheet, keeping the files it already has"`
+	Force          bool     `optional:"" help:"With --merge, overwrite files that already exist in the sheet" short:"f"`
+	Since          string   `optional:"" placeholder:"DURATION|TIME" help:"Only collect files modified after this point: a duration ago (e.g. 24h) or a timestamp (RFC 3339 or YYYY-MM-DD)"`
+
+	Dereference           bool `optional:"" help:"Copy the targets of symlinks instead of recreating the links"`
+	AllowExternalSymlinks bool `optional:"" help:"With --dereference, copy targets of symlinks that point outside the source instead of skipping them"`
+
+	symlinks, externalSymlinks int            // Symlinks materialized by the last copy
+	links                      int            // Symlinks recreated by the last copy
+	detections                 []detection    // Parsed --detect values
+	replacements               map[string]int // Replacements made by --detect per written file
+	kept                       int            // Existing sheet files left alone by --merge
+	since                      time.Time      // Parsed --since; zero collects files of any age
+}
+
+func (c *CollectCmd) Run(ctx *kong.Context, logger stamp.Logger) error {
+	// 1. Resolve config directory
+	configDir, err := configdir.GetConfigDirWithOverride(c.Config)
+	if err != nil {
+		return err
+	}
+	if err := configdir.ValidateSheetRoot(c.SheetRoot); err != nil {
+		return err
+	}
+	for _, pattern := range c.Exclude {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid --exclude pattern %q: %w", pattern, err)
+		}
+	}
+	if c.detections, err = parseDetections(c.Detect); err != nil {
+		return err
+	}
+	if c.Force && !c.Merge {
+		return fmt.Errorf("--force can only be used with --merge")
+	}
+	if c.AutoTemplate && c.Template {
+		return fmt.Errorf("--auto-template can't be used with --template")
+	}
+	if c.List && c.DryRun {
+		return fmt.Errorf("--list can't be used with --dry-run")
+	}
+	if c.Since != "" {
+		if c.since, err = parseSince(c.Since, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	// 2. Validate source path exists
+	srcInfo, err := os.Stat(c.Source)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("source path not found: %s", c.Source)
+		}
+		return fmt.Errorf("failed to stat source: %w", err)
+	}
+
+	// List mode: print what the skip rules let through, whether or not the sheet exists
+	if c.List {
+		return c.listFiles(srcInfo)
+	}
+
+	// 3. Build destination: {configDir}/{SheetRoot}/{Sheet}/
+	destDir := configdir.SheetDir(configDir, c.SheetRoot, c.Sheet)
+
+	// 4. Check if sheet already exists; --merge adds to it instead
+	if _, err := os.Stat(destDir); !os.IsNotExist(err) && !c.Merge {
+		return fmt.Errorf("sheet '%s' already exists at %s (use --merge to add files to it)", c.Sheet, destDir)
+	}
+
+	// Dry run: report the plan without touching the config directory
+	if c.DryRun {
+		return c.printPlan(srcInfo, destDir)
+	}
+
+	// 5. Create destination directory
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return fmt.Errorf("failed to create sheet directory: %w", err)
+	}
+
+	// 6. Copy files
+	if srcInfo.IsDir() {
+		if err := c.copyDirWithSkip(c.Source, destDir, logger); err != nil {
+			return err
+		}
+	} else if stamp.IsSpecialFile(srcInfo.Mode()) {
+		return fmt.Errorf("source is a special file: %s", c.Source)
+	} else {
+		destPath := filepath.Join(destDir, filepath.Base(c.Source))
+		if err := c.copyFileWithTemplate(c.Source, destPath); err != nil {
+			return err
+		}
+	}
+
+	// 7. Print success message
+	fmt.Fprintf(os.Stdout, "Successfully collected to sheet '%s' at %s\n", c.Sheet, destDir)
+	if c.symlinks > 0 {
+		fmt.Fprintf(os.Stdout, "Materialized %d symlinks (%d external)\n", c.symlinks, c.externalSymlinks)
+	}
+	if c.links > 0 {
+		fmt.Fprintf(os.Stdout, "Recreated %d symlinks\n", c.links)
+	}
+	if c.kept > 0 {
+		fmt.Fprintf(os.Stdout, "Kept %d files that already exist in the sheet (use --force to overwrite them)\n", c.kept)
+	}
+	c.printReplacements(destDir)
+	return nil
+}
+
+// printReplacements reports how many values --detect replaced in each file, sorted by path
+func (c *CollectCmd) printReplacements(destDir string) {
+	paths := make([]string, 0, len(c.replacements))
+	for path := range c.replacements {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		relPath, err := filepath.Rel(destDir, path)
+		if err != nil {
+			relPath = path
+		}
+		fmt.Fprintf(os.Stdout, "Replaced %d detected values in %s\n", c.replacements[path], filepath.ToSlash(relPath))
+	}
+}
+
+// printPlan prints the files collect would write and the entries it would skip
+func (c *CollectCmd) printPlan(srcInfo os.FileInfo, destDir string) error {
+	var planned, skipped []string
+
+	if srcInfo.IsDir() {
+		err := c.walkSource(c.Source,
+			func(path, relPath string, mode os.FileMode) error {
+				if !mode.IsDir() {
+					planned = append(planned, c.planLine(path, filepath.ToSlash(relPath), mode, destDir))
+				}
+				return nil
+			},
+			func(relPath, reason string) {
+				skipped = append(skipped, fmt.Sprintf("%s (%s)", filepath.ToSlash(relPath), reason))
+			})
+		if err != nil {
+			return err
+		}
+	} else {
+		planned = append(planned, c.planLine(c.Source, filepath.Base(c.Source), srcInfo.Mode(), destDir))
+	}
+
+	fmt.Fprintf(os.Stdout, "Would collect to sheet '%s' at %s:\n", c.Sheet, destDir)
+	for _, line := range planned {
+		fmt.Fprintf(os.Stdout, "  %s\n", line)
+	}
+	if len(skipped) > 0 {
+		fmt.Fprintf(os.Stdout, "Would skip:\n")
+		for _, line := range skipped {
+			fmt.Fprintf(os.Stdout, "  %s\n", line)
+		}
+	}
+	return nil
+}
+
+// listFiles prints the source-relative path of each file collect would import, one per line
+// It walks the source like a real collect, so the list matches what would be written
+func (c *CollectCmd) listFiles(srcInfo os.FileInfo) error {
+	if !srcInfo.IsDir() {
+		if stamp.IsSpecialFile(srcInfo.Mode()) {
+			return fmt.Errorf("source is a special file: %s", c.Source)
+		}
+		fmt.Fprintln(os.Stdout, filepath.Base(c.Source))
+		return nil
+	}
+	return c.walkSource(c.Source,
+		func(path, relPath string, mode os.FileMode) error {
+			if !mode.IsDir() {
+				fmt.Fprintln(os.Stdout, filepath.ToSlash(relPath))
+			}
+			return nil
+		},
+		func(relPath, reason string) {})
+}
+
+// planLine describes where collect would write the file at path, what --detect would replace in it,
+// and, with --merge, what happens to a file of the same name already in the sheet at destDir
+func (c *CollectCmd) planLine(path, relPath string, mode os.FileMode, destDir string) string {
+	replaced := 0
+	markers := false
+	if (len(c.detections) > 0 || c.AutoTemplate) && !stamp.IsSpecialFile(mode) && (mode&os.ModeSymlink == 0 || c.Dereference) {
+		if content, err := os.ReadFile(path); err == nil {
+			markers = c.AutoTemplate && hasTemplateMarkers(content)
+			_, replaced = templatize(content, c.detections, !markers)
+		}
+	}
+
+	target, line := relPath, relPath
+	switch {
+	case replaced > 0:
+		target = relPath + c.Ext
+		line = fmt.Sprintf("%s -> %s (%d detected values)", relPath, target, replaced)
+	case c.Template || markers:
+		target = relPath + c.Ext
+		line = fmt.Sprintf("%s -> %s", relPath, target)
+	}
+	if c.Merge && entryExists(filepath.Join(destDir, filepath.FromSlash(target))) {
+		if c.Force {
+			return line + " (overwrites existing file)"
+		}
+		return line + " (exists, kept)"
+	}
+	return line
+}
+
+// Reasons reported for entries collect skips
+const (
+	skipGit         = "git metadata"
+	skipNotRecurse  = "directory, not recursive"
+	skipSpecialFile = "special file"
+	skipDotfile     = "dotfile"
+	skipExcluded    = "excluded"
+	skipNotModified = "not modified since --since"
+)
+
+// parseSince parses a --since value as a duration before now or as a timestamp
+// Dates without a time are midnight in the local time zone
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q: want a duration like 24h or a timestamp like 2024-01-02 or 2024-01-02T15:04:05Z", value)
+}
+
+// notModified reports whether the file at path is skipped by --since
+// Materialized symlinks are judged by their targets
+func (c *CollectCmd) notModified(path string, mode os.FileMode) bool {
+	if c.since.IsZero() || mode.IsDir() {
+		return false
+	}
+	var info os.FileInfo
+	var err error
+	if mode&os.ModeSymlink != 0 && c.Dereference {
+		info, err = os.Stat(path)
+	} else {
+		info, err = os.Lstat(path)
+	}
+	return err == nil && !info.ModTime().After(c.since)
+}
+
+// walkSource visits every entry of src that collect would import, applying the skip rules
+// visit receives the source path, the path relative to src, and the entry's type bits
+// skip receives the relative path and reason for each pruned entry
+func (c *CollectCmd) walkSource(src string, visit func(path, relPath string, mode os.FileMode) error, skip func(relPath, reason string)) error {
+	// Non-recursive mode: only visit files directly in src directory
+	if !c.Recursive {
+		entries, err := os.ReadDir(src)
+		if err != nil {
+			return fmt.Errorf("failed to read directory: %w", err)
+		}
+
+		for _, entry := range entries {
+			// Skip .git
+			if entry.Name() == ".git" {
+				skip(entry.Name(), skipGit)
+				continue
+			}
+
+			// Skip hidden entries with --no-dotfiles
+			if c.skipDotfile(entry.Name()) {
+				skip(entry.Name(), skipDotfile)
+				continue
+			}
+
+			// Skip entries matching --exclude
+			if c.excluded(entry.Name()) {
+				skip(entry.Name(), skipExcluded)
+				continue
+			}
+
+			// Skip directories in non-recursive mode
+			if entry.IsDir() {
+				skip(entry.Name(), skipNotRecurse)
+				continue
+			}
+
+			// Skip FIFOs, sockets and devices unless they can be recreated
+			if c.skipSpecial(entry.Type()) {
+				skip(entry.Name(), skipSpecialFile)
+				continue
+			}
+
+			// Skip symlinks that cannot or may not be materialized
+			if entry.Type()&os.ModeSymlink != 0 && c.Dereference {
+				if reason, _ := stamp.SymlinkSkipReason(src, filepath.Join(src, entry.Name()), "the source", c.AllowExternalSymlinks); reason != "" {
+					skip(entry.Name(), reason)
+					continue
+				}
+			}
+
+			// Skip files not modified since --since
+			if c.notModified(filepath.Join(src, entry.Name()), entry.Type()) {
+				skip(entry.Name(), skipNotModified)
+				continue
+			}
+
+			if err := visit(filepath.Join(src, entry.Name()), entry.Name(), entry.Type()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Recursive mode: use filepath.Walk
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(src, path)
+		if err != nil {
+			return fmt.Errorf("failed to get relative path: %w", err)
+		}
+
+		// Skip .git (both directory and file for git worktree support)
+		if info.Name() == ".git" {
+			skip(relPath, skipGit)
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil // Skip file
+		}
+
+		// Skip hidden entries with --no-dotfiles, pruning hidden directories
+		// The source itself is always collected, even if its name starts with '.'
+		if path != src && c.skipDotfile(info.Name()) {
+			skip(relPath, skipDotfile)
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// Skip entries matching --exclude, pruning matched directories
+		if path != src && c.excluded(relPath) {
+			skip(relPath, skipExcluded)
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// Skip FIFOs, sockets and devices unless they can be recreated
+		if c.skipSpecial(info.Mode()) {
+			skip(relPath, skipSpecialFile)
+			return nil
+		}
+
+		// Skip symlinks that cannot or may not be materialized
+		if info.Mode()&os.ModeSymlink != 0 && c.Dereference {
+			if reason, _ := stamp.SymlinkSkipReason(src, path, "the source", c.AllowExternalSymlinks); reason != "" {
+				skip(relPath, reason)
+				return nil
+			}
+		}
+
+		// Skip files not modified since --since
+		if c.notModified(path, info.Mode()) {
+			skip(relPath, skipNotModified)
+			return nil
+		}
+
+		return visit(path, relPath, info.Mode())
+	})
+}
+
+// skipDotfile reports whether a hidden entry is excluded by --no-dotfiles
+func (c *CollectCmd) skipDotfile(name string) bool {
+	return !c.Dotfiles && strings.HasPrefix(name, ".")
+}
+
+// excluded reports whether relPath, relative to the source, matches an --exclude pattern
+func (c *CollectCmd) excluded(relPath string) bool {
+	slashPath := filepath.ToSlash(relPath)
+	return slices.ContainsFunc(c.Exclude, func(pattern string) bool {
+		return config.MatchPath(pattern, slashPath)
+	})
+}
+
+// skipSpecial reports whether an entry is a special file collect will not import
+func (c *CollectCmd) skipSpecial(mode os.FileMode) bool {
+	return stamp.IsSpecialFile(mode) && !(c.IncludeSpecial && stamp.CanRecreateSpecial(mode))
+}
+
+func (c *CollectCmd) copyDirWithSkip(src, dest string, logger stamp.Logger) error {
+	return c.walkSource(src,
+		func(path, relPath string, mode os.FileMode) error {
+			destPath := filepath.Join(dest, relPath)
+			if mode.IsDir() {
+				// With --since, directories are created for the files collected into them
+				if !c.since.IsZero() {
+					return nil
+				}
+				return os.MkdirAll(destPath, 0755)
+			}
+			if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+				return err
+			}
+			if stamp.IsSpecialFile(mode) {
+				if keep, err := c.keepExisting(destPath); keep || err != nil {
+					return err
+				}
+				return stamp.RecreateSpecial(destPath, mode)
+			}
+			if mode&os.ModeSymlink != 0 && !c.Dereference {
+				if keep, err := c.keepExisting(destPath); keep || err != nil {
+					return err
+				}
+				c.links++
+				return copySymlink(path, destPath)
+			}
+			if mode&os.ModeSymlink != 0 {
+				c.symlinks++
+				if _, _, external, _ := stamp.ResolveSymlink(src, path); external {
+					c.externalSymlinks++
+				}
+			}
+			return c.copyFileWithTemplate(path, destPath)
+		},
+		func(relPath, reason string) {
+			slashPath := filepath.ToSlash(relPath)
+			switch reason {
+			case skipGit, skipNotRecurse, skipDotfile, skipExcluded, skipNotModified:
+				// Intentional skips are not worth a warning
+			case skipSpecialFile:
+				logger.Log(stamp.Event{Event: stamp.EventWarning, Path: slashPath,
+					Message: fmt.Sprintf("skipped special file %s", slashPath)})
+			default:
+				logger.Log(stamp.Event{Event: stamp.EventWarning, Path: slashPath,
+					Message: fmt.Sprintf("skipped %s: %s", slashPath, reason)})
+			}
+		})
+}
+
+// copySymlink creates a link at dest with the same target as the link at src
+// Links keep their name, even with --template, since their targets are not rendered
+func copySymlink(src, dest string) error {
+	target, err := os.Readlink(src)
+	if err != nil {
+		return fmt.Errorf("failed to read symlink %s: %w", src, err)
+	}
+	if err := os.Symlink(target, dest); err != nil {
+		return fmt.Errorf("failed to create symlink %s: %w", dest, err)
+	}
+	return nil
+}
+
+// keepExisting reports whether --merge leaves an existing sheet entry at dest alone
+// With --force the entry is removed instead, so links and special files can be recreated
+// and writes never follow an existing symlink
+func (c *CollectCmd) keepExisting(dest string) (bool, error) {
+	if !c.Merge || !entryExists(dest) {
+		return false, nil
+	}
+	if !c.Force {
+		c.kept++
+		return true, nil
+	}
+	if err := os.Remove(dest); err != nil {
+		return false, fmt.Errorf("failed to replace %s: %w", dest, err)
+	}
+	return false, nil
+}
+
+// entryExists reports whether any file, link or directory exists at path
+func entryExists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+func (c *CollectCmd) copyFileWithTemplate(src, dest string) error {
+	content, err := os.ReadFile(src)
+	if err != nil {
+		return fmt.Errorf("failed to read file %s: %w", src, err)
+	}
+
+	// With --auto-template, only files that already hold template actions become templates
+	markers := c.AutoTemplate && hasTemplateMarkers(content)
+
+	// Replace detected values; changed files must be rendered, so they become templates
+	// Delimiters already in the file are escaped unless --auto-template takes them as actions
+	content, replaced := templatize(content, c.detections, !markers)
+
+	// Add extension if template flag is set
+	if c.Template || markers || replaced > 0 {
+		dest = dest + c.Ext
+	}
+	if keep, err := c.keepExisting(dest); keep || err != nil {
+		return err
+	}
+	if replaced > 0 {
+		if c.replacements == nil {
+			c.replacements = make(map[string]int)
+		}
+		c.replacements[dest] = replaced
+	}
+
+	// Keep the source permissions so executables stay executable when pressed
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return fmt.Errorf("failed to stat file %s: %w", src, err)
+	}
+	if err := os.WriteFile(dest, content, srcInfo.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write file %s: %w", dest, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,514 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCollectCmd_DryRun(t *testing.T) {
+	configDir := t.TempDir()
+	srcDir := t.TempDir()
+
+	if err := os.MkdirAll(filepath.Join(srcDir, "sub"), 0755); err != nil {
+		t.Fatalf("failed to create sub dir: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(srcDir, ".git"), 0755); err != nil {
+		t.Fatalf("failed to create .git dir: %v", err)
+	}
+	for _, name := range []string{"main.go", "sub/util.go", ".git/HEAD"} {
+		if err := os.WriteFile(filepath.Join(srcDir, name), []byte("x"), 0644); err != nil {
+			t.Fatalf("failed to create %s: %v", name, err)
+		}
+	}
+
+	output, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"collect", "-s", "planned", "-c", configDir, "-t", "--dry-run", srcDir})
+	})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	for _, want := range []string{
+		"  main.go -> main.go.stamp\n",
+		"  sub/util.go -> sub/util.go.stamp\n",
+		"Would skip:\n  .git (git metadata)\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output = %q, want it to contain %q", output, want)
+		}
+	}
+	if strings.Contains(output, "HEAD") {
+		t.Errorf("output = %q, should not list files inside .git", output)
+	}
+
+	// Nothing is written
+	if _, err := os.Stat(filepath.Join(configDir, "sheets", "planned")); !os.IsNotExist(err) {
+		t.Error("sheet directory should not be created in dry-run mode")
+	}
+}
+
+func TestCollectCmd_Merge(t *testing.T) {
+	configDir := t.TempDir()
+	sheetDir := createSheet(t, configDir, "app", map[string]string{"main.go.stamp": "package {{.pkg}}\n", "README.md": "kept\n"})
+
+	srcDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(srcDir, ".git"), 0755); err != nil {
+		t.Fatalf("failed to create .git dir: %v", err)
+	}
+	for name, content := range map[string]string{"main.go": "package main\n", "util.go": "package main\n", ".git/HEAD": "ref"} {
+		if err := os.WriteFile(filepath.Join(srcDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to create %s: %v", name, err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "README.md"), []byte("new\n"), 0644); err != nil {
+		t.Fatalf("failed to create README.md: %v", err)
+	}
+
+	// Without --merge an existing sheet is refused
+	err := NewCLI().Execute([]string{"collect", "-s", "app", "-c", configDir, "-t", srcDir})
+	if err == nil || !strings.Contains(err.Error(), "use --merge") {
+		t.Fatalf("Execute() error = %v, want existing sheet error suggesting --merge", err)
+	}
+
+	output, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"collect", "-s", "app", "-c", configDir, "-t", "--merge", srcDir})
+	})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if !strings.Contains(output, "Kept 1 files") {
+		t.Errorf("output = %q, want it to report the kept file", output)
+	}
+	assertContent(t, filepath.Join(sheetDir, "main.go.stamp"), "package {{.pkg}}\n")
+	assertContent(t, filepath.Join(sheetDir, "util.go.stamp"), "package main\n")
+	assertContent(t, filepath.Join(sheetDir, "README.md"), "kept\n")
+	assertContent(t, filepath.Join(sheetDir, "README.md.stamp"), "new\n")
+	if _, err := os.Stat(filepath.Join(sheetDir, ".git")); !os.IsNotExist(err) {
+		t.Errorf(".git should not be collected, stat error = %v", err)
+	}
+
+	// --force replaces the existing files
+	if err := NewCLI().Execute([]string{"collect", "-s", "app", "-c", configDir, "-t", "--merge", "--force", srcDir}); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	assertContent(t, filepath.Join(sheetDir, "main.go.stamp"), "package main\n")
+
+	err = NewCLI().Execute([]string{"collect", "-s", "other", "-c", configDir, "--force", srcDir})
+	if err == nil || !strings.Contains(err.Error(), "--force can only be used with --merge") {
+		t.Errorf("Execute() error = %v, want --force to require --merge", err)
+	}
+}
+
+func TestCollectCmd_MergeDryRun(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "app", map[string]string{"main.go": "old\n"})
+
+	srcDir := t.TempDir()
+	for _, name := range []string{"main.go", "util.go"} {
+		if err := os.WriteFile(filepath.Join(srcDir, name), []byte("x"), 0644); err != nil {
+			t.Fatalf("failed to create %s: %v", name, err)
+		}
+	}
+
+	output, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"collect", "-s", "app", "-c", configDir, "--merge", "--dry-run", srcDir})
+	})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	for _, want := range []string{"  main.go (exists, kept)\n", "  util.go\n"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output = %q, want it to contain %q", output, want)
+		}
+	}
+}
+
+func TestCollectCmd_Since(t *testing.T) {
+	srcDir := t.TempDir()
+	old := time.Now().Add(-48 * time.Hour)
+	for name, modTime := range map[string]time.Time{
+		"recent.txt":         time.Now(),
+		"old.txt":            old,
+		"docs/recent.md":     time.Now(),
+		"docs/old.md":        old,
+		"archive/old/log.md": old,
+	} {
+		path := filepath.Join(srcDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatalf("failed to create %s: %v", name, err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("failed to set modification time of %s: %v", name, err)
+		}
+	}
+
+	configDir := t.TempDir()
+	if err := NewCLI().Execute([]string{"collect", "-s", "app", "-c", configDir, "--since", "24h", srcDir}); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	// Only recent files are collected, and directories left empty are not created
+	sheetDir := filepath.Join(configDir, "sheets", "app")
+	assertContent(t, filepath.Join(sheetDir, "recent.txt"), "recent.txt")
+	assertContent(t, filepath.Join(sheetDir, "docs", "recent.md"), "docs/recent.md")
+	for _, name := range []string{"old.txt", "docs/old.md", "archive"} {
+		if _, err := os.Stat(filepath.Join(sheetDir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s should not be collected, stat error = %v", name, err)
+		}
+	}
+
+	// A timestamp before every file collects them all
+	if err := NewCLI().Execute([]string{"collect", "-s", "all", "-c", configDir, "--since", old.Add(-time.Hour).Format(time.RFC3339), srcDir}); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	assertContent(t, filepath.Join(configDir, "sheets", "all", "archive", "old", "log.md"), "archive/old/log.md")
+
+	err := NewCLI().Execute([]string{"collect", "-s", "bad", "-c", configDir, "--since", "yesterday", srcDir})
+	if err == nil || !strings.Contains(err.Error(), `invalid --since "yesterday"`) {
+		t.Errorf("Execute() error = %v, want invalid --since error", err)
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"90m", now.Add(-90 * time.Minute)},
+		{"2024-03-01T08:30:00Z", time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC)},
+		{"2024-03-01", time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)},
+	}
+	for _, tt := range tests {
+		got, err := parseSince(tt.value, now)
+		if err != nil {
+			t.Fatalf("parseSince(%q) failed: %v", tt.value, err)
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseSince(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestCollectCmd_NoDotfiles(t *testing.T) {
+	for _, recursive := range []bool{true, false} {
+		configDir := t.TempDir()
+		srcDir := t.TempDir()
+		if err := os.MkdirAll(filepath.Join(srcDir, ".config"), 0755); err != nil {
+			t.Fatalf("failed to create .config dir: %v", err)
+		}
+		for _, name := range []string{"main.go", ".DS_Store", ".config/settings.json"} {
+			if err := os.WriteFile(filepath.Join(srcDir, name), []byte("x"), 0644); err != nil {
+				t.Fatalf("failed to create %s: %v", name, err)
+			}
+		}
+
+		args := []string{"collect", "-s", "clean", "-c", configDir, "--no-dotfiles", srcDir}
+		if !recursive {
+			args = append(args, "--no-recursive")
+		}
+		if _, err := captureStdout(t, func() error { return NewCLI().Execute(args) }); err != nil {
+			t.Fatalf("Execute(recursive=%v) failed: %v", recursive, err)
+		}
+
+		sheetDir := filepath.Join(configDir, "sheets", "clean")
+		if _, err := os.Stat(filepath.Join(sheetDir, "main.go")); err != nil {
+			t.Errorf("recursive=%v: main.go should be collected: %v", recursive, err)
+		}
+		for _, name := range []string{".DS_Store", ".config"} {
+			if _, err := os.Stat(filepath.Join(sheetDir, name)); !os.IsNotExist(err) {
+				t.Errorf("recursive=%v: %s should be skipped", recursive, name)
+			}
+		}
+	}
+}
+
+func TestCollectCmd_NoDotfilesDryRun(t *testing.T) {
+	configDir := t.TempDir()
+	srcDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(srcDir, ".DS_Store"), []byte("x"), 0644); err != nil {
+		t.Fatalf("failed to create .DS_Store: %v", err)
+	}
+
+	output, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"collect", "-s", "clean", "-c", configDir, "--no-dotfiles", "--dry-run", srcDir})
+	})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if !strings.Contains(output, "  .DS_Store (dotfile)\n") {
+		t.Errorf("output = %q, want .DS_Store listed as skipped", output)
+	}
+}
+
+func TestCollectCmd_Exclude(t *testing.T) {
+	for _, recursive := range []bool{true, false} {
+		configDir := t.TempDir()
+		srcDir := t.TempDir()
+		for _, dir := range []string{"tmp", "sub"} {
+			if err := os.MkdirAll(filepath.Join(srcDir, dir), 0755); err != nil {
+				t.Fatalf("failed to create %s dir: %v", dir, err)
+			}
+		}
+		for _, name := range []string{"main.go", "debug.log", "tmp/cache", "sub/trace.log", "sub/util.go"} {
+			if err := os.WriteFile(filepath.Join(srcDir, name), []byte("x"), 0644); err != nil {
+				t.Fatalf("failed to create %s: %v", name, err)
+			}
+		}
+
+		args := []string{"collect", "-s", "clean", "-c", configDir, "--exclude", "*.log", "--exclude", "tmp", srcDir}
+		if !recursive {
+			args = append(args, "--no-recursive")
+		}
+		if _, err := captureStdout(t, func() error { return NewCLI().Execute(args) }); err != nil {
+			t.Fatalf("Execute(recursive=%v) failed: %v", recursive, err)
+		}
+
+		sheetDir := filepath.Join(configDir, "sheets", "clean")
+		collected := []string{"main.go"}
+		if recursive {
+			collected = append(collected, "sub/util.go")
+		}
+		for _, name := range collected {
+			if _, err := os.Stat(filepath.Join(sheetDir, name)); err != nil {
+				t.Errorf("recursive=%v: %s should be collected: %v", recursive, name, err)
+			}
+		}
+		for _, name := range []string{"debug.log", "tmp", "sub/trace.log"} {
+			if _, err := os.Stat(filepath.Join(sheetDir, name)); !os.IsNotExist(err) {
+				t.Errorf("recursive=%v: %s should be excluded", recursive, name)
+			}
+		}
+	}
+}
+
+func TestCollectCmd_ExcludeRelativePath(t *testing.T) {
+	configDir := t.TempDir()
+	srcDir := t.TempDir()
+	for _, dir := range []string{"tmp", "sub/tmp"} {
+		if err := os.MkdirAll(filepath.Join(srcDir, dir), 0755); err != nil {
+			t.Fatalf("failed to create %s dir: %v", dir, err)
+		}
+	}
+	for _, name := range []string{"tmp/a", "sub/tmp/b"} {
+		if err := os.WriteFile(filepath.Join(srcDir, name), []byte("x"), 0644); err != nil {
+			t.Fatalf("failed to create %s: %v", name, err)
+		}
+	}
+
+	// A pattern with a slash only matches at that path
+	output, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"collect", "-s", "clean", "-c", configDir, "--exclude", "tmp/*", "--dry-run", srcDir})
+	})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if !strings.Contains(output, "  tmp/a (excluded)\n") || !strings.Contains(output, "  sub/tmp/b\n") {
+		t.Errorf("output = %q, want only tmp/a excluded", output)
+	}
+
+	err = NewCLI().Execute([]string{"collect", "-s", "bad", "-c", configDir, "--exclude", "[", srcDir})
+	if err == nil || !strings.Contains(err.Error(), "invalid --exclude pattern") {
+		t.Errorf("Execute() error = %v, want invalid pattern error", err)
+	}
+}
+
+func TestCollectCmd_Detect(t *testing.T) {
+	configDir := t.TempDir()
+	srcDir := t.TempDir()
+	files := map[string]string{
+		"go.mod":    "module github.com/acme/billing\n",
+		"main.go":   "package main\n\n// billing serves invoices; billingd is unrelated\nconst name = \"billing\"\n",
+		"LICENSE":   "MIT\n",
+		"README.md": "# acme\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(srcDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to create %s: %v", name, err)
+		}
+	}
+
+	output, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"collect", "-s", "svc", "-c", configDir, "--detect", "name=billing", "--detect", "org=acme", srcDir})
+	})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	sheetDir := filepath.Join(configDir, "sheets", "svc")
+	assertContent(t, filepath.Join(sheetDir, "go.mod.stamp"), "module github.com/{{.org}}/{{.name}}\n")
+	assertContent(t, filepath.Join(sheetDir, "main.go.stamp"), "package main\n\n// {{.name}} serves invoices; billingd is unrelated\nconst name = \"{{.name}}\"\n")
+	assertContent(t, filepath.Join(sheetDir, "README.md.stamp"), "# {{.org}}\n")
+	assertContent(t, filepath.Join(sheetDir, "LICENSE"), "MIT\n")
+	for _, want := range []string{
+		"Replaced 2 detected values in go.mod.stamp\n",
+		"Replaced 2 detected values in main.go.stamp\n",
+		"Replaced 1 detected values in README.md.stamp\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output = %q, want it to contain %q", output, want)
+		}
+	}
+
+	if err := NewCLI().Execute([]string{"collect", "-s", "bad", "-c", configDir, "--detect", "name", srcDir}); err == nil {
+		t.Error("--detect without a value should fail")
+	}
+}
+
+// TestCollectCmd_DetectEscapesDelims tests that a detected file keeps its existing {{ }} literally when pressed
+func TestCollectCmd_DetectEscapesDelims(t *testing.T) {
+	configDir := t.TempDir()
+	srcDir := t.TempDir()
+	workflow := "name: billing\non:\n  push:\n    branches: [${{ github.ref }}]\n"
+	if err := os.WriteFile(filepath.Join(srcDir, "ci.yml"), []byte(workflow), 0644); err != nil {
+		t.Fatalf("failed to create ci.yml: %v", err)
+	}
+
+	if _, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"collect", "-s", "svc", "-c", configDir, "--detect", "name=billing", srcDir})
+	}); err != nil {
+		t.Fatalf("collect failed: %v", err)
+	}
+
+	destDir := t.TempDir()
+	if _, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"-s", "svc", "-c", configDir, "-d", destDir, "name=invoices"})
+	}); err != nil {
+		t.Fatalf("press failed: %v", err)
+	}
+	assertContent(t, filepath.Join(destDir, "ci.yml"), "name: invoices\non:\n  push:\n    branches: [${{ github.ref }}]\n")
+}
+
+func TestTemplatize(t *testing.T) {
+	detections, err := parseDetections([]string{"app=app", "full=my-app"})
+	if err != nil {
+		t.Fatalf("parseDetections() failed: %v", err)
+	}
+
+	tests := []struct {
+		content string
+		want    string
+		count   int
+	}{
+		{"app", "{{.app}}", 1},
+		{"my-app and app", "{{.full}} and {{.app}}", 2},
+		{"apps mapp app_1", "apps mapp app_1", 0},
+		{"(app)", "({{.app}})", 1},
+		{"bin\x00app", "bin\x00app", 0},
+		{"ref: ${{ github.ref }}", "ref: ${{ github.ref }}", 0},
+		{"app: ${{ github.ref }}", `{{.app}}: ${{"{{"}} github.ref {{"}}"}}`, 1},
+	}
+	for _, tt := range tests {
+		got, count := templatize([]byte(tt.content), detections, true)
+		if string(got) != tt.want || count != tt.count {
+			t.Errorf("templatize(%q) = %q, %d, want %q, %d", tt.content, got, count, tt.want, tt.count)
+		}
+	}
+}
+
+func TestCollectCmd_AutoTemplate(t *testing.T) {
+	configDir := t.TempDir()
+	srcDir := t.TempDir()
+	files := map[string]string{
+		"config.yaml": "name: {{.name}}\n",
+		"README.md":   "# plain {{ text\n",
+		"logo.png":    "\x89PNG\x00{{.x}}",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(srcDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to create %s: %v", name, err)
+		}
+	}
+
+	output, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"collect", "-s", "auto", "-c", configDir, "--auto-template", "--dry-run", srcDir})
+	})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	for _, want := range []string{"  config.yaml -> config.yaml.stamp\n", "  README.md\n", "  logo.png\n"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output = %q, want it to contain %q", output, want)
+		}
+	}
+
+	if _, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"collect", "-s", "auto", "-c", configDir, "--auto-template", srcDir})
+	}); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	sheetDir := filepath.Join(configDir, "sheets", "auto")
+	assertContent(t, filepath.Join(sheetDir, "config.yaml.stamp"), "name: {{.name}}\n")
+	assertContent(t, filepath.Join(sheetDir, "README.md"), "# plain {{ text\n")
+	assertContent(t, filepath.Join(sheetDir, "logo.png"), "\x89PNG\x00{{.x}}")
+
+	if err := NewCLI().Execute([]string{"collect", "-s", "both", "-c", configDir, "--auto-template", "-t", srcDir}); err == nil {
+		t.Error("--auto-template with --template should fail")
+	}
+}
+
+func TestCollectCmd_List(t *testing.T) {
+	configDir := t.TempDir()
+	srcDir := t.TempDir()
+	for _, dir := range []string{".git", "sub", "tmp", ".cache"} {
+		if err := os.MkdirAll(filepath.Join(srcDir, dir), 0755); err != nil {
+			t.Fatalf("failed to create %s dir: %v", dir, err)
+		}
+	}
+	for _, name := range []string{"main.go", ".env", ".git/HEAD", "sub/util.go", "sub/debug.log", "tmp/cache", ".cache/x"} {
+		if err := os.WriteFile(filepath.Join(srcDir, name), []byte("x"), 0644); err != nil {
+			t.Fatalf("failed to create %s: %v", name, err)
+		}
+	}
+	flags := []string{"--exclude", "*.log", "--exclude", "tmp", "--no-dotfiles", srcDir}
+
+	output, err := captureStdout(t, func() error {
+		return NewCLI().Execute(append([]string{"collect", "-s", "app", "-c", configDir, "--list"}, flags...))
+	})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if output != "main.go\nsub/util.go\n" {
+		t.Errorf("output = %q, want main.go and sub/util.go", output)
+	}
+	sheetDir := filepath.Join(configDir, "sheets", "app")
+	if _, err := os.Stat(sheetDir); !os.IsNotExist(err) {
+		t.Error("sheet directory should not be created with --list")
+	}
+
+	// The listed files are exactly the files a real collect writes
+	if _, err := captureStdout(t, func() error {
+		return NewCLI().Execute(append([]string{"collect", "-s", "app", "-c", configDir}, flags...))
+	}); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	var collected []string
+	err = filepath.Walk(sheetDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(sheetDir, path)
+		collected = append(collected, filepath.ToSlash(rel))
+		return err
+	})
+	if err != nil {
+		t.Fatalf("failed to walk sheet: %v", err)
+	}
+	if got := strings.Join(collected, "\n") + "\n"; got != output {
+		t.Errorf("collected files = %q, listed %q", got, output)
+	}
+
+	// Listing works for an existing sheet too, since nothing is written
+	output, err = captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"collect", "-s", "app", "-c", configDir, "--list-files", filepath.Join(srcDir, "main.go")})
+	})
+	if err != nil || output != "main.go\n" {
+		t.Errorf("Execute() = %q, %v; want main.go", output, err)
+	}
+}
@@ -0,0 +1,146 @@
+//go:build unix
+
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestCollectCmd_SkipsFIFO(t *testing.T) {
+	configDir := t.TempDir()
+	srcDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(srcDir, "file.txt"), []byte("content"), 0644); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+	if err := syscall.Mkfifo(filepath.Join(srcDir, "pipe"), 0644); err != nil {
+		t.Skipf("mkfifo not available: %v", err)
+	}
+
+	var stderr bytes.Buffer
+	cli := NewCLI()
+	cli.stderr = &stderr
+	if _, err := captureStdout(t, func() error {
+		return cli.Execute([]string{"collect", "-s", "fifo", "-c", configDir, srcDir})
+	}); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	sheetDir := filepath.Join(configDir, "sheets", "fifo")
+	if _, err := os.Stat(filepath.Join(sheetDir, "file.txt")); err != nil {
+		t.Errorf("regular file should be collected: %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(sheetDir, "pipe")); !os.IsNotExist(err) {
+		t.Error("FIFO should not be collected")
+	}
+	if !strings.Contains(stderr.String(), "Warning: skipped special file pipe") {
+		t.Errorf("stderr = %q, want special file warning", stderr.String())
+	}
+}
+
+func TestCollectCmd_SymlinksDereference(t *testing.T) {
+	configDir := t.TempDir()
+	srcDir := t.TempDir()
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(srcDir, "file.txt"), []byte("content"), 0644); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0644); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+	if err := os.Symlink("file.txt", filepath.Join(srcDir, "alias.txt")); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(srcDir, "leak.txt")); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+
+	var stderr bytes.Buffer
+	cli := NewCLI()
+	cli.stderr = &stderr
+	output, err := captureStdout(t, func() error {
+		return cli.Execute([]string{"collect", "-s", "linked", "-c", configDir, "--dereference", srcDir})
+	})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	sheetDir := filepath.Join(configDir, "sheets", "linked")
+	assertContent(t, filepath.Join(sheetDir, "alias.txt"), "content")
+	if _, err := os.Lstat(filepath.Join(sheetDir, "leak.txt")); !os.IsNotExist(err) {
+		t.Error("leak.txt should be refused")
+	}
+	if !strings.Contains(output, "Materialized 1 symlinks (0 external)") {
+		t.Errorf("output = %q, want symlink summary", output)
+	}
+	if !strings.Contains(stderr.String(), "leak.txt") {
+		t.Errorf("stderr = %q, want a warning for leak.txt", stderr.String())
+	}
+}
+
+func TestCollectCmd_SymlinksRecreated(t *testing.T) {
+	for _, recursive := range []bool{true, false} {
+		configDir := t.TempDir()
+		srcDir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(srcDir, "v2.txt"), []byte("v2"), 0644); err != nil {
+			t.Fatalf("failed to create file: %v", err)
+		}
+		if err := os.Symlink("v2.txt", filepath.Join(srcDir, "latest.txt")); err != nil {
+			t.Fatalf("failed to create symlink: %v", err)
+		}
+
+		args := []string{"collect", "-s", "linked", "-c", configDir, "-t", srcDir}
+		if !recursive {
+			args = append(args, "--no-recursive")
+		}
+		output, err := captureStdout(t, func() error { return NewCLI().Execute(args) })
+		if err != nil {
+			t.Fatalf("Execute(recursive=%v) failed: %v", recursive, err)
+		}
+
+		// The link keeps its name and target; only regular files get the template extension
+		sheetDir := filepath.Join(configDir, "sheets", "linked")
+		if target, err := os.Readlink(filepath.Join(sheetDir, "latest.txt")); err != nil || target != "v2.txt" {
+			t.Errorf("recursive=%v: latest.txt -> %q (%v), want v2.txt", recursive, target, err)
+		}
+		if !strings.Contains(output, "Recreated 1 symlinks") {
+			t.Errorf("recursive=%v: output = %q, want symlink summary", recursive, output)
+		}
+	}
+}
+
+func TestCollectCmd_PreservesExecutableBit(t *testing.T) {
+	configDir := t.TempDir()
+	srcDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(srcDir, "build.sh"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatalf("failed to create script: %v", err)
+	}
+
+	if _, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"collect", "-s", "scripts", "-c", configDir, srcDir})
+	}); err != nil {
+		t.Fatalf("collect failed: %v", err)
+	}
+
+	destDir := t.TempDir()
+	if err := NewCLI().Execute([]string{"-s", "scripts", "-d", destDir, "-c", configDir, "-q"}); err != nil {
+		t.Fatalf("press failed: %v", err)
+	}
+
+	for _, path := range []string{
+		filepath.Join(configDir, "sheets", "scripts", "build.sh"),
+		filepath.Join(destDir, "build.sh"),
+	} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("failed to stat %s: %v", path, err)
+		}
+		if info.Mode().Perm()&0111 == 0 {
+			t.Errorf("%s should be executable, mode is %v", path, info.Mode().Perm())
+		}
+	}
+}
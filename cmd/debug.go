@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/alecthomas/kong"
+	"github.com/monochromegane/stamp/internal/stamp"
+)
+
+// DebugCmd groups commands that help sheet authors inspect stamp's behavior
+type DebugCmd struct {
+	Tree DebugTreeCmd `cmd:"" help:"Print a template's parse tree and the variables validation extracts from it"`
+}
+
+type DebugTreeCmd struct {
+	File       string `arg:"" type:"existingfile" help:"Template file to inspect"`
+	Ext        string `optional:"" default:".stamp" help:"Stamp file extension (default: .stamp)" short:"e"`
+	LeftDelim  string `optional:"" default:"{{" help:"Opening delimiter of template actions (default: {{)"`
+	RightDelim string `optional:"" default:"}}" help:"Closing delimiter of template actions (default: }})"`
+}
+
+func (c *DebugTreeCmd) Run(ctx *kong.Context) error {
+	return stamp.New(nil, c.Ext, stamp.WithDelims(c.LeftDelim, c.RightDelim)).DumpParseTree(os.Stdout, c.File)
+}
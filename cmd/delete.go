@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alecthomas/kong"
+	"github.com/monochromegane/stamp/internal/configdir"
+)
+
+type DeleteCmd struct {
+	Sheet     string `required:"" help:"Sheet name to delete" short:"s"`
+	Config    string `optional:"" help:"Config directory path (overrides default)" short:"c"`
+	SheetRoot string `optional:"" default:"sheets" env:"STAMP_SHEET_ROOT" help:"Config subdirectory that holds sheets (default: sheets)"`
+	Yes       bool   `optional:"" help:"Delete without asking for confirmation" short:"y"`
+}
+
+func (c *DeleteCmd) Run(ctx *kong.Context) error {
+	// 1. Resolve config directory
+	configDir, err := configdir.GetConfigDirWithOverride(c.Config)
+	if err != nil {
+		return err
+	}
+	if err := configdir.ValidateSheetRoot(c.SheetRoot); err != nil {
+		return err
+	}
+
+	// 2. Refuse names that would resolve to the sheet root or outside it
+	if !filepath.IsLocal(c.Sheet) || filepath.Clean(c.Sheet) == "." {
+		return fmt.Errorf("invalid sheet name %q", c.Sheet)
+	}
+
+	// 3. Validate the sheet exists, listing available sheets if not
+	sheetDir, err := configdir.ResolveTemplateDirWithRoot(configDir, c.SheetRoot, c.Sheet)
+	if err != nil {
+		return err
+	}
+
+	// 4. Confirm unless --yes was given
+	if !c.Yes {
+		fmt.Fprintf(os.Stdout, "Delete sheet '%s' at %s? [y/N]: ", c.Sheet, sheetDir)
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if !isYes(answer) {
+			fmt.Fprintf(os.Stdout, "Aborted, sheet '%s' was not deleted\n", c.Sheet)
+			return nil
+		}
+	}
+
+	// 5. Remove the sheet directory tree
+	if err := os.RemoveAll(sheetDir); err != nil {
+		return fmt.Errorf("failed to delete sheet: %w", err)
+	}
+
+	fmt.Fprintf(os.Stdout, "Deleted sheet '%s' from %s\n", c.Sheet, sheetDir)
+	return nil
+}
+
+// isYes reports whether a confirmation answer accepts the prompt
+func isYes(answer string) bool {
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
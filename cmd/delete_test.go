@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDeleteCmd(t *testing.T) {
+	configDir := t.TempDir()
+	sheetDir := createSheet(t, configDir, "old", map[string]string{"a.txt": "a"})
+	createSheet(t, configDir, "keep", map[string]string{"b.txt": "b"})
+
+	output, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"delete", "-s", "old", "-c", configDir, "-y"})
+	})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if !strings.Contains(output, "Deleted sheet 'old'") {
+		t.Errorf("output = %q, want deletion message", output)
+	}
+	if _, err := os.Stat(sheetDir); !os.IsNotExist(err) {
+		t.Error("sheet directory should be removed")
+	}
+	if _, err := os.Stat(filepath.Join(configDir, "sheets", "keep", "b.txt")); err != nil {
+		t.Errorf("other sheets should be kept: %v", err)
+	}
+}
+
+func TestDeleteCmd_Missing(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "keep", map[string]string{"b.txt": "b"})
+
+	err := NewCLI().Execute([]string{"delete", "-s", "nope", "-c", configDir, "-y"})
+	if err == nil {
+		t.Fatal("Execute() should fail for a missing sheet")
+	}
+	if !strings.Contains(err.Error(), "sheet 'nope' not found") || !strings.Contains(err.Error(), "  - keep") {
+		t.Errorf("error = %q, want not found with available sheets", err.Error())
+	}
+}
+
+func TestDeleteCmd_InvalidName(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "keep", map[string]string{"b.txt": "b"})
+
+	for _, name := range []string{".", "..", "../sheets", "keep/.."} {
+		if err := NewCLI().Execute([]string{"delete", "-s", name, "-c", configDir, "-y"}); err == nil {
+			t.Errorf("delete -s %q should fail", name)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(configDir, "sheets", "keep")); err != nil {
+		t.Errorf("sheets should be untouched: %v", err)
+	}
+}
+
+func TestDeleteCmd_Confirmation(t *testing.T) {
+	configDir := t.TempDir()
+	sheetDir := createSheet(t, configDir, "old", map[string]string{"a.txt": "a"})
+
+	// Anything but yes keeps the sheet
+	withStdin(t, "n\n")
+	output, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"delete", "-s", "old", "-c", configDir})
+	})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if !strings.Contains(output, "Delete sheet 'old' at "+sheetDir+"? [y/N]: ") || !strings.Contains(output, "Aborted") {
+		t.Errorf("output = %q, want prompt and abort message", output)
+	}
+	if _, err := os.Stat(sheetDir); err != nil {
+		t.Errorf("sheet should be kept when not confirmed: %v", err)
+	}
+
+	withStdin(t, "yes\n")
+	if _, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"delete", "-s", "old", "-c", configDir})
+	}); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if _, err := os.Stat(sheetDir); !os.IsNotExist(err) {
+		t.Error("sheet should be removed when confirmed")
+	}
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/monochromegane/stamp/internal/stamp"
+)
+
+// detection replaces literal occurrences of a value with a variable reference
+type detection struct {
+	key   string
+	value []byte
+}
+
+// detectKeyPattern matches keys that can be referenced as {{.KEY}}
+var detectKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+// parseDetections parses --detect KEY=VALUE specs, longest value first
+// so a value that contains another is replaced as a whole
+func parseDetections(specs []string) ([]detection, error) {
+	detections := make([]detection, 0, len(specs))
+	for _, spec := range specs {
+		key, value, found := strings.Cut(spec, "=")
+		if !found || value == "" || !detectKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("invalid --detect %q: want KEY=VALUE with a non-empty value and a KEY usable as {{.KEY}}", spec)
+		}
+		detections = append(detections, detection{key: key, value: []byte(value)})
+	}
+	sort.SliceStable(detections, func(i, j int) bool {
+		return len(detections[i].value) > len(detections[j].value)
+	})
+	return detections, nil
+}
+
+// templatize replaces whole-word occurrences of each detected value in content with {{.KEY}}
+// It returns the new content and the number of replacements; binary content is left unchanged
+// With escape, delimiters already in content are rewritten as {{"{{"}} and {{"}}"}} so they render
+// literally, as in a GitHub workflow's ${{ github.ref }}
+func templatize(content []byte, detections []detection, escape bool) ([]byte, int) {
+	if len(detections) == 0 || stamp.IsBinary(content) {
+		return content, 0
+	}
+
+	var out bytes.Buffer
+	count := 0
+	for i := 0; i < len(content); {
+		if d, ok := detectionAt(content, i, detections); ok {
+			fmt.Fprintf(&out, "{{.%s}}", d.key)
+			i += len(d.value)
+			count++
+			continue
+		}
+		if delim, ok := delimAt(content, i); ok && escape {
+			fmt.Fprintf(&out, "{{%q}}", delim)
+			i += len(delim)
+			continue
+		}
+		out.WriteByte(content[i])
+		i++
+	}
+	if count == 0 {
+		return content, 0
+	}
+	return out.Bytes(), count
+}
+
+// detectionAt returns the first detection whose value occurs at content[i:] as a whole word
+func detectionAt(content []byte, i int, detections []detection) (detection, bool) {
+	for _, d := range detections {
+		if !bytes.HasPrefix(content[i:], d.value) {
+			continue
+		}
+		before, _ := utf8.DecodeLastRune(content[:i])
+		after, _ := utf8.DecodeRune(content[i+len(d.value):])
+		first, _ := utf8.DecodeRune(d.value)
+		last, _ := utf8.DecodeLastRune(d.value)
+		// A boundary is only needed where the value itself starts or ends with a word character
+		if i > 0 && isWordRune(first) && isWordRune(before) {
+			continue
+		}
+		if i+len(d.value) < len(content) && isWordRune(last) && isWordRune(after) {
+			continue
+		}
+		return d, true
+	}
+	return detection{}, false
+}
+
+// delimAt returns the default template delimiter that starts at content[i], if any
+func delimAt(content []byte, i int) (string, bool) {
+	for _, delim := range []string{"{{", "}}"} {
+		if bytes.HasPrefix(content[i:], []byte(delim)) {
+			return delim, true
+		}
+	}
+	return "", false
+}
+
+// isWordRune reports whether r is part of a word for whole-word matching
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// hasTemplateMarkers reports whether content holds a template action, a "{{" followed later by "}}"
+// Binary content is never a template, whatever bytes it contains
+func hasTemplateMarkers(content []byte) bool {
+	if stamp.IsBinary(content) {
+		return false
+	}
+	_, after, found := bytes.Cut(content, []byte("{{"))
+	return found && bytes.Contains(after, []byte("}}"))
+}
@@ -0,0 +1,306 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alecthomas/kong"
+	"github.com/monochromegane/stamp/internal/stamp"
+)
+
+type DiffCmd struct {
+	renderFlags
+	Dest string            `optional:"" default:"." help:"Destination directory to compare against (default: current directory)" short:"d"`
+	Vars map[string]string `arg:"" optional:"" help:"Template variables in KEY=VALUE format"`
+}
+
+// diffContext is the number of unchanged lines shown around each change
+const diffContext = 3
+
+func (c *DiffCmd) Run(ctx *kong.Context, logger stamp.Logger) error {
+	if err := c.checkOnly(); err != nil {
+		return err
+	}
+	return c.pressCmd().dryRun(os.Stdout, logger)
+}
+
+// dryRun renders the sheets into a scratch directory, writes the diff against the destination
+// and lists the post hooks a press would run, without writing files or running hooks
+func (c *PressCmd) dryRun(w io.Writer, logger stamp.Logger) error {
+	// 1. Resolve sheets and variables exactly as press does
+	srcDirs, sheets, vars, err := c.prepare(logger)
+	if err != nil {
+		return err
+	}
+
+	// 2. Render into a scratch directory so the destination is never touched
+	scratch, err := os.MkdirTemp("", "stamp-diff-")
+	if err != nil {
+		return fmt.Errorf("failed to create scratch directory: %w", err)
+	}
+	defer os.RemoveAll(scratch)
+
+	if _, err := c.newStamper(vars, logger, stamp.OverwriteError).ExecuteMultiple(srcDirs, scratch); err != nil {
+		return fmt.Errorf("stamp failed: %w", err)
+	}
+
+	// 3. Compare every rendered file with the destination
+	changed, err := diffTrees(w, scratch, c.Dest[0])
+	if err != nil {
+		return err
+	}
+	if changed == 0 {
+		fmt.Fprintf(w, "No changes\n")
+	}
+
+	// 4. List the post hooks press would run, without running them
+	if !c.NoHooks {
+		c.listHooks(w, sheets)
+	}
+	return nil
+}
+
+// pressCmd returns the press flags equivalent to the diff flags
+func (c *DiffCmd) pressCmd() *PressCmd {
+	return &PressCmd{renderFlags: c.renderFlags, Dest: []string{c.Dest}, Vars: c.Vars}
+}
+
+// treeChange is a rendered file that is missing from the destination or differs from it
+type treeChange struct {
+	path    string // Slash-separated path relative to both trees
+	missing bool   // The destination has no file at path
+	have    []byte // Destination content, nil if missing
+	want    []byte // Rendered content
+}
+
+// compareTrees returns a change for every regular file under rendered that is missing from dest
+// or differs from the file at the same path, in walk order
+func compareTrees(rendered, dest string) ([]treeChange, error) {
+	var changes []treeChange
+	err := filepath.WalkDir(rendered, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(rendered, path)
+		if err != nil {
+			return fmt.Errorf("failed to get relative path: %w", err)
+		}
+		slashPath := filepath.ToSlash(relPath)
+
+		want, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read rendered file: %w", err)
+		}
+		have, err := os.ReadFile(filepath.Join(dest, relPath))
+		if os.IsNotExist(err) {
+			changes = append(changes, treeChange{path: slashPath, missing: true, want: want})
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read destination file: %w", err)
+		}
+		if !bytes.Equal(have, want) {
+			changes = append(changes, treeChange{path: slashPath, have: have, want: want})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// diffTrees writes a diff for every regular file under rendered that differs from the file
+// at the same path under dest, and returns the number of new or changed files
+func diffTrees(w io.Writer, rendered, dest string) (int, error) {
+	changes, err := compareTrees(rendered, dest)
+	if err != nil {
+		return 0, err
+	}
+	for _, change := range changes {
+		switch {
+		case change.missing:
+			fmt.Fprintf(w, "new file: %s\n", change.path)
+		case stamp.IsBinary(change.have) || stamp.IsBinary(change.want):
+			fmt.Fprintf(w, "Binary files differ: %s\n", change.path)
+		default:
+			fmt.Fprint(w, unifiedDiff("a/"+change.path, "b/"+change.path, string(change.have), string(change.want)))
+		}
+	}
+	return len(changes), nil
+}
+
+// maxDiffCells bounds the size of the line-matching table; larger changes are shown as
+// a single replacement of the differing region
+const maxDiffCells = 4 << 20
+
+// diffLine is one line of an edit script: ' ' unchanged, '-' removed, '+' added
+type diffLine struct {
+	kind byte
+	text string
+}
+
+// unifiedDiff returns a unified diff turning oldText into newText, or "" if they are equal
+func unifiedDiff(oldName, newName, oldText, newText string) string {
+	lines := editScript(splitLines(oldText), splitLines(newText))
+
+	var b strings.Builder
+	for start := 0; start < len(lines); {
+		// Find the next change and extend the hunk while changes are close together
+		first := start
+		for first < len(lines) && lines[first].kind == ' ' {
+			first++
+		}
+		if first == len(lines) {
+			break
+		}
+		last := first
+		for i := first; i < len(lines) && i-last <= 2*diffContext; i++ {
+			if lines[i].kind != ' ' {
+				last = i
+			}
+		}
+		from := max(start, first-diffContext)
+		to := min(len(lines), last+diffContext+1)
+
+		if b.Len() == 0 {
+			fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldName, newName)
+		}
+		writeHunk(&b, lines, from, to)
+		start = to
+	}
+	return b.String()
+}
+
+// writeHunk writes lines[from:to] as one hunk with its header
+func writeHunk(b *strings.Builder, lines []diffLine, from, to int) {
+	// Count the lines of each side before and within the hunk
+	oldStart, newStart := 0, 0
+	for _, l := range lines[:from] {
+		if l.kind != '+' {
+			oldStart++
+		}
+		if l.kind != '-' {
+			newStart++
+		}
+	}
+	oldCount, newCount := 0, 0
+	for _, l := range lines[from:to] {
+		if l.kind != '+' {
+			oldCount++
+		}
+		if l.kind != '-' {
+			newCount++
+		}
+	}
+
+	fmt.Fprintf(b, "@@ -%s +%s @@\n", hunkRange(oldStart, oldCount), hunkRange(newStart, newCount))
+	for _, l := range lines[from:to] {
+		text, hasNewline := strings.CutSuffix(l.text, "\n")
+		fmt.Fprintf(b, "%c%s\n", l.kind, text)
+		if !hasNewline {
+			b.WriteString("\\ No newline at end of file\n")
+		}
+	}
+}
+
+// hunkRange formats the 1-based start and length of a hunk side
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// splitLines splits s into lines that keep their trailing newline
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// editScript returns a shortest edit script turning a into b based on their longest common subsequence
+func editScript(a, b []string) []diffLine {
+	// Common prefix and suffix are matched directly to keep the table small
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var lines []diffLine
+	for _, text := range a[:prefix] {
+		lines = append(lines, diffLine{' ', text})
+	}
+	lines = append(lines, editMiddle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, text := range a[len(a)-suffix:] {
+		lines = append(lines, diffLine{' ', text})
+	}
+	return lines
+}
+
+// editMiddle matches lines with a longest common subsequence table
+func editMiddle(a, b []string) []diffLine {
+	var lines []diffLine
+	if (len(a)+1)*(len(b)+1) > maxDiffCells {
+		for _, text := range a {
+			lines = append(lines, diffLine{'-', text})
+		}
+		for _, text := range b {
+			lines = append(lines, diffLine{'+', text})
+		}
+		return lines
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, diffLine{' ', a[i]})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, diffLine{'-', a[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', b[j]})
+			j++
+		}
+	}
+	return lines
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiffCmd(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "app", map[string]string{
+		"config.yaml.stamp": "name: {{.name}}\nport: 8080\ndebug: false\n",
+		"LICENSE":           "MIT\n",
+		"README.md.stamp":   "# {{.name}}\n",
+	})
+	destDir := t.TempDir()
+	for name, content := range map[string]string{
+		"config.yaml": "name: old\nport: 8080\ndebug: false\n",
+		"LICENSE":     "MIT\n",
+	} {
+		if err := os.WriteFile(filepath.Join(destDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	output, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"diff", "-s", "app", "-d", destDir, "-c", configDir, "name=app"})
+	})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	want := "new file: README.md\n" +
+		"--- a/config.yaml\n" +
+		"+++ b/config.yaml\n" +
+		"@@ -1,3 +1,3 @@\n" +
+		"-name: old\n" +
+		"+name: app\n" +
+		" port: 8080\n" +
+		" debug: false\n"
+	if output != want {
+		t.Errorf("output = %q, want %q", output, want)
+	}
+
+	// The destination is left untouched
+	assertContent(t, filepath.Join(destDir, "config.yaml"), "name: old\nport: 8080\ndebug: false\n")
+	if _, err := os.Stat(filepath.Join(destDir, "README.md")); !os.IsNotExist(err) {
+		t.Errorf("README.md should not be written, stat error = %v", err)
+	}
+}
+
+func TestDiffCmd_SharesPressFlags(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "app", map[string]string{"a.txt": "a\n", "b.txt": "b\n", "logo.png": "\x89PNG\x00new"})
+	sheetsFile := filepath.Join(t.TempDir(), "sheets.txt")
+	if err := os.WriteFile(sheetsFile, []byte("app\n"), 0644); err != nil {
+		t.Fatalf("failed to write sheets file: %v", err)
+	}
+	destDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(destDir, "logo.png"), []byte("\x89PNG\x00old"), 0644); err != nil {
+		t.Fatalf("failed to write logo.png: %v", err)
+	}
+
+	// --sheets-file and --only are accepted like in press, and binary files are not diffed
+	output, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"diff", "--sheets-file", sheetsFile, "-d", destDir, "-c", configDir, "--only", "a.txt", "--only", "*.png"})
+	})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if want := "new file: a.txt\nBinary files differ: logo.png\n"; output != want {
+		t.Errorf("output = %q, want %q", output, want)
+	}
+}
+
+func TestDiffCmd_NoChanges(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "app", map[string]string{"LICENSE": "MIT\n"})
+	destDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(destDir, "LICENSE"), []byte("MIT\n"), 0644); err != nil {
+		t.Fatalf("failed to write LICENSE: %v", err)
+	}
+
+	output, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"diff", "-s", "app", "-d", destDir, "-c", configDir})
+	})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if output != "No changes\n" {
+		t.Errorf("output = %q, want %q", output, "No changes\n")
+	}
+}
+
+func TestDiffCmd_MissingVariables(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "app", map[string]string{"README.md.stamp": "# {{.name}}\n"})
+
+	err := NewCLI().Execute([]string{"diff", "-s", "app", "-d", t.TempDir(), "-c", configDir})
+	if err == nil || !strings.Contains(err.Error(), "name") {
+		t.Errorf("Execute() error = %v, want missing variable error", err)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     string
+	}{
+		{"equal", "a\nb\n", "a\nb\n", ""},
+		{
+			"separate hunks",
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			"1\nTWO\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n",
+			"--- a\n+++ b\n" +
+				"@@ -1,5 +1,5 @@\n 1\n-2\n+TWO\n 3\n 4\n 5\n" +
+				"@@ -10,3 +10,4 @@\n 10\n 11\n 12\n+13\n",
+		},
+		{"insert into empty", "", "x\n", "--- a\n+++ b\n@@ -0,0 +1 @@\n+x\n"},
+		{"missing final newline", "x\n", "x", "--- a\n+++ b\n@@ -1 +1 @@\n-x\n+x\n\\ No newline at end of file\n"},
+	}
+	for _, tt := range tests {
+		if got := unifiedDiff("a", "b", tt.old, tt.new); got != tt.want {
+			t.Errorf("%s: unifiedDiff() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/monochromegane/stamp/internal/configdir"
+	"github.com/monochromegane/stamp/internal/stamp"
+)
+
+// Exit codes of the stamp command, so scripts can tell failures apart
+const (
+	ExitError      = 1 // Any other failure, such as an I/O error or an invalid flag
+	ExitValidation = 2 // Templates or variables failed validation
+	ExitNotFound   = 3 // A requested sheet does not exist
+)
+
+// validationError marks err as a validation failure for ExitCode without changing its message
+type validationError struct {
+	error
+}
+
+func (e *validationError) Unwrap() error {
+	return e.error
+}
+
+// ExitCode returns the exit code for an error returned by CLI.Execute
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var notFoundErr *configdir.NotFoundError
+	var templateErr *stamp.ValidationError
+	var validationErr *validationError
+	switch {
+	case errors.As(err, &notFoundErr):
+		return ExitNotFound
+	case errors.As(err, &templateErr), errors.As(err, &validationErr):
+		return ExitValidation
+	}
+	return ExitError
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/monochromegane/stamp/internal/configdir"
+	"github.com/monochromegane/stamp/internal/stamp"
+)
+
+func TestExitCode(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "app", map[string]string{"hello.txt.stamp": "Hello {{.name}}!"})
+	createSheet(t, configDir, "typed", map[string]string{
+		"a.txt.stamp":       "{{.env}}",
+		"stamp.schema.yaml": "variables:\n  env:\n    enum: [dev, prod]\n",
+	})
+	createSheet(t, configDir, "broken", map[string]string{"a.txt.stamp": "{{.name"})
+	destFile := filepath.Join(t.TempDir(), "out")
+	if err := os.WriteFile(destFile, []byte("file"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"success", []string{"-s", "app", "-d", t.TempDir(), "-c", configDir, "-q", "name=alice"}, 0},
+		{"missing variables", []string{"-s", "app", "-d", t.TempDir(), "-c", configDir, "-q"}, ExitValidation},
+		{"template syntax error", []string{"-s", "broken", "-d", t.TempDir(), "-c", configDir, "-q"}, ExitValidation},
+		{"schema rejection", []string{"-s", "typed", "-d", t.TempDir(), "-c", configDir, "-q", "env=qa"}, ExitValidation},
+		{"validate command", []string{"validate", "-s", "broken", "-c", configDir}, ExitValidation},
+		{"sheet not found", []string{"-s", "missing", "-d", t.TempDir(), "-c", configDir, "-q"}, ExitNotFound},
+		{"one of several sheets not found", []string{"-s", "app", "-s", "missing", "-d", t.TempDir(), "-c", configDir, "-q"}, ExitNotFound},
+		{"no sheets match pattern", []string{"-s", "web-*", "-d", t.TempDir(), "-c", configDir, "-q"}, ExitNotFound},
+		{"show missing sheet", []string{"show", "-s", "missing", "-c", configDir}, ExitNotFound},
+		{"destination is a file", []string{"-s", "app", "-d", destFile, "-c", configDir, "-q", "name=alice"}, ExitError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := captureStdout(t, func() error {
+				return NewCLI().Execute(tt.args)
+			})
+			if got := ExitCode(err); got != tt.want {
+				t.Errorf("ExitCode(%v) = %d, want %d", err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExitCode_WrappedErrors(t *testing.T) {
+	notFound := fmt.Errorf("sheet 'x': %w", &configdir.NotFoundError{Sheets: []string{"x"}, Message: "sheet 'x' not found"})
+	if got := ExitCode(notFound); got != ExitNotFound {
+		t.Errorf("ExitCode(wrapped NotFoundError) = %d, want %d", got, ExitNotFound)
+	}
+	validation := fmt.Errorf("press: %w", &stamp.ValidationError{MissingVars: map[string][]string{"name": {"a.stamp:1"}}})
+	if got := ExitCode(validation); got != ExitValidation {
+		t.Errorf("ExitCode(wrapped ValidationError) = %d, want %d", got, ExitValidation)
+	}
+	if got := ExitCode(errors.New("boom")); got != ExitError {
+		t.Errorf("ExitCode(generic) = %d, want %d", got, ExitError)
+	}
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/monochromegane/stamp/internal/config"
+)
+
+// runHooks runs the post commands of each sheet, in sheet order, in dir
+// dir is the destination, or its subdirectory named by --output-prefix
+// Output is streamed as it is produced; the first failing command stops the press
+func (c *PressCmd) runHooks(sheets []*config.Sheet, dir string) error {
+	for i, sheet := range sheets {
+		for _, command := range sheet.Post {
+			if !c.Quiet {
+				fmt.Fprintf(os.Stdout, "Running hook of sheet '%s': %s\n", c.Sheet[i], command)
+			}
+			cmd := hookCommand(command)
+			cmd.Dir = dir
+			cmd.Stdout = os.Stdout
+			cmd.Stderr = os.Stderr
+			if err := cmd.Run(); err != nil {
+				return fmt.Errorf("hook %q of sheet '%s' failed: %w", command, c.Sheet[i], err)
+			}
+		}
+	}
+	return nil
+}
+
+// listHooks writes the post commands runHooks would run, in the same order, without running them
+func (c *PressCmd) listHooks(w io.Writer, sheets []*config.Sheet) {
+	for i, sheet := range sheets {
+		for _, command := range sheet.Post {
+			fmt.Fprintf(w, "Would run hook of sheet '%s': %s\n", c.Sheet[i], command)
+		}
+	}
+}
+
+// hookCommand runs command through the platform shell
+func hookCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", command)
+	}
+	return exec.Command("sh", "-c", command)
+}
@@ -0,0 +1,138 @@
+//go:build unix
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPressCmd_PostHooks(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "app", map[string]string{
+		".stampsheet.yaml": "post:\n  - echo first > marker\n  - echo second >> marker\n",
+		"README.md":        "# app\n",
+	})
+
+	destDir := t.TempDir()
+	out, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"-s", "app", "-d", destDir, "-c", configDir})
+	})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	assertContent(t, filepath.Join(destDir, "marker"), "first\nsecond\n")
+	if !strings.Contains(out, "Running hook of sheet 'app': echo first > marker") {
+		t.Errorf("output = %q, want the hook announced", out)
+	}
+}
+
+func TestPressCmd_PostHooksRunInOutputPrefix(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "app", map[string]string{
+		".stampsheet.yaml": "post: [\"echo ok > marker\"]\n",
+		"README.md":        "# app\n",
+	})
+
+	destDir := t.TempDir()
+	if err := NewCLI().Execute([]string{"-s", "app", "-d", destDir, "-c", configDir, "-q", "--output-prefix", "{{.name}}", "name=svc"}); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	assertContent(t, filepath.Join(destDir, "svc", "marker"), "ok\n")
+	if _, err := os.Stat(filepath.Join(destDir, "marker")); !os.IsNotExist(err) {
+		t.Error("hooks should run in the output prefix, not the destination root")
+	}
+}
+
+func TestPressCmd_DryRun(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "app", map[string]string{
+		".stampsheet.yaml": "post: [touch marker]\n",
+		"README.md.stamp":  "# {{.name}}\n",
+	})
+
+	destDir := t.TempDir()
+	out, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"-s", "app", "-d", destDir, "-c", configDir, "--dry-run", "name=svc"})
+	})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if want := "new file: README.md\nWould run hook of sheet 'app': touch marker\n"; out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+	entries, err := os.ReadDir(destDir)
+	if err != nil {
+		t.Fatalf("failed to read destination: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("--dry-run wrote %d entries to the destination", len(entries))
+	}
+
+	if err := NewCLI().Execute([]string{"-s", "app", "-d", destDir, "-c", configDir, "--dry-run", "--watch"}); err == nil {
+		t.Error("--dry-run with --watch should fail")
+	}
+}
+
+func TestPressCmd_NoHooks(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "app", map[string]string{
+		".stampsheet.yaml": "post: [touch marker]\n",
+		"README.md":        "# app\n",
+	})
+
+	destDir := t.TempDir()
+	if err := NewCLI().Execute([]string{"-s", "app", "-d", destDir, "-c", configDir, "-q", "--no-hooks"}); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "marker")); !os.IsNotExist(err) {
+		t.Error("hooks should not run with --no-hooks")
+	}
+}
+
+func TestPressCmd_FailingHook(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "app", map[string]string{
+		".stampsheet.yaml": "post: [exit 3, touch marker]\n",
+		"README.md":        "# app\n",
+	})
+
+	destDir := t.TempDir()
+	err := NewCLI().Execute([]string{"-s", "app", "-d", destDir, "-c", configDir, "-q"})
+	if err == nil {
+		t.Fatal("Execute() succeeded, want hook failure")
+	}
+	if !strings.Contains(err.Error(), `hook "exit 3" of sheet 'app' failed`) {
+		t.Errorf("error = %q, want the failing hook named", err.Error())
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "README.md")); err != nil {
+		t.Errorf("files written before the hook should be kept: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "marker")); !os.IsNotExist(err) {
+		t.Error("hooks after a failing one should not run")
+	}
+}
+
+func TestDiffCmd_ListsHooks(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "app", map[string]string{
+		".stampsheet.yaml": "post: [touch marker]\n",
+		"README.md":        "# app\n",
+	})
+
+	destDir := t.TempDir()
+	out, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"diff", "-s", "app", "-d", destDir, "-c", configDir})
+	})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if !strings.Contains(out, "Would run hook of sheet 'app': touch marker") {
+		t.Errorf("output = %q, want the hook listed", out)
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "marker")); !os.IsNotExist(err) {
+		t.Error("diff should not run hooks")
+	}
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/alecthomas/kong"
+	"github.com/monochromegane/stamp/internal/config"
+	"github.com/monochromegane/stamp/internal/configdir"
+	"github.com/monochromegane/stamp/internal/stamp"
+)
+
+type ListCmd struct {
+	Config    string `optional:"" help:"Config directory path (overrides default)" short:"c"`
+	Ext       string `optional:"" default:".stamp" help:"Stamp file extension (default: .stamp)" short:"e"`
+	SheetRoot string `optional:"" default:"sheets" env:"STAMP_SHEET_ROOT" help:"Config subdirectory that holds sheets (default: sheets)"`
+	Long      bool   `optional:"" help:"Also show the number of files and the variables each sheet uses" short:"l"`
+}
+
+func (c *ListCmd) Run(ctx *kong.Context) error {
+	configDir, err := configdir.GetConfigDirWithOverride(c.Config)
+	if err != nil {
+		return err
+	}
+	if err := configdir.ValidateSheetRoot(c.SheetRoot); err != nil {
+		return err
+	}
+
+	configDirs := configdir.SearchPath(configDir)
+	sheets, err := configdir.ListAvailableSheetsInRoots(configDirs, c.SheetRoot)
+	if err != nil {
+		return err
+	}
+
+	if !c.Long {
+		for _, sheet := range sheets {
+			fmt.Fprintf(os.Stdout, "%s\n", sheet)
+		}
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, sheet := range sheets {
+		sheetDir, err := configdir.ResolveTemplateDirInRoots(configDirs, c.SheetRoot, sheet)
+		if err != nil {
+			return err
+		}
+		line, err := c.describe(sheetDir)
+		if err != nil {
+			return fmt.Errorf("sheet '%s': %w", sheet, err)
+		}
+		fmt.Fprintf(w, "%s\t%s\n", sheet, line)
+	}
+	return w.Flush()
+}
+
+// describe summarizes a sheet as its file count and variables, tab-separated
+func (c *ListCmd) describe(sheetDir string) (string, error) {
+	files := 0
+	err := filepath.WalkDir(sheetDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || path == filepath.Join(sheetDir, stamp.AttributesFile) || path == filepath.Join(sheetDir, config.SheetFile) || path == filepath.Join(sheetDir, config.SchemaFile) || path == filepath.Join(sheetDir, stamp.PartialsFile) {
+			return nil
+		}
+		files++
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to scan sheet: %w", err)
+	}
+
+	analysis, err := stamp.New(nil, c.Ext, stamp.WithFuncs(stamp.StringFuncs())).Analyze([]string{sheetDir})
+	if err != nil {
+		return "", err
+	}
+	vars := "(no variables)"
+	if required := analysis.RequiredNames(); len(required) > 0 {
+		vars = strings.Join(required, ", ")
+	}
+	if optional := analysis.OptionalNames(); len(optional) > 0 {
+		vars += fmt.Sprintf(" [optional: %s]", strings.Join(optional, ", "))
+	}
+	return fmt.Sprintf("%d files\t%s", files, vars), nil
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func TestListCmd(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "web", map[string]string{
+		"index.html.stamp": "<h1>{{.title}}</h1>{{.footer | default \"\"}}",
+		"style.css":        "body {}",
+		".stampattributes": "*.css eol=lf\n",
+	})
+	createSheet(t, configDir, "api", map[string]string{"main.go.stamp": "package {{.pkg}}"})
+	createSheet(t, configDir, "empty-vars", map[string]string{"LICENSE": "MIT"})
+
+	output, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"list", "-c", configDir})
+	})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if want := "api\nempty-vars\nweb\n"; output != want {
+		t.Errorf("output = %q, want %q", output, want)
+	}
+
+	output, err = captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"list", "-c", configDir, "--long"})
+	})
+	if err != nil {
+		t.Fatalf("Execute() with --long failed: %v", err)
+	}
+	want := "api         1 files  pkg\n" +
+		"empty-vars  1 files  (no variables)\n" +
+		"web         2 files  title [optional: footer]\n"
+	if output != want {
+		t.Errorf("output = %q, want %q", output, want)
+	}
+}
+
+func TestListCmd_NoSheets(t *testing.T) {
+	output, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"list", "-c", t.TempDir()})
+	})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if output != "" {
+		t.Errorf("output = %q, want empty", output)
+	}
+}
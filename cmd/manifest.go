@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/monochromegane/stamp/internal/stamp"
+)
+
+// pressManifest is the --manifest document
+type pressManifest struct {
+	Files []manifestEntry `json:"files"`
+}
+
+type manifestEntry struct {
+	Path   string `json:"path"`
+	Sheet  string `json:"sheet"`
+	Action string `json:"action"`
+}
+
+// manifestPath returns --manifest resolved against dest unless it is absolute
+func (c *PressCmd) manifestPath(dest string) string {
+	if filepath.IsAbs(c.Manifest) {
+		return c.Manifest
+	}
+	return filepath.Join(dest, c.Manifest)
+}
+
+// writeManifest writes every file of result, its sheet, and its action to --manifest
+func (c *PressCmd) writeManifest(result *stamp.Result, dest string) error {
+	// Result files name sheets by directory; report them as given on the command line
+	names := make(map[string]string, len(result.Sheets))
+	for i, sheet := range result.Sheets {
+		names[sheet.Sheet] = c.Sheet[i]
+	}
+
+	manifest := pressManifest{Files: []manifestEntry{}}
+	for _, f := range result.Files {
+		manifest.Files = append(manifest.Files, manifestEntry{Path: f.Path, Sheet: names[f.Sheet], Action: f.Action})
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	path := c.manifestPath(dest)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create manifest directory: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write manifest file: %w", err)
+	}
+	return nil
+}
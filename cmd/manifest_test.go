@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPressCmd_Manifest(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "base", map[string]string{"a.txt": "a", "b.txt.stamp": "{{.name}}", "docs/c.md": "c"})
+	createSheet(t, configDir, "extra", map[string]string{"a.txt": "extra a", "d.txt.stamp": "{{.name}}"})
+
+	readManifest := func(t *testing.T, path string) pressManifest {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("failed to read manifest: %v", err)
+		}
+		var manifest pressManifest
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			t.Fatalf("manifest is not JSON: %v", err)
+		}
+		return manifest
+	}
+
+	t.Run("relative", func(t *testing.T) {
+		destDir := t.TempDir()
+		err := NewCLI().Execute([]string{"-s", "base", "-s", "extra", "-d", destDir, "-c", configDir, "-q", "--manifest", "out/manifest.json", "name=alice"})
+		if err != nil {
+			t.Fatalf("Execute() failed: %v", err)
+		}
+
+		manifest := readManifest(t, filepath.Join(destDir, "out", "manifest.json"))
+		want := []manifestEntry{
+			{Path: "a.txt", Sheet: "extra", Action: "copied"},
+			{Path: "b.txt", Sheet: "base", Action: "templated"},
+			{Path: "d.txt", Sheet: "extra", Action: "templated"},
+			{Path: "docs/c.md", Sheet: "base", Action: "copied"},
+		}
+		if len(manifest.Files) != len(want) {
+			t.Fatalf("manifest files = %+v, want %+v", manifest.Files, want)
+		}
+		for i := range want {
+			if manifest.Files[i] != want[i] {
+				t.Errorf("files[%d] = %+v, want %+v", i, manifest.Files[i], want[i])
+			}
+		}
+	})
+
+	t.Run("absolute", func(t *testing.T) {
+		destDir := t.TempDir()
+		path := filepath.Join(t.TempDir(), "manifest.json")
+		err := NewCLI().Execute([]string{"-s", "base", "-d", destDir, "-c", configDir, "-q", "--manifest", path, "name=alice"})
+		if err != nil {
+			t.Fatalf("Execute() failed: %v", err)
+		}
+		if got := len(readManifest(t, path).Files); got != 3 {
+			t.Errorf("manifest lists %d files, want 3", got)
+		}
+	})
+}
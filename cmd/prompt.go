@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/monochromegane/stamp/internal/stamp"
+)
+
+// stdinIsTerminal reports whether stdin is an interactive terminal
+// It is a variable so tests can script the prompts
+var stdinIsTerminal = func() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// promptMissingVars asks for each variable the sheets need but vars lacks, and adds the answers to vars
+// Other validation errors are left for the stamper to report
+func (c *PressCmd) promptMissingVars(r io.Reader, w io.Writer, srcDirs []string, vars map[string]string) error {
+	err := stamp.New(vars, c.Ext, stamp.WithFuncs(stamp.StringFuncs()), stamp.WithDelims(c.LeftDelim, c.RightDelim), stamp.WithNoopSuffix(c.NoopSuffix)).Validate(srcDirs)
+	var validationErr *stamp.ValidationError
+	if !errors.As(err, &validationErr) || len(validationErr.ParseErrors) > 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(validationErr.MissingVars))
+	for name := range validationErr.MissingVars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	reader := bufio.NewReader(r)
+	for _, name := range names {
+		fmt.Fprintf(w, "%s (used in %s): ", name, strings.Join(validationErr.MissingVars[name], ", "))
+		answer, err := reader.ReadString('\n')
+		if err != nil && (err != io.EOF || answer == "") {
+			return fmt.Errorf("no value entered for %s: %w", name, err)
+		}
+		vars[name] = strings.TrimRight(answer, "\r\n")
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/monochromegane/stamp/internal/stamp"
+)
+
+// withTerminal makes stdin look like a terminal (or not) for the duration of the test
+func withTerminal(t *testing.T, terminal bool) {
+	t.Helper()
+	old := stdinIsTerminal
+	stdinIsTerminal = func() bool { return terminal }
+	t.Cleanup(func() { stdinIsTerminal = old })
+}
+
+func TestPressCmd_Interactive(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "greet", map[string]string{"hello.txt.stamp": "Hello {{.name}} from {{.org}} ({{.team}})"})
+	withTerminal(t, true)
+	withStdin(t, "alice\nacme\n")
+
+	destDir := t.TempDir()
+	if err := NewCLI().Execute([]string{"-s", "greet", "-d", destDir, "-c", configDir, "-q", "-i", "team=core"}); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	assertContent(t, filepath.Join(destDir, "hello.txt"), "Hello alice from acme (core)")
+}
+
+func TestPressCmd_InteractiveEOF(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "greet", map[string]string{"hello.txt.stamp": "Hello {{.name}} from {{.org}}"})
+	withTerminal(t, true)
+	withStdin(t, "alice")
+
+	err := NewCLI().Execute([]string{"-s", "greet", "-d", t.TempDir(), "-c", configDir, "-q", "-i"})
+	if err == nil || err.Error() != "no value entered for org: EOF" {
+		t.Errorf("Execute() error = %v, want no value entered for org", err)
+	}
+}
+
+func TestPressCmd_InteractiveNotTerminal(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "greet", map[string]string{"hello.txt.stamp": "Hello {{.name}}"})
+	withTerminal(t, false)
+	withStdin(t, "alice\n")
+
+	err := NewCLI().Execute([]string{"-s", "greet", "-d", t.TempDir(), "-c", configDir, "-q", "-i"})
+	var validationErr *stamp.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Errorf("Execute() error = %v, want ValidationError when stdin is not a terminal", err)
+	}
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/monochromegane/stamp/internal/config"
+)
+
+// checkSchemas validates the merged variables against the stamp.schema.yaml of each sheet
+// Under a strict schema, variables given on the command line or in a --var-file must be
+// declared by one of the sheets' schemas
+func (c *PressCmd) checkSchemas(srcDirs []string, vars map[string]string) error {
+	var problems []string
+	var schemas []*config.Schema
+	strict := false
+	for i, dir := range srcDirs {
+		schema, err := config.LoadSchema(dir)
+		if err != nil {
+			return fmt.Errorf("sheet '%s': %w", c.Sheet[i], err)
+		}
+		if schema == nil {
+			continue
+		}
+		schemas = append(schemas, schema)
+		strict = strict || schema.Strict
+		for _, p := range schema.Check(vars) {
+			problems = append(problems, fmt.Sprintf("  - %s (sheet '%s')", p, c.Sheet[i]))
+		}
+	}
+
+	if strict {
+		for _, name := range slices.Sorted(maps.Keys(c.varSources)) {
+			source := c.varSources[name]
+			if source != sourceArgs && !strings.HasPrefix(source, "--var-file ") {
+				continue
+			}
+			if !slices.ContainsFunc(schemas, func(s *config.Schema) bool { return s.Declares(name) }) {
+				problems = append(problems, fmt.Sprintf("  - %s: not declared in %s (%s)", name, config.SchemaFile, source))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return &validationError{fmt.Errorf("variables rejected by sheet schema:\n%s", strings.Join(problems, "\n"))}
+	}
+	return nil
+}
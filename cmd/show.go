@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alecthomas/kong"
+	"github.com/monochromegane/stamp/internal/config"
+	"github.com/monochromegane/stamp/internal/configdir"
+	"github.com/monochromegane/stamp/internal/stamp"
+)
+
+type ShowCmd struct {
+	Sheet     string `required:"" help:"Sheet name to show" short:"s"`
+	Config    string `optional:"" help:"Config directory path (overrides default)" short:"c"`
+	Ext       string `optional:"" default:".stamp" help:"Stamp file extension (default: .stamp)" short:"e"`
+	SheetRoot string `optional:"" default:"sheets" env:"STAMP_SHEET_ROOT" help:"Config subdirectory that holds sheets (default: sheets)"`
+	Cat       string `optional:"" help:"Print the raw contents of a file in the sheet instead of the tree"`
+}
+
+func (c *ShowCmd) Run(ctx *kong.Context) error {
+	// 1. Resolve config directory
+	configDir, err := configdir.GetConfigDirWithOverride(c.Config)
+	if err != nil {
+		return err
+	}
+	if err := configdir.ValidateSheetRoot(c.SheetRoot); err != nil {
+		return err
+	}
+
+	// 2. Validate the sheet exists, listing available sheets if not
+	sheetDir, err := configdir.ResolveTemplateDirInRoots(configdir.SearchPath(configDir), c.SheetRoot, c.Sheet)
+	if err != nil {
+		return err
+	}
+
+	// 3. Print a single file, or the whole tree
+	if c.Cat != "" {
+		return c.cat(os.Stdout, sheetDir)
+	}
+	fmt.Fprintf(os.Stdout, "%s\n", c.Sheet)
+	return c.printTree(os.Stdout, sheetDir, sheetDir, "")
+}
+
+// cat writes the raw contents of the file at the sheet-relative path --cat
+func (c *ShowCmd) cat(w io.Writer, sheetDir string) error {
+	relPath := filepath.FromSlash(c.Cat)
+	if !filepath.IsLocal(relPath) {
+		return fmt.Errorf("invalid path %q: must be relative to the sheet", c.Cat)
+	}
+
+	path := filepath.Join(sheetDir, relPath)
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("file '%s' not found in sheet '%s'", c.Cat, c.Sheet)
+		}
+		return fmt.Errorf("failed to stat file: %w", err)
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("'%s' in sheet '%s' is not a regular file", c.Cat, c.Sheet)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	_, err = w.Write(content)
+	return err
+}
+
+// printTree writes the entries of dir as a tree, marking how press treats each file
+func (c *ShowCmd) printTree(w io.Writer, sheetDir, dir, indent string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	for i, entry := range entries {
+		branch, next := "├── ", "│   "
+		if i == len(entries)-1 {
+			branch, next = "└── ", "    "
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		if entry.IsDir() {
+			fmt.Fprintf(w, "%s%s%s/\n", indent, branch, entry.Name())
+			if err := c.printTree(w, sheetDir, path, indent+next); err != nil {
+				return err
+			}
+			continue
+		}
+		fmt.Fprintf(w, "%s%s%s  (%s)\n", indent, branch, entry.Name(), c.kind(sheetDir, path))
+	}
+	return nil
+}
+
+// kind describes how press handles the file at path
+func (c *ShowCmd) kind(sheetDir, path string) string {
+	switch {
+	case path == filepath.Join(sheetDir, stamp.AttributesFile) || path == filepath.Join(sheetDir, config.SheetFile) || path == filepath.Join(sheetDir, config.SchemaFile):
+		return "sheet metadata"
+	case path == filepath.Join(sheetDir, stamp.PartialsFile):
+		return "partials"
+	case strings.HasSuffix(path, c.Ext+".noop"):
+		return "noop"
+	case strings.HasSuffix(path, c.Ext+"-sq"):
+		return "template, [[ ]]"
+	case strings.HasSuffix(path, c.Ext):
+		return "template"
+	}
+	return "static"
+}
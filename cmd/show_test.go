@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestShowCmd(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "web", map[string]string{
+		"index.html.stamp":       "<h1>{{.title}}</h1>",
+		"nginx.conf.stamp.noop":  "root {{.root}};",
+		"main.tf.stamp-sq":       "name = \"[[.name]]\"",
+		"assets/style.css":       "body {}",
+		"assets/js/app.js.stamp": "// {{.name}}",
+		".stampattributes":       "*.css eol=lf\n",
+	})
+
+	output, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"show", "-s", "web", "-c", configDir})
+	})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	want := "web\n" +
+		"├── .stampattributes  (sheet metadata)\n" +
+		"├── assets/\n" +
+		"│   ├── js/\n" +
+		"│   │   └── app.js.stamp  (template)\n" +
+		"│   └── style.css  (static)\n" +
+		"├── index.html.stamp  (template)\n" +
+		"├── main.tf.stamp-sq  (template, [[ ]])\n" +
+		"└── nginx.conf.stamp.noop  (noop)\n"
+	if output != want {
+		t.Errorf("output = %q, want %q", output, want)
+	}
+}
+
+func TestShowCmd_Cat(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "web", map[string]string{"assets/js/app.js.stamp": "// {{.name}}\n"})
+
+	output, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"show", "-s", "web", "-c", configDir, "--cat", "assets/js/app.js.stamp"})
+	})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if want := "// {{.name}}\n"; output != want {
+		t.Errorf("output = %q, want %q", output, want)
+	}
+
+	for _, tc := range []struct {
+		path string
+		want string
+	}{
+		{"missing.txt", "file 'missing.txt' not found in sheet 'web'"},
+		{"assets", "'assets' in sheet 'web' is not a regular file"},
+		{"../stamp.yaml", `invalid path "../stamp.yaml"`},
+	} {
+		err := NewCLI().Execute([]string{"show", "-s", "web", "-c", configDir, "--cat", tc.path})
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Execute(--cat %s) error = %v, want %q", tc.path, err, tc.want)
+		}
+	}
+}
+
+func TestShowCmd_SheetNotFound(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "web", map[string]string{"index.html": ""})
+
+	err := NewCLI().Execute([]string{"show", "-s", "api", "-c", configDir})
+	if err == nil || !strings.Contains(err.Error(), "web") {
+		t.Errorf("Execute() error = %v, want not-found error listing available sheets", err)
+	}
+}
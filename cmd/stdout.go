@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/monochromegane/stamp/internal/stamp"
+)
+
+// stdoutDest is the --dest value that writes the single output file of the sheets to stdout
+const stdoutDest = "-"
+
+// pressToStdout renders the sheets into a scratch directory and writes their only output file to w
+// Validation applies as usual; sheets producing more than one file are an error
+func (c *PressCmd) pressToStdout(w io.Writer, logger stamp.Logger) error {
+	if c.Watch || c.SheetsFromStdin || c.Verbose {
+		return fmt.Errorf("-d %s can't be used with --watch, --sheets-from-stdin or --verbose", stdoutDest)
+	}
+
+	srcDirs, _, vars, err := c.prepare(logger)
+	if err != nil {
+		return err
+	}
+
+	scratch, err := os.MkdirTemp("", "stamp-stdout-")
+	if err != nil {
+		return fmt.Errorf("failed to create scratch directory: %w", err)
+	}
+	defer os.RemoveAll(scratch)
+
+	result, err := c.newStamper(vars, logger, stamp.OverwriteError).ExecuteMultiple(srcDirs, scratch)
+	if err != nil {
+		return fmt.Errorf("stamp failed: %w", err)
+	}
+	if len(result.Files) != 1 {
+		paths := make([]string, len(result.Files))
+		for i, f := range result.Files {
+			paths[i] = f.Path
+		}
+		return fmt.Errorf("-d %s needs sheets with exactly one output file, got %d: %s", stdoutDest, len(paths), strings.Join(paths, ", "))
+	}
+	file := result.Files[0]
+	if file.Action == stamp.ActionLinked {
+		return fmt.Errorf("-d %s can't write symlink %s to stdout", stdoutDest, file.Path)
+	}
+
+	content, err := os.ReadFile(filepath.Join(scratch, filepath.FromSlash(file.Path)))
+	if err != nil {
+		return fmt.Errorf("failed to read rendered file: %w", err)
+	}
+	_, err = w.Write(content)
+	return err
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/monochromegane/stamp/internal/stamp"
+)
+
+func TestPressCmd_Stdout(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "gitignore", map[string]string{".gitignore.stamp": "/{{.name}}\n*.log\n"})
+
+	output, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"-s", "gitignore", "-d", "-", "-c", configDir, "name=app"})
+	})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if output != "/app\n*.log\n" {
+		t.Errorf("output = %q, want the rendered file only", output)
+	}
+}
+
+func TestPressCmd_StdoutErrors(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "single", map[string]string{"out.txt.stamp": "{{.name}}\n"})
+	createSheet(t, configDir, "multi", map[string]string{"a.txt": "a", "b.txt": "b"})
+
+	press := func(sheet string, vars map[string]string) (string, error) {
+		var buf bytes.Buffer
+		c := &PressCmd{renderFlags: renderFlags{Sheet: []string{sheet}, Config: configDir, Ext: ".stamp", SheetRoot: "sheets",
+			MergeStrategy: "overwrite", LineEndings: "keep", LeftDelim: "{{", RightDelim: "}}", SkipToolCheck: true},
+			Dest: []string{stdoutDest}, Vars: vars}
+		err := c.pressToStdout(&buf, stamp.NewTextLogger(&bytes.Buffer{}))
+		return buf.String(), err
+	}
+
+	if got, err := press("single", map[string]string{"name": "alice"}); err != nil || got != "alice\n" {
+		t.Errorf("pressToStdout() = %q, %v, want \"alice\\n\"", got, err)
+	}
+
+	// Validation still applies
+	var validationErr *stamp.ValidationError
+	if _, err := press("single", nil); !errors.As(err, &validationErr) || validationErr.MissingVars["name"] == nil {
+		t.Errorf("pressToStdout() error = %v, want name to be missing", err)
+	}
+
+	if got, err := press("multi", nil); err == nil || !strings.Contains(err.Error(), "got 2: a.txt, b.txt") || got != "" {
+		t.Errorf("pressToStdout() = %q, %v, want an error listing both files", got, err)
+	}
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/monochromegane/stamp/internal/stamp"
+)
+
+// Sources reported for variables no template uses
+const (
+	sourceConfig = "global config"
+	sourceArgs   = "command line"
+)
+
+// recordSources notes source as the origin of every key in vars, replacing earlier sources
+func (c *PressCmd) recordSources(vars map[string]string, source string) {
+	if c.varSources == nil {
+		c.varSources = make(map[string]string)
+	}
+	for k := range vars {
+		c.varSources[k] = source
+	}
+}
+
+// recordResolved notes source as the layer that supplied the final value of name so far
+func (c *PressCmd) recordResolved(name, source string) {
+	if c.resolved == nil {
+		c.resolved = make(map[string]string)
+	}
+	c.resolved[name] = source
+}
+
+// printResolved writes each variable with the layer that supplied its value, sorted by name
+// Values are left out, since they may come from secrets in the environment
+func (c *PressCmd) printResolved(w io.Writer) {
+	if len(c.resolved) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "Variables:\n")
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, name := range slices.Sorted(maps.Keys(c.resolved)) {
+		fmt.Fprintf(tw, "  %s\t%s\n", name, c.resolved[name])
+	}
+	tw.Flush()
+}
+
+// checkUnusedVars warns about variables from config files or the command line that no template
+// in srcDirs reads, or fails with --strict-unused
+// Dotted names count as used when their top-level name is, and nothing is reported when a
+// template reads the whole variable map or looks variables up with var
+func (c *PressCmd) checkUnusedVars(srcDirs []string, logger stamp.Logger) error {
+	if len(c.varSources) == 0 {
+		return nil
+	}
+	analysis, err := stamp.New(nil, c.Ext,
+		stamp.WithDelims(c.LeftDelim, c.RightDelim),
+		stamp.WithNoopSuffix(c.NoopSuffix),
+		stamp.WithOutputPrefix(c.OutputPrefix),
+		stamp.WithFuncs(stamp.StringFuncs()),
+	).Analyze(srcDirs)
+	if err != nil {
+		return err
+	}
+	// Broken templates are reported by validation, and their variables are unknown
+	if len(analysis.ReadsAll) > 0 || len(analysis.ParseErrors) > 0 {
+		return nil
+	}
+
+	var unused []string
+	for _, name := range slices.Sorted(maps.Keys(c.varSources)) {
+		top, _, _ := strings.Cut(name, ".")
+		_, required := analysis.Required[top]
+		_, optional := analysis.Optional[top]
+		if !required && !optional {
+			unused = append(unused, fmt.Sprintf("%s (%s)", name, c.varSources[name]))
+		}
+	}
+	if len(unused) == 0 {
+		return nil
+	}
+	if c.StrictUnused {
+		return fmt.Errorf("variables not used by any template (--strict-unused): %s", strings.Join(unused, ", "))
+	}
+	for _, name := range unused {
+		logger.Log(stamp.Event{Event: stamp.EventWarning, Message: fmt.Sprintf("variable %s is not used by any template", name)})
+	}
+	return nil
+}
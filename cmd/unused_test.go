@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPressCmd_UnusedVars(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "app", map[string]string{
+		"main.go.stamp":       "package {{.pkg}}\n// {{.db.host | default \"none\"}}\n",
+		"{{.name}}/README.md": "readme\n",
+	})
+	createSheet(t, configDir, "dump", map[string]string{"env.stamp": "{{range $k, $v := .}}{{$k}}={{$v}}\n{{end}}"})
+	if err := os.WriteFile(filepath.Join(configDir, "stamp.yaml"), []byte("pkg: main\nnmae: typo\ndb:\n  host: localhost\n"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	press := func(args ...string) (string, error) {
+		var stderr bytes.Buffer
+		cli := NewCLI()
+		cli.stderr = &stderr
+		base := []string{"-d", t.TempDir(), "-c", configDir, "-q"}
+		err := cli.Execute(append(base, args...))
+		return stderr.String(), err
+	}
+
+	t.Run("warns", func(t *testing.T) {
+		stderr, err := press("-s", "app", "name=app", "extra=x")
+		if err != nil {
+			t.Fatalf("Execute() failed: %v", err)
+		}
+		for _, want := range []string{
+			"Warning: variable nmae (global config) is not used by any template\n",
+			"Warning: variable extra (command line) is not used by any template\n",
+		} {
+			if !strings.Contains(stderr, want) {
+				t.Errorf("stderr = %q, want it to contain %q", stderr, want)
+			}
+		}
+		// Nested keys, defaulted variables and variables in names are used
+		for _, used := range []string{"variable db.host", "variable pkg", "variable name "} {
+			if strings.Contains(stderr, used) {
+				t.Errorf("stderr = %q, should not warn about %s", stderr, used)
+			}
+		}
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		_, err := press("--strict-unused", "-s", "app", "name=app")
+		if err == nil || !strings.Contains(err.Error(), "nmae (global config)") {
+			t.Errorf("Execute() error = %v, want --strict-unused error naming nmae", err)
+		}
+	})
+
+	t.Run("all used", func(t *testing.T) {
+		stderr, err := press("--strict-unused", "-s", "dump", "extra=x")
+		if err != nil {
+			t.Fatalf("Execute() failed: %v", err)
+		}
+		if stderr != "" {
+			t.Errorf("stderr = %q, want no warnings when a template reads every variable", stderr)
+		}
+	})
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/alecthomas/kong"
+	"github.com/monochromegane/stamp/internal/config"
+	"github.com/monochromegane/stamp/internal/configdir"
+	"github.com/monochromegane/stamp/internal/stamp"
+)
+
+type ValidateCmd struct {
+	Sheet      []string `optional:"" help:"Sheet name(s) or glob patterns like 'web-*' from config directory (can specify multiple)" short:"s"`
+	All        bool     `optional:"" help:"Validate every sheet in the config directory"`
+	Config     string   `optional:"" help:"Config directory path (overrides default)" short:"c"`
+	Ext        string   `optional:"" default:".stamp" help:"Stamp file extension (default: .stamp)" short:"e"`
+	NoopSuffix string   `optional:"" default:".noop" help:"Suffix after the stamp extension that marks files copied verbatim (default: .noop)"`
+	SheetRoot  string   `optional:"" default:"sheets" env:"STAMP_SHEET_ROOT" help:"Config subdirectory that holds sheets (default: sheets)"`
+	LeftDelim  string   `optional:"" default:"{{" help:"Opening delimiter of template actions (default: {{)"`
+	RightDelim string   `optional:"" default:"}}" help:"Closing delimiter of template actions (default: }})"`
+}
+
+func (c *ValidateCmd) Run(ctx *kong.Context) error {
+	if c.All == (len(c.Sheet) > 0) {
+		return fmt.Errorf("specify sheets with -s or validate all of them with --all")
+	}
+
+	// 1. Resolve config directory and sheets
+	configDir, err := configdir.GetConfigDirWithOverride(c.Config)
+	if err != nil {
+		return err
+	}
+	if err := configdir.ValidateSheetRoot(c.SheetRoot); err != nil {
+		return err
+	}
+	configDirs := configdir.SearchPath(configDir)
+	names, err := configdir.ExpandSheetGlobs(configDirs, c.SheetRoot, c.Sheet)
+	if err != nil {
+		return err
+	}
+	if c.All {
+		if names, err = configdir.ListAvailableSheetsInRoots(configDirs, c.SheetRoot); err != nil {
+			return err
+		}
+		if len(names) == 0 {
+			fmt.Fprintf(os.Stdout, "No sheets to validate\n")
+			return nil
+		}
+	}
+	srcDirs, err := configdir.ResolveTemplateDirsInRoots(configDirs, c.SheetRoot, names)
+	if err != nil {
+		return err
+	}
+
+	// 2. Check each sheet on its own so every problem is reported in one run
+	failed := 0
+	for i, dir := range srcDirs {
+		ok, err := c.validateSheet(os.Stdout, names[i], dir)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return &validationError{fmt.Errorf("%d of %d sheet(s) failed validation", failed, len(srcDirs))}
+	}
+	return nil
+}
+
+// validateSheet reports the problems and variables of the sheet in dir, and whether it is valid
+// Variables are listed but never required to be provided
+func (c *ValidateCmd) validateSheet(w io.Writer, name, dir string) (bool, error) {
+	var problems []string
+	if _, err := config.LoadSheet(dir); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := config.LoadSchema(dir); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	analysis, err := stamp.New(nil, c.Ext,
+		stamp.WithDelims(c.LeftDelim, c.RightDelim),
+		stamp.WithNoopSuffix(c.NoopSuffix),
+		stamp.WithFuncs(stamp.StringFuncs()),
+	).Analyze([]string{dir})
+	if err != nil {
+		return false, err
+	}
+	for _, path := range slices.Sorted(maps.Keys(analysis.ParseErrors)) {
+		msg := analysis.ParseErrors[path].Error()
+		if inner := errors.Unwrap(analysis.ParseErrors[path]); inner != nil {
+			msg = inner.Error()
+		}
+		problems = append(problems, fmt.Sprintf("%s: %s", path, msg))
+	}
+	for _, fn := range slices.Sorted(maps.Keys(analysis.UnknownFunctions)) {
+		problems = append(problems, fmt.Sprintf("unknown function %q used in %s", fn, strings.Join(analysis.UnknownFunctions[fn], ", ")))
+	}
+
+	if len(problems) == 0 {
+		fmt.Fprintf(w, "%s: ok\n", name)
+	} else {
+		fmt.Fprintf(w, "%s: %d problem(s)\n", name, len(problems))
+		for _, p := range problems {
+			fmt.Fprintf(w, "  - %s\n", p)
+		}
+	}
+	if required := analysis.RequiredNames(); len(required) > 0 {
+		fmt.Fprintf(w, "  required: %s\n", strings.Join(required, ", "))
+	}
+	if optional := analysis.OptionalNames(); len(optional) > 0 {
+		fmt.Fprintf(w, "  optional: %s\n", strings.Join(optional, ", "))
+	}
+	return len(problems) == 0, nil
+}
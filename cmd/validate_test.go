@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateCmd_CleanSheet(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "app", map[string]string{
+		"README.md.stamp": "# {{.name}}\n{{.license | default \"MIT\"}}\n",
+		"main.go":         "package main\n",
+	})
+
+	out, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"validate", "-s", "app", "-c", configDir})
+	})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	want := "app: ok\n  required: name\n  optional: license\n"
+	if out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+}
+
+func TestValidateCmd_BrokenTemplate(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "broken", map[string]string{
+		"README.md.stamp": "# {{.name\n",
+		"ok.txt.stamp":    "{{.org}}\n",
+	})
+
+	out, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"validate", "-s", "broken", "-c", configDir})
+	})
+	if err == nil {
+		t.Fatal("Execute() succeeded, want validation failure")
+	}
+	if !strings.Contains(err.Error(), "1 of 1 sheet(s) failed validation") {
+		t.Errorf("error = %q, want failure count", err.Error())
+	}
+	if !strings.Contains(out, "broken: 1 problem(s)") || !strings.Contains(out, "  - README.md.stamp: template: README.md.stamp:2: unclosed action") {
+		t.Errorf("output = %q, want the parse error reported", out)
+	}
+	if !strings.Contains(out, "  required: org\n") {
+		t.Errorf("output = %q, want variables of parsable templates listed", out)
+	}
+}
+
+func TestValidateCmd_All(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "good", map[string]string{"a.txt.stamp": "{{.name}}\n"})
+	createSheet(t, configDir, "bad", map[string]string{
+		"a.txt.stamp":      "{{upper .name}} {{shout .name}}\n",
+		".stampsheet.yaml": "unknown: true\n",
+	})
+
+	out, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"validate", "--all", "-c", configDir})
+	})
+	if err == nil || !strings.Contains(err.Error(), "1 of 2 sheet(s) failed validation") {
+		t.Fatalf("Execute() error = %v, want one failing sheet", err)
+	}
+	for _, want := range []string{"good: ok\n", "bad: 2 problem(s)\n", `unknown function "shout" used in a.txt.stamp`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output = %q, want it to contain %q", out, want)
+		}
+	}
+}
+
+func TestValidateCmd_RequiresSheets(t *testing.T) {
+	configDir := t.TempDir()
+	if err := NewCLI().Execute([]string{"validate", "-c", configDir}); err == nil {
+		t.Error("Execute() without -s or --all succeeded, want error")
+	}
+	if err := NewCLI().Execute([]string{"validate", "-s", "app", "--all", "-c", configDir}); err == nil {
+		t.Error("Execute() with both -s and --all succeeded, want error")
+	}
+}
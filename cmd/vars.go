@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/alecthomas/kong"
+	"github.com/monochromegane/stamp/internal/config"
+	"github.com/monochromegane/stamp/internal/stamp"
+)
+
+type VarsCmd struct {
+	renderFlags
+}
+
+// sheetVar describes one variable used by the requested sheets
+type sheetVar struct {
+	required bool     // Used at least once without a default
+	paths    []string // Sheet-qualified paths of the templates using it
+}
+
+func (c *VarsCmd) Run(ctx *kong.Context, logger stamp.Logger) error {
+	// 1. Resolve config directory and sheets exactly as press does
+	press := &PressCmd{renderFlags: c.renderFlags}
+	configDirs, srcDirs, err := press.resolveSheets()
+	if err != nil {
+		return err
+	}
+	c.Sheet = press.Sheet
+
+	// 2. Load the config and sheet defaults that press would use
+	configVars, err := config.LoadHierarchicalMultipleInRoots(configDirs, c.Sheet)
+	if err != nil {
+		return fmt.Errorf("config error: %w", err)
+	}
+	sheets := make([]*config.Sheet, len(srcDirs))
+	for i, dir := range srcDirs {
+		if sheets[i], err = config.LoadSheet(dir); err != nil {
+			return err
+		}
+	}
+	defaults := config.MergeDefaults(sheets)
+
+	// 3. Merge variable usage across sheets
+	vars, err := c.collectVars(press.newStamper(nil, logger, stamp.OverwriteError), srcDirs)
+	if err != nil {
+		return err
+	}
+
+	// 4. Print each variable with its status and usage locations
+	if len(vars) == 0 {
+		fmt.Fprintf(os.Stdout, "No variables used by %s\n", strings.Join(c.Sheet, ", "))
+		return nil
+	}
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(os.Stdout, "Variables used by %s:\n\n", strings.Join(c.Sheet, ", "))
+	for _, name := range names {
+		v := vars[name]
+		status := "missing"
+		if provides(configVars, name) {
+			status = "set in config"
+		} else if value, ok := defaults[name]; ok {
+			status = fmt.Sprintf("default %q", value)
+		} else if !v.required {
+			status = "optional"
+		}
+		fmt.Fprintf(os.Stdout, "  - %s (%s)\n", name, status)
+		fmt.Fprintf(os.Stdout, "    used in:\n")
+		for _, path := range v.paths {
+			fmt.Fprintf(os.Stdout, "      - %s\n", path)
+		}
+	}
+	return nil
+}
+
+// provides reports whether vars sets name, either directly or through nested name.* keys
+func provides(vars map[string]string, name string) bool {
+	if _, ok := vars[name]; ok {
+		return true
+	}
+	for k := range vars {
+		if strings.HasPrefix(k, name+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// collectVars analyzes each sheet with the stamper press would render it with and merges the results
+// Paths are prefixed with the sheet name so usage across sheets can be told apart
+func (c *VarsCmd) collectVars(stamper *stamp.Stamper, srcDirs []string) (map[string]*sheetVar, error) {
+	vars := make(map[string]*sheetVar)
+	add := func(sheet string, usage map[string][]string, required bool) {
+		for name, paths := range usage {
+			v, ok := vars[name]
+			if !ok {
+				v = &sheetVar{}
+				vars[name] = v
+			}
+			v.required = v.required || required
+			for _, path := range paths {
+				v.paths = append(v.paths, sheet+"/"+path)
+			}
+		}
+	}
+
+	for i, dir := range srcDirs {
+		analysis, err := stamper.Analyze([]string{dir})
+		if err != nil {
+			return nil, err
+		}
+		add(c.Sheet[i], analysis.Required, true)
+		add(c.Sheet[i], analysis.Optional, false)
+	}
+	for _, v := range vars {
+		sort.Strings(v.paths)
+	}
+	return vars, nil
+}
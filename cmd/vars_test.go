@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVarsCmd(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "app", map[string]string{
+		"main.go.stamp":    "package {{.pkg}} // {{.org}}",
+		"README.md.stamp":  "# {{.pkg}}\n{{.license | default \"MIT\"}}",
+		"static.txt":       "{{.notATemplate}}",
+		"LICENSE.stamp":    "{{.year}}",
+		".stampsheet.yaml": "defaults:\n  year: \"2024\"\n",
+	})
+	if err := os.WriteFile(filepath.Join(configDir, "stamp.yaml"), []byte("org: example\n"), 0644); err != nil {
+		t.Fatalf("failed to write global config: %v", err)
+	}
+
+	output, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"vars", "-s", "app", "-c", configDir})
+	})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	want := `Variables used by app:
+
+  - license (optional)
+    used in:
+      - app/README.md.stamp
+  - org (set in config)
+    used in:
+      - app/main.go.stamp
+  - pkg (missing)
+    used in:
+      - app/README.md.stamp
+      - app/main.go.stamp
+  - year (default "2024")
+    used in:
+      - app/LICENSE.stamp
+`
+	if output != want {
+		t.Errorf("output = %q, want %q", output, want)
+	}
+}
+
+func TestVarsCmd_MultipleSheets(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "base", map[string]string{"a.txt.stamp": "{{.name | default \"x\"}}"})
+	createSheet(t, configDir, "extra", map[string]string{"b.txt.stamp": "{{.name}} {{.port}}"})
+	createSheet(t, configDir, "plain", map[string]string{"c.txt": "c"})
+
+	output, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"vars", "-s", "base", "-s", "extra", "-c", configDir})
+	})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	// name is optional in base but required in extra, so it is required overall
+	want := `Variables used by base, extra:
+
+  - name (missing)
+    used in:
+      - base/a.txt.stamp
+      - extra/b.txt.stamp
+  - port (missing)
+    used in:
+      - extra/b.txt.stamp
+`
+	if output != want {
+		t.Errorf("output = %q, want %q", output, want)
+	}
+
+	output, err = captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"vars", "-s", "plain", "-c", configDir})
+	})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if want := "No variables used by plain\n"; output != want {
+		t.Errorf("output = %q, want %q", output, want)
+	}
+}
+
+func TestVarsCmd_SharesPressFlags(t *testing.T) {
+	configDir := t.TempDir()
+	createSheet(t, configDir, "chart", map[string]string{
+		"values.yaml.stamp":  "name: [[.name]]\nimage: {{ .Values.image }}\n",
+		"raw.yaml.stamp.raw": "[[.verbatim]]",
+	})
+
+	output, err := captureStdout(t, func() error {
+		return NewCLI().Execute([]string{"vars", "-s", "chart", "-c", configDir, "--left-delim", "[[", "--right-delim", "]]", "--noop-suffix", ".raw"})
+	})
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	// Only [[ ]] actions count, and files with the --noop-suffix are not templates
+	want := `Variables used by chart:
+
+  - name (missing)
+    used in:
+      - chart/values.yaml.stamp
+`
+	if output != want {
+		t.Errorf("output = %q, want %q", output, want)
+	}
+}
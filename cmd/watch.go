@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/monochromegane/stamp/internal/stamp"
+)
+
+// watchInterval is how often watch mode polls the sheet directories
+const watchInterval = 500 * time.Millisecond
+
+// watch renders once, then re-renders whenever a sheet changes until interrupted
+// Renders go to a scratch preview directory, never to the destination, and post hooks never run
+// Failed renders are reported and watching continues, so authors can fix sheets in place
+func (c *PressCmd) watch(logger stamp.Logger) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	return c.watchUntil(ctx, os.Stdout, logger, watchInterval)
+}
+
+// watchUntil runs watch mode, polling every interval, until ctx is done
+// The first render is diffed against the destination, each later one against the render before it
+func (c *PressCmd) watchUntil(ctx context.Context, w io.Writer, logger stamp.Logger, interval time.Duration) error {
+	_, srcDirs, err := c.resolveSheets()
+	if err != nil {
+		return err
+	}
+
+	scratch, err := os.MkdirTemp("", "stamp-watch-")
+	if err != nil {
+		return fmt.Errorf("failed to create scratch directory: %w", err)
+	}
+	defer os.RemoveAll(scratch)
+	preview := filepath.Join(scratch, "preview")
+	next := filepath.Join(scratch, "next")
+
+	base := c.Dest[0]
+	render := func() {
+		if err := c.renderPreview(w, logger, next, base); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.RemoveAll(next)
+			return
+		}
+		if err := os.RemoveAll(preview); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to replace preview: %v\n", err)
+			return
+		}
+		if err := os.Rename(next, preview); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to replace preview: %v\n", err)
+			return
+		}
+		base = preview
+	}
+
+	fmt.Fprintf(w, "Watching %d sheet(s) for changes, rendering into %s (Ctrl-C to stop)\n", len(srcDirs), preview)
+	baseline := fingerprint(srcDirs)
+	render()
+	watchDirs(ctx, srcDirs, baseline, interval, render)
+	return nil
+}
+
+// renderPreview renders the sheets into dir and writes the diff from base to the new render,
+// including files of base the render no longer produces
+func (c *PressCmd) renderPreview(w io.Writer, logger stamp.Logger, dir, base string) error {
+	srcDirs, _, vars, err := c.prepare(logger)
+	if err != nil {
+		return err
+	}
+	if _, err := c.newStamper(vars, logger, stamp.OverwriteError).ExecuteMultiple(srcDirs, dir); err != nil {
+		return fmt.Errorf("stamp failed: %w", err)
+	}
+
+	changed, err := diffTrees(w, dir, base)
+	if err != nil {
+		return err
+	}
+	// The destination may hold files the sheets never wrote, so only earlier renders report removals
+	if base != c.Dest[0] {
+		removed, err := compareTrees(base, dir)
+		if err != nil {
+			return err
+		}
+		for _, change := range removed {
+			if change.missing {
+				fmt.Fprintf(w, "deleted file: %s\n", change.path)
+				changed++
+			}
+		}
+	}
+	if changed == 0 {
+		fmt.Fprintf(w, "No changes\n")
+	}
+	return nil
+}
+
+// watchDirs polls dirs every interval and calls onChange once edits have settled
+// Changes are detected relative to the baseline fingerprint; a change is only acted on
+// after one interval without further changes, which debounces multi-step editor writes
+func watchDirs(ctx context.Context, dirs []string, baseline string, interval time.Duration, onChange func()) {
+	last := baseline
+	pending := false
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		current := fingerprint(dirs)
+		if current != last {
+			last, pending = current, true
+			continue
+		}
+		if pending {
+			pending = false
+			onChange()
+		}
+	}
+}
+
+// fingerprint summarizes the names, sizes, modes and modification times under dirs
+func fingerprint(dirs []string) string {
+	var sb strings.Builder
+	for _, dir := range dirs {
+		filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			fmt.Fprintf(&sb, "%s|%d|%s|%d\n", path, info.Size(), info.Mode(), info.ModTime().UnixNano())
+			return nil
+		})
+	}
+	return sb.String()
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/monochromegane/stamp/internal/stamp"
+)
+
+func TestWatch_RerendersOnChange(t *testing.T) {
+	configDir := t.TempDir()
+	sheetDir := createSheet(t, configDir, "greet", map[string]string{"hello.txt.stamp": "Hello {{.name}}\n", "old.txt": "old\n"})
+	destDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(destDir, "hello.txt"), []byte("Hello bob\n"), 0644); err != nil {
+		t.Fatalf("failed to create destination file: %v", err)
+	}
+
+	cmd := &PressCmd{renderFlags: renderFlags{Sheet: []string{"greet"}, Config: configDir, Ext: ".stamp", SheetRoot: "sheets"},
+		Dest: []string{destDir}, Overwrite: stamp.OverwriteError, Quiet: true, Vars: map[string]string{"name": "alice"}}
+
+	var out syncBuffer
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- cmd.watchUntil(ctx, &out, stamp.NewTextLogger(os.Stderr), 10*time.Millisecond)
+	}()
+
+	// The first render is diffed against the destination
+	waitForOutput(t, &out, "--- a/hello.txt\n+++ b/hello.txt\n@@ -1 +1 @@\n-Hello bob\n+Hello alice\nnew file: old.txt\n")
+
+	// Later renders are diffed against the previous render
+	out.Reset()
+	if err := os.WriteFile(filepath.Join(sheetDir, "hello.txt.stamp"), []byte("Hi {{.name}}!\n"), 0644); err != nil {
+		t.Fatalf("failed to modify sheet: %v", err)
+	}
+	if err := os.Remove(filepath.Join(sheetDir, "old.txt")); err != nil {
+		t.Fatalf("failed to remove sheet file: %v", err)
+	}
+	waitForOutput(t, &out, "--- a/hello.txt\n+++ b/hello.txt\n@@ -1 +1 @@\n-Hello alice\n+Hi alice!\ndeleted file: old.txt\n")
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("watchUntil() failed: %v", err)
+	}
+
+	// The destination is never written
+	assertContent(t, filepath.Join(destDir, "hello.txt"), "Hello bob\n")
+	if _, err := os.Stat(filepath.Join(destDir, "old.txt")); !os.IsNotExist(err) {
+		t.Errorf("old.txt should not be written to the destination, stat error = %v", err)
+	}
+}
+
+// syncBuffer is a bytes.Buffer that the watch goroutine and the test can share
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func (b *syncBuffer) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf.Reset()
+}
+
+// waitForOutput polls out until it contains want, failing the test after a few seconds
+func waitForOutput(t *testing.T, out *syncBuffer, want string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(out.String(), want) {
+		if time.Now().After(deadline) {
+			t.Fatalf("output = %q, want it to contain %q", out.String(), want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -5,3 +5,7 @@ go 1.25.5
 require github.com/alecthomas/kong v1.13.0
 
 require github.com/goccy/go-yaml v1.19.1
+
+require golang.org/x/text v0.40.0
+
+require github.com/BurntSushi/toml v1.6.0
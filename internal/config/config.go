@@ -2,35 +2,143 @@ package config
 
 import (
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 
+	"github.com/BurntSushi/toml"
 	"github.com/goccy/go-yaml"
 )
 
-// Load reads a YAML config file and returns key-value pairs
-// Returns error if file doesn't exist or is invalid YAML
+// Load reads a YAML (or, for a .toml extension, TOML) config file and returns key-value pairs
+// Files listed under the include key are loaded first, and the including file's keys win
+// Returns error if file doesn't exist or is invalid
 func Load(path string) (map[string]string, error) {
+	return loadFile(path, nil, nil)
+}
+
+// IncludeKey lists other config files to merge in, relative to the including file's directory
+const IncludeKey = "include"
+
+// loadFile is Load that tracks the chain of including files to detect cycles
+// When sources is not nil, it records the file that supplied each key
+func loadFile(path string, chain []string, sources map[string]string) (map[string]string, error) {
 	// Check file exists first for better error message
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		return nil, fmt.Errorf("config file not found: %s", path)
 	}
 
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve config file: %w", err)
+	}
+	if slices.Contains(chain, abs) {
+		return nil, fmt.Errorf("include cycle: %s", strings.Join(append(chain, abs), " -> "))
+	}
+
 	// Read file
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	// Parse YAML into map[string]string
+	// Parse YAML or TOML, flattening nested maps into dotted keys
+	format := "YAML"
+	var raw map[string]any
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		format = "TOML"
+		err = toml.Unmarshal(data, &raw)
+	} else {
+		err = yaml.Unmarshal(data, &raw)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s config: %w", format, err)
+	}
+
+	includes, err := includeList(raw[IncludeKey])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	delete(raw, IncludeKey)
+
+	// Included files are merged in order, so a later one wins over an earlier one
 	vars := make(map[string]string)
-	if err := yaml.Unmarshal(data, &vars); err != nil {
-		return nil, fmt.Errorf("failed to parse YAML config: %w", err)
+	for _, include := range includes {
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(filepath.Dir(path), include)
+		}
+		included, err := loadFile(include, append(chain, abs), sources)
+		if err != nil {
+			return nil, err
+		}
+		maps.Copy(vars, included)
+	}
+
+	own := make(map[string]string)
+	flatten(own, "", raw)
+	for k, v := range own {
+		vars[k] = v
+		if sources != nil {
+			sources[k] = path
+		}
 	}
 
 	return vars, nil
 }
 
+// includeList returns the file names of an include value, which is a name or a list of names
+func includeList(v any) ([]string, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{v}, nil
+	case []any:
+		names := make([]string, 0, len(v))
+		for _, item := range v {
+			name, ok := item.(string)
+			if !ok || name == "" {
+				return nil, fmt.Errorf("%s must list file names, got %v", IncludeKey, item)
+			}
+			names = append(names, name)
+		}
+		return names, nil
+	}
+	return nil, fmt.Errorf("%s must be a file name or a list of file names, got %v", IncludeKey, v)
+}
+
+// flatten copies m into vars, joining nested keys with "."
+// so {db: {host: x}} becomes db.host=x; null values become empty strings
+func flatten(vars map[string]string, prefix string, m map[string]any) {
+	for k, v := range m {
+		flattenValue(vars, prefix+k, v)
+	}
+}
+
+// flattenValue stores v under key, flattening maps and lists
+// List items are keyed by their index, so {hosts: [a, b]} becomes hosts.0=a and hosts.1=b
+func flattenValue(vars map[string]string, key string, v any) {
+	switch v := v.(type) {
+	case map[string]any:
+		flatten(vars, key+".", v)
+	case []any:
+		for i, item := range v {
+			flattenValue(vars, key+"."+strconv.Itoa(i), item)
+		}
+	case []map[string]any:
+		for i, item := range v {
+			flatten(vars, key+"."+strconv.Itoa(i)+".", item)
+		}
+	case nil:
+		vars[key] = ""
+	default:
+		vars[key] = fmt.Sprint(v)
+	}
+}
+
 // LoadHierarchical loads global config only
 // Sheet-specific configs are no longer supported
 // Priority: CLI args > global config
@@ -48,19 +156,70 @@ func LoadHierarchicalMultiple(configDir string, templateNames []string) (map[str
 	return loadGlobalConfig(configDir)
 }
 
-// loadGlobalConfig loads the global config file from the config directory
+// LoadHierarchicalMultipleInRoots is LoadHierarchicalMultiple for several config directories
+// Their global configs are merged, and a key in an earlier directory wins
+func LoadHierarchicalMultipleInRoots(configDirs []string, templateNames []string) (map[string]string, error) {
+	merged, _, err := LoadGlobalWithSources(configDirs)
+	return merged, err
+}
+
+// LoadGlobalWithSources is LoadHierarchicalMultipleInRoots that also returns the path of the
+// config file that supplied each key
+func LoadGlobalWithSources(configDirs []string) (map[string]string, map[string]string, error) {
+	merged := make(map[string]string)
+	sources := make(map[string]string)
+	for _, configDir := range slices.Backward(configDirs) {
+		for _, name := range globalFiles {
+			fileSources := make(map[string]string)
+			vars, err := loadOptionalWithSources(filepath.Join(configDir, name), fileSources)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to load global config: %w", err)
+			}
+			maps.Copy(merged, vars)
+			maps.Copy(sources, fileSources)
+		}
+	}
+	return merged, sources, nil
+}
+
+// globalFiles lists the global config file names in increasing priority
+// When several exist they are merged, and a key in stamp.yaml wins over the same key in stamp.toml
+var globalFiles = []string{"stamp.toml", "stamp.yaml"}
+
+// loadGlobalConfig loads the global config files from the config directory
 func loadGlobalConfig(configDir string) (map[string]string, error) {
-	globalPath := filepath.Join(configDir, "stamp.yaml")
-	globalVars, err := loadOptional(globalPath)
+	globalVars := make(map[string]string)
+	for _, name := range globalFiles {
+		vars, err := loadOptional(filepath.Join(configDir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load global config: %w", err)
+		}
+		maps.Copy(globalVars, vars)
+	}
+	return globalVars, nil
+}
+
+// LoadGlobalFile loads a chosen global config file in place of the default ones
+// A relative path is resolved against the config directory, and unlike the defaults the file must exist
+func LoadGlobalFile(configDir, path string) (map[string]string, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(configDir, path)
+	}
+	vars, err := Load(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load global config: %w", err)
 	}
-	return globalVars, nil
+	return vars, nil
 }
 
 // loadOptional loads a config file if it exists, returns empty map if not
 // Only errors on read/parse failures
 func loadOptional(path string) (map[string]string, error) {
+	return loadOptionalWithSources(path, nil)
+}
+
+// loadOptionalWithSources is loadOptional that records the file supplying each key in sources
+func loadOptionalWithSources(path string, sources map[string]string) (map[string]string, error) {
 	// Check if file exists
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		// File doesn't exist - not an error, return empty map
@@ -69,7 +228,7 @@ func loadOptional(path string) (map[string]string, error) {
 
 	// File exists - load it using the existing Load function
 	// But handle the "not found" error case (shouldn't happen given the check above)
-	vars, err := Load(path)
+	vars, err := loadFile(path, nil, sources)
 	if err != nil {
 		// If we get "not found" error here, return empty map
 		// (race condition: file was deleted between Stat and Load)
@@ -3,6 +3,8 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -110,6 +112,196 @@ version: 1.5`
 	}
 }
 
+func TestLoad_NestedValues(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "nested.yaml")
+	content := `name: app
+db:
+  host: localhost
+  port: 5432
+  options:
+    ssl: ~`
+
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	vars, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	// Nested maps are flattened into dotted keys
+	want := map[string]string{"name": "app", "db.host": "localhost", "db.port": "5432", "db.options.ssl": ""}
+	if len(vars) != len(want) {
+		t.Errorf("vars = %v, want %v", vars, want)
+	}
+	for k, v := range want {
+		if got, ok := vars[k]; !ok || got != v {
+			t.Errorf("vars[%s] = %q, want %q", k, got, v)
+		}
+	}
+}
+
+func TestLoad_ListValue(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "list.yaml")
+	content := `db:
+  hosts: [a, b]
+users:
+  - name: alice
+  - name: bob
+    roles: [admin]`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	vars, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	// List items are flattened into keys with their index
+	want := map[string]string{
+		"db.hosts.0": "a", "db.hosts.1": "b",
+		"users.0.name": "alice", "users.1.name": "bob", "users.1.roles.0": "admin",
+	}
+	if len(vars) != len(want) {
+		t.Errorf("vars = %v, want %v", vars, want)
+	}
+	for k, v := range want {
+		if got, ok := vars[k]; !ok || got != v {
+			t.Errorf("vars[%s] = %q, want %q", k, got, v)
+		}
+	}
+}
+
+func TestLoad_TOMLArrayOfTables(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "stamp.toml")
+	content := "ports = [80, 443]\n\n[[users]]\nname = \"alice\"\n\n[[users]]\nname = \"bob\"\n"
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	vars, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	want := map[string]string{"ports.0": "80", "ports.1": "443", "users.0.name": "alice", "users.1.name": "bob"}
+	if len(vars) != len(want) {
+		t.Errorf("vars = %v, want %v", vars, want)
+	}
+	for k, v := range want {
+		if got, ok := vars[k]; !ok || got != v {
+			t.Errorf("vars[%s] = %q, want %q", k, got, v)
+		}
+	}
+}
+
+func TestLoad_ValidTOML(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.toml")
+	content := `name = "bob"
+org = "example"
+
+[db]
+host = "localhost"`
+
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	vars, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	expected := map[string]string{
+		"name":    "bob",
+		"org":     "example",
+		"db.host": "localhost",
+	}
+
+	if len(vars) != len(expected) {
+		t.Errorf("got %d vars, want %d", len(vars), len(expected))
+	}
+
+	for k, want := range expected {
+		if got := vars[k]; got != want {
+			t.Errorf("vars[%q] = %q, want %q", k, got, want)
+		}
+	}
+}
+
+func TestLoad_TOMLNumberValues(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "numbers.toml")
+	content := `port = 8080
+enabled = true
+version = 1.5`
+
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	vars, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	// Numbers and booleans should be stringified
+	if vars["port"] != "8080" {
+		t.Errorf("vars[port] = %q, want \"8080\"", vars["port"])
+	}
+	if vars["enabled"] != "true" {
+		t.Errorf("vars[enabled] = %q, want \"true\"", vars["enabled"])
+	}
+	if vars["version"] != "1.5" {
+		t.Errorf("vars[version] = %q, want \"1.5\"", vars["version"])
+	}
+}
+
+func TestLoad_InvalidTOML(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "bad.toml")
+	if err := os.WriteFile(configPath, []byte(`name = [unclosed`), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	_, err := Load(configPath)
+	if err == nil || !strings.Contains(err.Error(), "TOML") {
+		t.Fatalf("Load() error = %v, want a TOML parse error", err)
+	}
+}
+
+func TestLoadHierarchical_TOMLAndYAML(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "stamp.toml"), []byte("org = \"toml-org\"\nlicense = \"MIT\"\n"), 0644); err != nil {
+		t.Fatalf("failed to write TOML config: %v", err)
+	}
+
+	vars, err := LoadHierarchical(dir, "go-cli")
+	if err != nil {
+		t.Fatalf("LoadHierarchical() failed: %v", err)
+	}
+	if vars["org"] != "toml-org" || vars["license"] != "MIT" {
+		t.Errorf("vars = %v, want values from stamp.toml", vars)
+	}
+
+	// stamp.yaml wins over stamp.toml for the same key
+	if err := os.WriteFile(filepath.Join(dir, "stamp.yaml"), []byte("org: yaml-org\n"), 0644); err != nil {
+		t.Fatalf("failed to write YAML config: %v", err)
+	}
+	vars, err = LoadHierarchicalMultiple(dir, []string{"go-cli"})
+	if err != nil {
+		t.Fatalf("LoadHierarchicalMultiple() failed: %v", err)
+	}
+	if vars["org"] != "yaml-org" || vars["license"] != "MIT" {
+		t.Errorf("vars = %v, want org from stamp.yaml and license from stamp.toml", vars)
+	}
+}
+
 func TestLoadHierarchical_OnlyGlobalConfig(t *testing.T) {
 	dir := t.TempDir()
 
@@ -297,6 +489,159 @@ license: MIT`
 	}
 }
 
+func TestLoadHierarchicalMultipleInRoots(t *testing.T) {
+	company := t.TempDir()
+	personal := t.TempDir()
+	if err := os.WriteFile(filepath.Join(company, "stamp.yaml"), []byte("org: acme\nlicense: MIT\n"), 0644); err != nil {
+		t.Fatalf("failed to write company config: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(personal, "stamp.yaml"), []byte("org: alice\nauthor: alice\n"), 0644); err != nil {
+		t.Fatalf("failed to write personal config: %v", err)
+	}
+
+	vars, err := LoadHierarchicalMultipleInRoots([]string{company, personal}, []string{"go-cli"})
+	if err != nil {
+		t.Fatalf("LoadHierarchicalMultipleInRoots() failed: %v", err)
+	}
+
+	// Earlier config directories win
+	expected := map[string]string{
+		"org":     "acme",
+		"license": "MIT",
+		"author":  "alice",
+	}
+	if len(vars) != len(expected) {
+		t.Errorf("got %d vars, want %d", len(vars), len(expected))
+	}
+	for k, want := range expected {
+		if got := vars[k]; got != want {
+			t.Errorf("vars[%q] = %q, want %q", k, got, want)
+		}
+	}
+}
+
+func TestLoadGlobalWithSources(t *testing.T) {
+	company := t.TempDir()
+	personal := t.TempDir()
+	if err := os.WriteFile(filepath.Join(company, "stamp.toml"), []byte("org = \"acme\"\nlicense = \"MIT\"\n"), 0644); err != nil {
+		t.Fatalf("failed to write company config: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(company, "stamp.yaml"), []byte("license: Apache-2.0\n"), 0644); err != nil {
+		t.Fatalf("failed to write company config: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(personal, "stamp.yaml"), []byte("org: alice\nauthor: alice\n"), 0644); err != nil {
+		t.Fatalf("failed to write personal config: %v", err)
+	}
+
+	_, sources, err := LoadGlobalWithSources([]string{company, personal})
+	if err != nil {
+		t.Fatalf("LoadGlobalWithSources() failed: %v", err)
+	}
+
+	// Each key names the file whose value won
+	expected := map[string]string{
+		"org":     filepath.Join(company, "stamp.toml"),
+		"license": filepath.Join(company, "stamp.yaml"),
+		"author":  filepath.Join(personal, "stamp.yaml"),
+	}
+	if !reflect.DeepEqual(sources, expected) {
+		t.Errorf("sources = %v, want %v", sources, expected)
+	}
+}
+
+func TestLoadGlobalWithSources_Include(t *testing.T) {
+	configDir := t.TempDir()
+	files := map[string]string{
+		"stamp.yaml":        "include: [vars/company.yaml]\norg: alice\n",
+		"vars/company.yaml": "include: base.toml\norg: acme\nlicense: MIT\n",
+		"vars/base.toml":    "license = \"Apache-2.0\"\nci = \"github\"\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(configDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	vars, sources, err := LoadGlobalWithSources([]string{configDir})
+	if err != nil {
+		t.Fatalf("LoadGlobalWithSources() failed: %v", err)
+	}
+
+	// The including file wins over the files it includes, at every level
+	expected := map[string]string{"org": "alice", "license": "MIT", "ci": "github"}
+	if !reflect.DeepEqual(vars, expected) {
+		t.Errorf("vars = %v, want %v", vars, expected)
+	}
+	expectedSources := map[string]string{
+		"org":     filepath.Join(configDir, "stamp.yaml"),
+		"license": filepath.Join(configDir, "vars", "company.yaml"),
+		"ci":      filepath.Join(configDir, "vars", "base.toml"),
+	}
+	if !reflect.DeepEqual(sources, expectedSources) {
+		t.Errorf("sources = %v, want %v", sources, expectedSources)
+	}
+}
+
+func TestLoad_IncludeCycle(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.yaml"), []byte("include: b.yaml\nx: 1\n"), 0644); err != nil {
+		t.Fatalf("failed to write a.yaml: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "b.yaml"), []byte("include: [a.yaml]\ny: 2\n"), 0644); err != nil {
+		t.Fatalf("failed to write b.yaml: %v", err)
+	}
+
+	_, err := Load(filepath.Join(dir, "a.yaml"))
+	if err == nil || !strings.Contains(err.Error(), "include cycle") {
+		t.Fatalf("Load() error = %v, want include cycle", err)
+	}
+}
+
+func TestLoad_InvalidInclude(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"missing.yaml": "include: nope.yaml\n",
+		"type.yaml":    "include:\n  a: b\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+		if _, err := Load(path); err == nil {
+			t.Errorf("Load(%s) should fail", name)
+		}
+	}
+}
+
+func TestLoadGlobalFile(t *testing.T) {
+	configDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(configDir, "stamp.yaml"), []byte("org: default\nlicense: MIT\n"), 0644); err != nil {
+		t.Fatalf("failed to write global config: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "work.yaml"), []byte("org: acme\n"), 0644); err != nil {
+		t.Fatalf("failed to write profile: %v", err)
+	}
+
+	// The chosen file replaces stamp.yaml instead of merging with it
+	for _, path := range []string{"work.yaml", filepath.Join(configDir, "work.yaml")} {
+		vars, err := LoadGlobalFile(configDir, path)
+		if err != nil {
+			t.Fatalf("LoadGlobalFile(%q) failed: %v", path, err)
+		}
+		if len(vars) != 1 || vars["org"] != "acme" {
+			t.Errorf("LoadGlobalFile(%q) = %v, want only org=acme", path, vars)
+		}
+	}
+
+	if _, err := LoadGlobalFile(configDir, "missing.yaml"); err == nil {
+		t.Error("LoadGlobalFile() should fail for a missing file")
+	}
+}
+
 func TestLoadOptional_ExistingFile(t *testing.T) {
 	dir := t.TempDir()
 	configPath := filepath.Join(dir, "config.yaml")
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// SchemaFile declares the variables a sheet accepts at a sheet root
+// Like SheetFile it is never written to the destination
+const SchemaFile = "stamp.schema.yaml"
+
+// Schema describes the variables a sheet accepts
+type Schema struct {
+	Strict    bool                  `yaml:"strict"`    // Reject variables given on the command line that are not declared
+	Variables map[string]*VarSchema `yaml:"variables"` // Declared variables by name; nested names use dots
+}
+
+// VarSchema constrains the value of one variable
+type VarSchema struct {
+	Required bool     `yaml:"required"` // The variable must be provided
+	Pattern  string   `yaml:"pattern"`  // Regular expression the whole value must match
+	Enum     []string `yaml:"enum"`     // Values the variable may take
+
+	pattern *regexp.Regexp
+}
+
+// LoadSchema reads the variable schema from a sheet directory
+// A missing file yields nil; unknown keys and invalid patterns are an error
+func LoadSchema(sheetDir string) (*Schema, error) {
+	data, err := os.ReadFile(filepath.Join(sheetDir, SchemaFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", SchemaFile, err)
+	}
+
+	schema := &Schema{}
+	if err := yaml.UnmarshalWithOptions(data, schema, yaml.Strict()); err != nil {
+		return nil, fmt.Errorf("failed to parse %s in %s: %w", SchemaFile, sheetDir, err)
+	}
+	for name, v := range schema.Variables {
+		if v == nil {
+			schema.Variables[name] = &VarSchema{}
+			continue
+		}
+		if v.Pattern == "" {
+			continue
+		}
+		if v.pattern, err = regexp.Compile(`^(?:` + v.Pattern + `)$`); err != nil {
+			return nil, fmt.Errorf("invalid pattern for %s in %s: %w", name, SchemaFile, err)
+		}
+	}
+	return schema, nil
+}
+
+// Check returns a problem for each declared variable whose value in vars is missing or rejected,
+// sorted by variable name
+func (s *Schema) Check(vars map[string]string) []string {
+	var problems []string
+	for _, name := range slices.Sorted(maps.Keys(s.Variables)) {
+		v := s.Variables[name]
+		value, ok := vars[name]
+		switch {
+		case !ok:
+			if v.Required && !hasNested(vars, name) {
+				problems = append(problems, fmt.Sprintf("%s: required but not set", name))
+			}
+		case v.pattern != nil && !v.pattern.MatchString(value):
+			problems = append(problems, fmt.Sprintf("%s: %q does not match pattern %s", name, value, v.Pattern))
+		case len(v.Enum) > 0 && !slices.Contains(v.Enum, value):
+			problems = append(problems, fmt.Sprintf("%s: %q is not one of %s", name, value, strings.Join(v.Enum, ", ")))
+		}
+	}
+	return problems
+}
+
+// Declares reports whether name, or a variable it is nested in, is declared
+func (s *Schema) Declares(name string) bool {
+	for declared := range s.Variables {
+		if name == declared || strings.HasPrefix(name, declared+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// hasNested reports whether vars sets a variable nested in name
+func hasNested(vars map[string]string, name string) bool {
+	for k := range vars {
+		if strings.HasPrefix(k, name+".") {
+			return true
+		}
+	}
+	return false
+}
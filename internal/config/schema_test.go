@@ -0,0 +1,79 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// writeSchema writes content as the schema file of a new sheet directory
+func writeSchema(t *testing.T, content string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, SchemaFile), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write schema file: %v", err)
+	}
+	return dir
+}
+
+func TestSchema_Check(t *testing.T) {
+	dir := writeSchema(t, `
+variables:
+  name:
+    required: true
+    pattern: '[a-z][a-z0-9-]*'
+  env:
+    enum: [dev, staging, prod]
+  db:
+    required: true
+  owner:
+    required: true
+  note:
+`)
+	schema, err := LoadSchema(dir)
+	if err != nil {
+		t.Fatalf("LoadSchema() failed: %v", err)
+	}
+
+	// Nested values satisfy a required parent, and undeclared constraints accept anything
+	if problems := schema.Check(map[string]string{"name": "api-1", "env": "prod", "db.host": "localhost", "owner": "", "note": "x"}); len(problems) != 0 {
+		t.Errorf("Check() = %v, want no problems", problems)
+	}
+
+	// Patterns must match the whole value
+	got := schema.Check(map[string]string{"name": "API-1", "env": "qa", "db.host": "localhost"})
+	want := []string{
+		`env: "qa" is not one of dev, staging, prod`,
+		`name: "API-1" does not match pattern [a-z][a-z0-9-]*`,
+		`owner: required but not set`,
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Check() = %q, want %q", got, want)
+	}
+	if got := schema.Check(map[string]string{"name": "api 1", "db": "x", "owner": "me"}); len(got) != 1 || !strings.HasPrefix(got[0], "name:") {
+		t.Errorf("Check() = %q, want a pattern mismatch for name", got)
+	}
+
+	if !schema.Declares("db.host") || !schema.Declares("note") || schema.Declares("names") {
+		t.Error("Declares() should match declared and nested names only")
+	}
+}
+
+func TestLoadSchema_Missing(t *testing.T) {
+	schema, err := LoadSchema(t.TempDir())
+	if err != nil || schema != nil {
+		t.Errorf("LoadSchema() = %v, %v; want nil, nil", schema, err)
+	}
+}
+
+func TestLoadSchema_Invalid(t *testing.T) {
+	if _, err := LoadSchema(writeSchema(t, "variables:\n  name:\n    patern: x\n")); err == nil {
+		t.Error("LoadSchema() should reject unknown keys")
+	}
+	_, err := LoadSchema(writeSchema(t, "variables:\n  name:\n    pattern: '[a-'\n"))
+	if err == nil || !strings.Contains(err.Error(), "invalid pattern for name") {
+		t.Errorf("LoadSchema() error = %v, want invalid pattern error", err)
+	}
+}
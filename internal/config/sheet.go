@@ -0,0 +1,188 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// SheetFile holds sheet-level settings at a sheet root
+// Unlike the removed sheet stamp.yaml its variable defaults rank below every other source,
+// and it is never written to the destination
+const SheetFile = ".stampsheet.yaml"
+
+// Sheet describes sheet-level settings
+type Sheet struct {
+	RequiresTools []string          `yaml:"requires_tools"` // Executables that must be on PATH
+	MinVersion    string            `yaml:"min_version"`    // Oldest stamp version that supports the sheet
+	Defaults      map[string]string `yaml:"defaults"`       // Variable values used when nothing else provides them
+	Post          []string          `yaml:"post"`           // Commands run in the destination after a successful press
+	Permissions   Permissions       `yaml:"permissions"`    // Modes of written files by output path pattern
+}
+
+// Permission sets the mode of written files whose output path matches Pattern
+type Permission struct {
+	Pattern string
+	Mode    os.FileMode
+}
+
+// Permissions holds the permission rules of a sheet in file order
+type Permissions []Permission
+
+// UnmarshalYAML reads a mapping of patterns to octal modes, keeping the order of the file
+// Modes are quoted strings such as "0755" or unquoted YAML octals such as 0755
+func (p *Permissions) UnmarshalYAML(unmarshal func(any) error) error {
+	var items yaml.MapSlice
+	if err := unmarshal(&items); err != nil {
+		return err
+	}
+	for _, item := range items {
+		pattern := fmt.Sprint(item.Key)
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid permissions pattern %q: %w", pattern, err)
+		}
+		var mode uint64
+		var err error
+		switch v := item.Value.(type) {
+		case string:
+			mode, err = strconv.ParseUint(v, 8, 32)
+		case uint64:
+			mode = v
+		case int64:
+			if mode = uint64(v); v < 0 {
+				err = strconv.ErrRange
+			}
+		default:
+			err = strconv.ErrSyntax
+		}
+		if err != nil || mode > 0777 {
+			return fmt.Errorf("invalid permissions %v for %q: want an octal mode such as \"0755\"", item.Value, pattern)
+		}
+		*p = append(*p, Permission{Pattern: pattern, Mode: os.FileMode(mode)})
+	}
+	return nil
+}
+
+// Lookup returns the mode declared for a slash-separated output path
+// As in .stampattributes, later matching patterns override earlier ones
+func (p Permissions) Lookup(relPath string) (os.FileMode, bool) {
+	var mode os.FileMode
+	found := false
+	for _, rule := range p {
+		if MatchPath(rule.Pattern, relPath) {
+			mode, found = rule.Mode, true
+		}
+	}
+	return mode, found
+}
+
+// MatchPath reports whether a slash-separated sheet-relative path matches a glob pattern
+// Patterns without a slash match the base name at any depth; others match the whole path,
+// and a leading slash only anchors the pattern at the root
+func MatchPath(pattern, relPath string) bool {
+	target := relPath
+	if !strings.Contains(pattern, "/") {
+		target = path.Base(relPath)
+	}
+	matched, _ := path.Match(strings.TrimPrefix(pattern, "/"), target)
+	return matched
+}
+
+// LoadSheet reads the sheet settings from a sheet directory
+// A missing file yields empty settings; unknown keys are an error
+func LoadSheet(sheetDir string) (*Sheet, error) {
+	data, err := os.ReadFile(filepath.Join(sheetDir, SheetFile))
+	if os.IsNotExist(err) {
+		return &Sheet{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", SheetFile, err)
+	}
+
+	sheet := &Sheet{}
+	if err := yaml.UnmarshalWithOptions(data, sheet, yaml.Strict()); err != nil {
+		return nil, fmt.Errorf("failed to parse %s in %s: %w", SheetFile, sheetDir, err)
+	}
+	return sheet, nil
+}
+
+// MergeDefaults combines the defaults of sheets applied in order
+// A later sheet's default replaces an earlier sheet's default for the same variable
+func MergeDefaults(sheets []*Sheet) map[string]string {
+	defaults := make(map[string]string)
+	for _, sheet := range sheets {
+		for k, v := range sheet.Defaults {
+			defaults[k] = v
+		}
+	}
+	return defaults
+}
+
+// CheckMinVersion fails if running is older than the sheet's min_version
+func (s *Sheet) CheckMinVersion(running string) error {
+	if s.MinVersion == "" {
+		return nil
+	}
+	cmp, err := compareVersions(running, s.MinVersion)
+	if err != nil {
+		return fmt.Errorf("invalid min_version: %w", err)
+	}
+	if cmp < 0 {
+		return fmt.Errorf("sheet requires stamp >= %s, you have %s", strings.TrimPrefix(s.MinVersion, "v"), strings.TrimPrefix(running, "v"))
+	}
+	return nil
+}
+
+// compareVersions compares two MAJOR[.MINOR[.PATCH]] versions with an optional "v" prefix
+// Pre-release versions sort before their release, as in semver
+func compareVersions(a, b string) (int, error) {
+	pa, preA, err := parseVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	pb, preB, err := parseVersion(b)
+	if err != nil {
+		return 0, err
+	}
+	for i := range pa {
+		if pa[i] != pb[i] {
+			if pa[i] < pb[i] {
+				return -1, nil
+			}
+			return 1, nil
+		}
+	}
+	switch {
+	case preA == preB:
+		return 0, nil
+	case preA == "":
+		return 1, nil
+	case preB == "":
+		return -1, nil
+	}
+	return strings.Compare(preA, preB), nil
+}
+
+// parseVersion splits a version into numeric parts and a pre-release suffix
+func parseVersion(v string) ([3]int, string, error) {
+	var parts [3]int
+	core, pre, _ := strings.Cut(strings.TrimPrefix(v, "v"), "-")
+	core, _, _ = strings.Cut(core, "+")
+	fields := strings.Split(core, ".")
+	if len(fields) > 3 {
+		return parts, "", fmt.Errorf("malformed version %q", v)
+	}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return parts, "", fmt.Errorf("malformed version %q", v)
+		}
+		parts[i] = n
+	}
+	return parts, pre, nil
+}
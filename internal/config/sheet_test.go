@@ -0,0 +1,165 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadSheet(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, SheetFile), []byte("requires_tools: [docker, node]\n"), 0644); err != nil {
+		t.Fatalf("failed to write sheet file: %v", err)
+	}
+
+	sheet, err := LoadSheet(dir)
+	if err != nil {
+		t.Fatalf("LoadSheet() failed: %v", err)
+	}
+	if len(sheet.RequiresTools) != 2 || sheet.RequiresTools[0] != "docker" || sheet.RequiresTools[1] != "node" {
+		t.Errorf("RequiresTools = %v, want [docker node]", sheet.RequiresTools)
+	}
+}
+
+func TestLoadSheet_Missing(t *testing.T) {
+	sheet, err := LoadSheet(t.TempDir())
+	if err != nil {
+		t.Fatalf("LoadSheet() failed: %v", err)
+	}
+	if len(sheet.RequiresTools) != 0 {
+		t.Errorf("RequiresTools = %v, want none", sheet.RequiresTools)
+	}
+}
+
+func TestLoadSheet_UnknownKey(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, SheetFile), []byte("requires_tool: [docker]\n"), 0644); err != nil {
+		t.Fatalf("failed to write sheet file: %v", err)
+	}
+
+	if _, err := LoadSheet(dir); err == nil {
+		t.Fatal("LoadSheet() should reject unknown keys")
+	}
+}
+
+func TestSheet_CheckMinVersion(t *testing.T) {
+	tests := []struct {
+		name       string
+		minVersion string
+		running    string
+		wantErr    string
+	}{
+		{name: "unset", minVersion: "", running: "0.0.1"},
+		{name: "equal", minVersion: "0.0.4", running: "0.0.4"},
+		{name: "newer minor", minVersion: "0.9.0", running: "0.10.0"},
+		{name: "v prefix", minVersion: "v1.2", running: "1.2.0"},
+		{name: "older", minVersion: "1.2.0", running: "1.1.9", wantErr: "sheet requires stamp >= 1.2.0, you have 1.1.9"},
+		{name: "pre-release", minVersion: "1.0.0", running: "1.0.0-rc.1", wantErr: "sheet requires stamp >= 1.0.0, you have 1.0.0-rc.1"},
+		{name: "malformed", minVersion: "one", running: "1.0.0", wantErr: "invalid min_version"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sheet := &Sheet{MinVersion: tt.minVersion}
+			err := sheet.CheckMinVersion(tt.running)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("CheckMinVersion(%q) failed: %v", tt.running, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("CheckMinVersion(%q) error = %v, want %q", tt.running, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMergeDefaults(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, SheetFile), []byte("defaults:\n  license: \"\"\n  branch: main\n"), 0644); err != nil {
+		t.Fatalf("failed to write sheet file: %v", err)
+	}
+	base, err := LoadSheet(dir)
+	if err != nil {
+		t.Fatalf("LoadSheet() failed: %v", err)
+	}
+	extra := &Sheet{Defaults: map[string]string{"branch": "develop"}}
+
+	defaults := MergeDefaults([]*Sheet{base, extra, {}})
+	if len(defaults) != 2 || defaults["branch"] != "develop" {
+		t.Errorf("MergeDefaults() = %v, want later sheet's branch", defaults)
+	}
+	if v, ok := defaults["license"]; !ok || v != "" {
+		t.Errorf("license = %q, %v, want an empty default", v, ok)
+	}
+}
+
+func TestLoadSheet_Permissions(t *testing.T) {
+	dir := t.TempDir()
+	content := "permissions:\n  \"*.sh\": \"0755\"\n  bin/*: 0700\n  bin/lib.sh: 0o644\n"
+	if err := os.WriteFile(filepath.Join(dir, SheetFile), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write sheet file: %v", err)
+	}
+
+	sheet, err := LoadSheet(dir)
+	if err != nil {
+		t.Fatalf("LoadSheet() failed: %v", err)
+	}
+	tests := []struct {
+		path  string
+		mode  os.FileMode
+		found bool
+	}{
+		{"run.sh", 0755, true},
+		{"scripts/run.sh", 0755, true},
+		{"bin/tool", 0700, true},
+		{"bin/lib.sh", 0644, true},
+		{"README.md", 0, false},
+	}
+	for _, tt := range tests {
+		if mode, found := sheet.Permissions.Lookup(tt.path); mode != tt.mode || found != tt.found {
+			t.Errorf("Lookup(%q) = %v, %v; want %v, %v", tt.path, mode, found, tt.mode, tt.found)
+		}
+	}
+}
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"*.sh", "run.sh", true},
+		{"*.sh", "scripts/run.sh", true},
+		{"bin/*", "bin/tool", true},
+		{"bin/*", "lib/bin/tool", false},
+		{"sub/*.stamp", "sub/nested/file.stamp", false},
+		{"/README.md", "README.md", true},
+		{"/README.md", "docs/README.md", false},
+		{"[", "x", false},
+	}
+	for _, tt := range tests {
+		if got := MatchPath(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("MatchPath(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestLoadSheet_InvalidPermissions(t *testing.T) {
+	for _, content := range []string{
+		"permissions:\n  \"*.sh\": 755\n",
+		"permissions:\n  \"*.sh\": \"rwx\"\n",
+		"permissions:\n  \"*.sh\": \"01755\"\n",
+		"permissions:\n  \"[\": \"0755\"\n",
+	} {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, SheetFile), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write sheet file: %v", err)
+		}
+		if _, err := LoadSheet(dir); err == nil || !strings.Contains(err.Error(), "permissions") {
+			t.Errorf("LoadSheet(%q) error = %v, want an invalid permissions error", content, err)
+		}
+	}
+}
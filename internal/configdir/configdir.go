@@ -4,16 +4,36 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 )
 
+// NotFoundError is returned when requested sheets do not exist in any config directory
+type NotFoundError struct {
+	Sheets  []string // Missing sheet names, or the pattern that matched none
+	Message string   // Full message, naming the directories searched and the sheets available
+}
+
+func (e *NotFoundError) Error() string {
+	return e.Message
+}
+
 // GetConfigDir returns the default config directory path
 // Priority: $XDG_CONFIG_HOME/stamp > os.UserConfigDir()/stamp
+// A leading ~ in XDG_CONFIG_HOME is expanded and a relative value is made absolute
 // Does NOT create the directory
 func GetConfigDir() (string, error) {
 	// Check XDG_CONFIG_HOME first
 	if xdgConfig := os.Getenv("XDG_CONFIG_HOME"); xdgConfig != "" {
+		xdgConfig, err := expandHome(xdgConfig)
+		if err != nil {
+			return "", err
+		}
+		xdgConfig, err = filepath.Abs(xdgConfig)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve XDG_CONFIG_HOME: %w", err)
+		}
 		return filepath.Join(xdgConfig, "stamp"), nil
 	}
 
@@ -28,11 +48,15 @@ func GetConfigDir() (string, error) {
 
 // GetConfigDirWithOverride returns config directory, with optional override
 // If override is empty, uses GetConfigDir()
-// If override is provided, validates it exists and returns it
+// If override is provided, expands a leading ~, validates it exists and returns it
 func GetConfigDirWithOverride(override string) (string, error) {
 	if override == "" {
 		return GetConfigDir()
 	}
+	override, err := expandHome(override)
+	if err != nil {
+		return "", err
+	}
 
 	// Validate override path exists
 	info, err := os.Stat(override)
@@ -49,46 +73,131 @@ func GetConfigDirWithOverride(override string) (string, error) {
 	return override, nil
 }
 
+// expandHome replaces a leading "~" or "~/" in path with the user's home directory
+// Other uses of ~, such as "~user", are left as they are
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") && !strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to expand ~ in %s: %w", path, err)
+	}
+	return filepath.Join(home, path[1:]), nil
+}
+
+// PathEnv names the environment variable listing more config directories to search for sheets
+const PathEnv = "STAMP_PATH"
+
+// SearchPath returns configDir followed by the directories listed in STAMP_PATH
+// Entries are separated by the OS path list separator (':' on Unix); empty entries
+// and duplicates are dropped. Earlier directories win when several have a sheet
+func SearchPath(configDir string) []string {
+	dirs := []string{configDir}
+	for _, dir := range filepath.SplitList(os.Getenv(PathEnv)) {
+		if dir != "" && !slices.Contains(dirs, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// DefaultSheetRoot is the config subdirectory that holds sheets
+const DefaultSheetRoot = "sheets"
+
+// ValidateSheetRoot checks that a sheet root names a directory inside the config directory
+func ValidateSheetRoot(sheetRoot string) error {
+	if sheetRoot == "" || !filepath.IsLocal(sheetRoot) {
+		return fmt.Errorf("invalid sheet root %q: must be a relative path inside the config directory", sheetRoot)
+	}
+	return nil
+}
+
+// SheetDir returns the directory of a sheet: {configDir}/{sheetRoot}/{name}
+func SheetDir(configDir, sheetRoot, name string) string {
+	return filepath.Join(configDir, sheetRoot, name)
+}
+
 // ResolveTemplateDir resolves sheet directory path and validates existence
 // Returns: {configDir}/sheets/{templateName}/
 // Validates directory exists, returns helpful error if not
 func ResolveTemplateDir(configDir, templateName string) (string, error) {
-	templatePath := filepath.Join(configDir, "sheets", templateName)
+	return ResolveTemplateDirWithRoot(configDir, DefaultSheetRoot, templateName)
+}
 
-	// Check if sheet directory exists
-	info, err := os.Stat(templatePath)
-	if os.IsNotExist(err) {
+// ResolveTemplateDirWithRoot is ResolveTemplateDir for sheets under {configDir}/{sheetRoot}/
+func ResolveTemplateDirWithRoot(configDir, sheetRoot, templateName string) (string, error) {
+	return ResolveTemplateDirInRoots([]string{configDir}, sheetRoot, templateName)
+}
+
+// ResolveTemplateDirInRoots is ResolveTemplateDirWithRoot searching each config directory in order
+// The first config directory that has the sheet wins
+func ResolveTemplateDirInRoots(configDirs []string, sheetRoot, templateName string) (string, error) {
+	templatePath, err := findSheet(configDirs, sheetRoot, templateName)
+	if err != nil {
+		return "", err
+	}
+	if templatePath == "" {
 		// Sheet doesn't exist - provide helpful error with available sheets
-		available, listErr := ListAvailableSheets(configDir)
+		configDir := configDirs[0]
+		available, listErr := ListAvailableSheetsInRoots(configDirs, sheetRoot)
 		if listErr != nil || len(available) == 0 {
-			return "", fmt.Errorf("sheet '%s' not found in %s/sheets/\n\nCreate sheet directory: mkdir -p %s/sheets/%s",
-				templateName, configDir, configDir, templateName)
+			return "", &NotFoundError{Sheets: []string{templateName}, Message: fmt.Sprintf("sheet '%s' not found in %s\n\nCreate sheet directory: mkdir -p %s/%s/%s",
+				templateName, searchedDirs(configDirs, sheetRoot), configDir, sheetRoot, templateName)}
 		}
 
 		var sb strings.Builder
-		sb.WriteString(fmt.Sprintf("sheet '%s' not found in %s/sheets/\n\n", templateName, configDir))
+		sb.WriteString(fmt.Sprintf("sheet '%s' not found in %s\n\n", templateName, searchedDirs(configDirs, sheetRoot)))
 		sb.WriteString("Available sheets:\n")
 		for _, name := range available {
 			sb.WriteString(fmt.Sprintf("  - %s\n", name))
 		}
-		sb.WriteString(fmt.Sprintf("\nCreate new sheet: mkdir -p %s/sheets/%s", configDir, templateName))
-		return "", fmt.Errorf("%s", sb.String())
-	}
-	if err != nil {
-		return "", fmt.Errorf("failed to access sheet directory: %w", err)
-	}
-	if !info.IsDir() {
-		return "", fmt.Errorf("sheet path is not a directory: %s", templatePath)
+		sb.WriteString(fmt.Sprintf("\nCreate new sheet: mkdir -p %s/%s/%s", configDir, sheetRoot, templateName))
+		return "", &NotFoundError{Sheets: []string{templateName}, Message: sb.String()}
 	}
 
 	return templatePath, nil
 }
 
+// findSheet returns the directory of a sheet in the first config directory that has it
+// It returns "" if no config directory has the sheet
+func findSheet(configDirs []string, sheetRoot, templateName string) (string, error) {
+	for _, configDir := range configDirs {
+		templatePath := SheetDir(configDir, sheetRoot, templateName)
+		info, err := os.Stat(templatePath)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to access sheet '%s': %w", templateName, err)
+		}
+		if !info.IsDir() {
+			return "", fmt.Errorf("sheet path is not a directory: %s", templatePath)
+		}
+		return templatePath, nil
+	}
+	return "", nil
+}
+
+// searchedDirs describes the sheet directories of configDirs for error messages
+func searchedDirs(configDirs []string, sheetRoot string) string {
+	dirs := make([]string, len(configDirs))
+	for i, configDir := range configDirs {
+		dirs[i] = fmt.Sprintf("%s/%s/", configDir, sheetRoot)
+	}
+	return strings.Join(dirs, ", ")
+}
+
 // ListAvailableSheets returns list of sheet names in config directory
 // Returns: []string of sheet names from sheets/ subdirectory
 // Used for error messages when sheet not found
 func ListAvailableSheets(configDir string) ([]string, error) {
-	sheetsDir := filepath.Join(configDir, "sheets")
+	return ListAvailableSheetsWithRoot(configDir, DefaultSheetRoot)
+}
+
+// ListAvailableSheetsWithRoot is ListAvailableSheets for sheets under {configDir}/{sheetRoot}/
+func ListAvailableSheetsWithRoot(configDir, sheetRoot string) ([]string, error) {
+	sheetsDir := filepath.Join(configDir, sheetRoot)
 
 	// Check if sheets directory exists
 	info, err := os.Stat(sheetsDir)
@@ -122,9 +231,87 @@ func ListAvailableSheets(configDir string) ([]string, error) {
 	return sheets, nil
 }
 
+// ListAvailableSheetsInRoots lists the sheets of every config directory, sorted and without duplicates
+func ListAvailableSheetsInRoots(configDirs []string, sheetRoot string) ([]string, error) {
+	seen := make(map[string]bool)
+	sheets := []string{}
+	for _, configDir := range configDirs {
+		names, err := ListAvailableSheetsWithRoot(configDir, sheetRoot)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			if !seen[name] {
+				seen[name] = true
+				sheets = append(sheets, name)
+			}
+		}
+	}
+	sort.Strings(sheets)
+	return sheets, nil
+}
+
+// ExpandSheetGlobs replaces each name containing glob characters (*, ? or [) with the available
+// sheets it matches, in sorted order; names keep their relative order for the overwrite priority
+// A name that exists as a sheet is used literally, and a sheet already selected by an earlier
+// pattern or name is not added again by a pattern
+func ExpandSheetGlobs(configDirs []string, sheetRoot string, names []string) ([]string, error) {
+	var available []string
+	var expanded []string
+	for _, name := range names {
+		if !strings.ContainsAny(name, "*?[") {
+			expanded = append(expanded, name)
+			continue
+		}
+		path, err := findSheet(configDirs, sheetRoot, name)
+		if err != nil {
+			return nil, err
+		}
+		if path != "" {
+			expanded = append(expanded, name)
+			continue
+		}
+
+		if available == nil {
+			if available, err = ListAvailableSheetsInRoots(configDirs, sheetRoot); err != nil {
+				return nil, err
+			}
+		}
+		matched := false
+		for _, sheet := range available {
+			ok, err := filepath.Match(name, sheet)
+			if err != nil {
+				return nil, fmt.Errorf("invalid sheet pattern %q: %w", name, err)
+			}
+			if !ok {
+				continue
+			}
+			matched = true
+			if !slices.Contains(expanded, sheet) {
+				expanded = append(expanded, sheet)
+			}
+		}
+		if !matched {
+			return nil, &NotFoundError{Sheets: []string{name}, Message: fmt.Sprintf("no sheets match %q in %s", name, searchedDirs(configDirs, sheetRoot))}
+		}
+	}
+	return expanded, nil
+}
+
 // ResolveTemplateDirs resolves multiple sheet directories and validates ALL exist
 // Returns all resolved paths OR comprehensive error
 func ResolveTemplateDirs(configDir string, templateNames []string) ([]string, error) {
+	return ResolveTemplateDirsWithRoot(configDir, DefaultSheetRoot, templateNames)
+}
+
+// ResolveTemplateDirsWithRoot is ResolveTemplateDirs for sheets under {configDir}/{sheetRoot}/
+func ResolveTemplateDirsWithRoot(configDir, sheetRoot string, templateNames []string) ([]string, error) {
+	return ResolveTemplateDirsInRoots([]string{configDir}, sheetRoot, templateNames)
+}
+
+// ResolveTemplateDirsInRoots is ResolveTemplateDirsWithRoot searching each config directory in order
+// Each sheet is taken from the first config directory that has it
+func ResolveTemplateDirsInRoots(configDirs []string, sheetRoot string, templateNames []string) ([]string, error) {
 	if len(templateNames) == 0 {
 		return nil, fmt.Errorf("no sheets specified")
 	}
@@ -135,16 +322,13 @@ func ResolveTemplateDirs(configDir string, templateNames []string) ([]string, er
 
 	// Try to resolve each sheet
 	for _, name := range templateNames {
-		path := filepath.Join(configDir, "sheets", name)
-		info, err := os.Stat(path)
-
-		if os.IsNotExist(err) {
+		path, err := findSheet(configDirs, sheetRoot, name)
+		if err != nil {
+			return nil, err
+		}
+		if path == "" {
 			missingTemplates = append(missingTemplates, name)
 			foundTemplates = append(foundTemplates, fmt.Sprintf("  ✗ %s - not found", name))
-		} else if err != nil {
-			return nil, fmt.Errorf("failed to access sheet '%s': %w", name, err)
-		} else if !info.IsDir() {
-			return nil, fmt.Errorf("sheet path is not a directory: %s", path)
 		} else {
 			resolvedPaths = append(resolvedPaths, path)
 			foundTemplates = append(foundTemplates, fmt.Sprintf("  ✓ %s - %s", name, path))
@@ -153,7 +337,7 @@ func ResolveTemplateDirs(configDir string, templateNames []string) ([]string, er
 
 	// If any sheets are missing, return comprehensive error
 	if len(missingTemplates) > 0 {
-		available, _ := ListAvailableSheets(configDir)
+		available, _ := ListAvailableSheetsInRoots(configDirs, sheetRoot)
 
 		var sb strings.Builder
 		sb.WriteString("Failed to resolve sheets:\n")
@@ -170,10 +354,10 @@ func ResolveTemplateDirs(configDir string, templateNames []string) ([]string, er
 
 		sb.WriteString("\nCreate missing sheets:\n")
 		for _, name := range missingTemplates {
-			sb.WriteString(fmt.Sprintf("  mkdir -p %s/sheets/%s\n", configDir, name))
+			sb.WriteString(fmt.Sprintf("  mkdir -p %s/%s/%s\n", configDirs[0], sheetRoot, name))
 		}
 
-		return nil, fmt.Errorf("%s", sb.String())
+		return nil, &NotFoundError{Sheets: missingTemplates, Message: sb.String()}
 	}
 
 	return resolvedPaths, nil
@@ -3,6 +3,7 @@ package configdir
 import (
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -53,6 +54,56 @@ func TestGetConfigDir(t *testing.T) {
 	}
 }
 
+func TestGetConfigDir_ExpandsXDGConfigHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		xdgConfigHome string
+		want          string
+	}{
+		{"tilde prefix", "~/foo", filepath.Join(home, "foo", "stamp")},
+		{"tilde only", "~", filepath.Join(home, "stamp")},
+		{"relative", "rel/config", filepath.Join(wd, "rel", "config", "stamp")},
+		{"tilde user is relative", "~other/config", filepath.Join(wd, "~other", "config", "stamp")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("XDG_CONFIG_HOME", tt.xdgConfigHome)
+			got, err := GetConfigDir()
+			if err != nil {
+				t.Fatalf("GetConfigDir() failed: %v", err)
+			}
+			if !filepath.IsAbs(got) || got != tt.want {
+				t.Errorf("GetConfigDir() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetConfigDirWithOverride_ExpandsTilde(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	if err := os.MkdirAll(filepath.Join(home, "sheets"), 0755); err != nil {
+		t.Fatalf("failed to create test directory: %v", err)
+	}
+
+	got, err := GetConfigDirWithOverride("~/sheets")
+	if err != nil {
+		t.Fatalf("GetConfigDirWithOverride() failed: %v", err)
+	}
+	if want := filepath.Join(home, "sheets"); got != want {
+		t.Errorf("GetConfigDirWithOverride() = %v, want %v", got, want)
+	}
+}
+
 func TestGetConfigDirWithOverride(t *testing.T) {
 	// Create temporary directory for testing
 	tmpDir := t.TempDir()
@@ -267,3 +318,151 @@ func TestListAvailableSheets(t *testing.T) {
 		})
 	}
 }
+
+func TestResolveTemplateDirsWithRoot(t *testing.T) {
+	tmpDir := t.TempDir()
+	sheet := filepath.Join(tmpDir, "stamp-sheets", "go-cli")
+	if err := os.MkdirAll(sheet, 0755); err != nil {
+		t.Fatalf("failed to create test directory: %v", err)
+	}
+
+	got, err := ResolveTemplateDirsWithRoot(tmpDir, "stamp-sheets", []string{"go-cli"})
+	if err != nil {
+		t.Fatalf("ResolveTemplateDirsWithRoot() failed: %v", err)
+	}
+	if len(got) != 1 || got[0] != sheet {
+		t.Errorf("ResolveTemplateDirsWithRoot() = %v, want [%s]", got, sheet)
+	}
+
+	available, err := ListAvailableSheetsWithRoot(tmpDir, "stamp-sheets")
+	if err != nil || len(available) != 1 || available[0] != "go-cli" {
+		t.Errorf("ListAvailableSheetsWithRoot() = %v, %v, want [go-cli]", available, err)
+	}
+
+	// The default root does not see sheets under a custom root
+	_, err = ResolveTemplateDirs(tmpDir, []string{"go-cli"})
+	if err == nil {
+		t.Fatal("ResolveTemplateDirs() should not find sheets under a custom root")
+	}
+
+	_, err = ResolveTemplateDirWithRoot(tmpDir, "stamp-sheets", "missing")
+	if err == nil || !strings.Contains(err.Error(), "stamp-sheets/missing") {
+		t.Errorf("ResolveTemplateDirWithRoot() error = %v, want hint with custom root", err)
+	}
+}
+
+func TestResolveTemplateDirsInRoots(t *testing.T) {
+	company := t.TempDir()
+	personal := t.TempDir()
+	for _, dir := range []string{
+		filepath.Join(company, "sheets", "shared"),
+		filepath.Join(company, "sheets", "go-cli"),
+		filepath.Join(personal, "sheets", "go-cli"),
+		filepath.Join(personal, "sheets", "dotfiles"),
+	} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("failed to create test directory: %v", err)
+		}
+	}
+	roots := []string{company, personal}
+
+	// Sheets only in the second root are found; earlier roots win for duplicates
+	got, err := ResolveTemplateDirsInRoots(roots, "sheets", []string{"dotfiles", "go-cli"})
+	if err != nil {
+		t.Fatalf("ResolveTemplateDirsInRoots() failed: %v", err)
+	}
+	want := []string{filepath.Join(personal, "sheets", "dotfiles"), filepath.Join(company, "sheets", "go-cli")}
+	if len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("ResolveTemplateDirsInRoots() = %v, want %v", got, want)
+	}
+
+	dir, err := ResolveTemplateDirInRoots(roots, "sheets", "dotfiles")
+	if err != nil || dir != want[0] {
+		t.Errorf("ResolveTemplateDirInRoots() = %q, %v, want %q", dir, err, want[0])
+	}
+
+	available, err := ListAvailableSheetsInRoots(roots, "sheets")
+	if err != nil || strings.Join(available, ",") != "dotfiles,go-cli,shared" {
+		t.Errorf("ListAvailableSheetsInRoots() = %v, %v, want [dotfiles go-cli shared]", available, err)
+	}
+
+	// Missing sheets list every searched root and suggest creating them in the first
+	_, err = ResolveTemplateDirInRoots(roots, "sheets", "missing")
+	if err == nil || !strings.Contains(err.Error(), personal) || !strings.Contains(err.Error(), "mkdir -p "+company) {
+		t.Errorf("ResolveTemplateDirInRoots() error = %v, want both roots and a hint for the first", err)
+	}
+}
+
+func TestExpandSheetGlobs(t *testing.T) {
+	company := t.TempDir()
+	personal := t.TempDir()
+	for _, dir := range []string{
+		filepath.Join(company, "sheets", "web-api"),
+		filepath.Join(company, "sheets", "base"),
+		filepath.Join(personal, "sheets", "web-ui"),
+		filepath.Join(personal, "sheets", "web-*"),
+		filepath.Join(personal, "sheets", "worker"),
+	} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("failed to create test directory: %v", err)
+		}
+	}
+	roots := []string{company, personal}
+
+	tests := []struct {
+		names []string
+		want  []string
+	}{
+		// Matches are sorted and placed where the pattern was given
+		{[]string{"base", "w?b-[au]*"}, []string{"base", "web-api", "web-ui"}},
+		// A pattern does not add a sheet selected before it again
+		{[]string{"web-ui", "web-[^*]*"}, []string{"web-ui", "web-api"}},
+		// A sheet named like a pattern is used literally
+		{[]string{"web-*", "base"}, []string{"web-*", "base"}},
+		{[]string{"*er", "base"}, []string{"worker", "base"}},
+	}
+	for _, tt := range tests {
+		got, err := ExpandSheetGlobs(roots, "sheets", tt.names)
+		if err != nil {
+			t.Fatalf("ExpandSheetGlobs(%v) failed: %v", tt.names, err)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("ExpandSheetGlobs(%v) = %v, want %v", tt.names, got, tt.want)
+		}
+	}
+
+	if _, err := ExpandSheetGlobs(roots, "sheets", []string{"db-*"}); err == nil || !strings.Contains(err.Error(), `no sheets match "db-*"`) {
+		t.Errorf("ExpandSheetGlobs() error = %v, want no match error", err)
+	}
+	if _, err := ExpandSheetGlobs(roots, "sheets", []string{"web-["}); err == nil {
+		t.Error("ExpandSheetGlobs() should reject a malformed pattern")
+	}
+}
+
+func TestSearchPath(t *testing.T) {
+	t.Setenv(PathEnv, strings.Join([]string{"/company", "", "/main", "/personal"}, string(filepath.ListSeparator)))
+
+	got := SearchPath("/main")
+	want := []string{"/main", "/company", "/personal"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("SearchPath() = %v, want %v", got, want)
+	}
+
+	t.Setenv(PathEnv, "")
+	if got := SearchPath("/main"); len(got) != 1 || got[0] != "/main" {
+		t.Errorf("SearchPath() without %s = %v, want [/main]", PathEnv, got)
+	}
+}
+
+func TestValidateSheetRoot(t *testing.T) {
+	for _, root := range []string{"sheets", "stamp/sheets"} {
+		if err := ValidateSheetRoot(root); err != nil {
+			t.Errorf("ValidateSheetRoot(%q) = %v, want nil", root, err)
+		}
+	}
+	for _, root := range []string{"", "../sheets", "/abs"} {
+		if err := ValidateSheetRoot(root); err == nil {
+			t.Errorf("ValidateSheetRoot(%q) = nil, want error", root)
+		}
+	}
+}
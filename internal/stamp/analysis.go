@@ -0,0 +1,361 @@
+package stamp
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"text/template/parse"
+)
+
+// builtinFuncs lists the functions predefined by text/template
+var builtinFuncs = map[string]struct{}{
+	"and": {}, "call": {}, "html": {}, "index": {}, "slice": {}, "js": {},
+	"len": {}, "not": {}, "or": {}, "print": {}, "printf": {}, "println": {},
+	"urlquery": {}, "eq": {}, "ge": {}, "gt": {}, "le": {}, "lt": {}, "ne": {},
+}
+
+// Analysis describes the variables and functions used by a set of sheets
+// All map values are template paths relative to their sheet directory
+type Analysis struct {
+	Required         map[string][]string // variables used at least once without a default
+	RequiredAt       map[string][]string // "path:line" of each unguarded use of a required variable; names by path only
+	Optional         map[string][]string // variables only ever used guarded by default
+	UnknownFunctions map[string][]string // functions that are neither builtin nor registered
+	ParseErrors      map[string]error    // templates that failed to parse
+	ReadsAll         []string            // templates that read the whole variable map or look variables up with var
+}
+
+// RequiredNames returns the sorted names of required variables
+func (a *Analysis) RequiredNames() []string {
+	return sortedKeys(a.Required)
+}
+
+// OptionalNames returns the sorted names of optional variables
+func (a *Analysis) OptionalNames() []string {
+	return sortedKeys(a.Optional)
+}
+
+// AnalyzeSheet scans the template files in dirs using the given extension
+func AnalyzeSheet(dirs []string, ext string) (*Analysis, error) {
+	return New(nil, ext).Analyze(dirs)
+}
+
+// Analyze scans the template files in dirs using the Stamper's settings
+// It is the single source of truth for sheet variable analysis; validation checks its result
+// Only files that pressing would render are analyzed: WithOnly, symlinks that would be
+// skipped and, with WithCopyBinary, binary files are left out
+// Parse errors are recorded in the result rather than returned
+func (s *Stamper) Analyze(dirs []string) (*Analysis, error) {
+	a := &Analysis{
+		Required:         make(map[string][]string),
+		RequiredAt:       make(map[string][]string),
+		Optional:         make(map[string][]string),
+		UnknownFunctions: make(map[string][]string),
+		ParseErrors:      make(map[string]error),
+	}
+	guarded := make(map[string][]string)
+
+	for _, dir := range dirs {
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			// Variables in file and directory names are analyzed like template text
+			// With WithOnly, files outside the selection are ignored and directory names are
+			// analyzed for the selected files beneath them
+			relPath, _ := relSlashPath(dir, path)
+			if !info.IsDir() && relPath != PartialsFile && !s.selectedSource(relPath) {
+				return nil
+			}
+			if path != dir && (!info.IsDir() || len(s.only) == 0) {
+				s.analyzeName(relPath, info.Name(), guarded, a)
+			}
+			if !info.IsDir() && len(s.only) > 0 {
+				names := strings.Split(relPath, "/")
+				for i := 1; i < len(names); i++ {
+					s.analyzeName(strings.Join(names[:i], "/"), names[i-1], guarded, a)
+				}
+			}
+
+			// Partials are analyzed like a template
+			if relPath == PartialsFile {
+				usage, err := analyzeTemplate(path, s.leftDelim, s.rightDelim)
+				if err != nil {
+					a.ParseErrors[relPath] = err
+					return nil
+				}
+				s.record(usage, relPath, false, guarded, a)
+				return nil
+			}
+
+			// Skip non-template files
+			if info.IsDir() || IsSpecialFile(info.Mode()) || s.isTmplNoopFile(path) || !s.isTemplateFile(path) {
+				return nil
+			}
+
+			// Symlinks that will be skipped are not analyzed either
+			if info.Mode()&os.ModeSymlink != 0 {
+				if reason, _ := SymlinkSkipReason(dir, path, "the sheet", s.allowExternalSymlinks); reason != "" {
+					return nil
+				}
+			}
+
+			leftDelim, rightDelim := s.delimsFor(path)
+			usage, err := analyzeTemplate(path, leftDelim, rightDelim)
+			if errors.Is(err, ErrBinaryTemplate) && s.copyBinary {
+				return nil
+			}
+			if err != nil {
+				a.ParseErrors[relPath] = err
+				return nil
+			}
+			s.record(usage, relPath, false, guarded, a)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan templates: %w", err)
+		}
+	}
+
+	// Variables in the output prefix are used like variables in file names
+	s.analyzeName(outputPrefixSource, s.outputPrefix, guarded, a)
+
+	// A variable is optional only if no template uses it unguarded
+	for v, paths := range guarded {
+		if _, ok := a.Required[v]; !ok {
+			a.Optional[v] = paths
+		}
+	}
+
+	return a, nil
+}
+
+// analyzeName records the variables used by a file or directory name, or the output prefix, at relPath
+func (s *Stamper) analyzeName(relPath, name string, guarded map[string][]string, a *Analysis) {
+	if !strings.Contains(name, s.leftDelim) {
+		return
+	}
+	usage, err := analyzeText(name, name, s.leftDelim, s.rightDelim)
+	if err != nil {
+		a.ParseErrors[relPath] = err
+		return
+	}
+	s.record(usage, relPath, true, guarded, a)
+}
+
+// templateUsage holds the names found in a single template
+type templateUsage struct {
+	required map[string][]int // Lines of each unguarded use, in order
+	guarded  map[string]struct{}
+	funcs    map[string]struct{}
+	readsAll bool // The root "." is used as a value, e.g. {{range $k, $v := .}}
+	scoped   int  // Depth of range and with bodies, where "." is no longer the root
+	text     string
+	tree     *parse.Tree
+}
+
+// line returns the 1-based line of the byte offset pos in the template text
+func (u *templateUsage) line(pos parse.Pos) int {
+	return 1 + strings.Count(u.text[:min(int(pos), len(u.text))], "\n")
+}
+
+// locations returns "path:line" for each distinct line in lines
+func locations(relPath string, lines []int) []string {
+	var locs []string
+	for i, line := range lines {
+		if i == 0 || line != lines[i-1] {
+			locs = append(locs, fmt.Sprintf("%s:%d", relPath, line))
+		}
+	}
+	return locs
+}
+
+// record adds the names a template uses at relPath to the analysis
+// Uses in a name are located by path only, and each path is recorded once per variable
+func (s *Stamper) record(u *templateUsage, relPath string, isName bool, guarded map[string][]string, a *Analysis) {
+	if _, dynamic := u.funcs["var"]; (u.readsAll || dynamic) && !slices.Contains(a.ReadsAll, relPath) {
+		a.ReadsAll = append(a.ReadsAll, relPath)
+	}
+	for v, lines := range u.required {
+		if !slices.Contains(a.Required[v], relPath) {
+			a.Required[v] = append(a.Required[v], relPath)
+		}
+		if isName {
+			if !slices.Contains(a.RequiredAt[v], relPath) {
+				a.RequiredAt[v] = append(a.RequiredAt[v], relPath)
+			}
+			continue
+		}
+		a.RequiredAt[v] = append(a.RequiredAt[v], locations(relPath, lines)...)
+	}
+	for v := range u.guarded {
+		if !slices.Contains(guarded[v], relPath) {
+			guarded[v] = append(guarded[v], relPath)
+		}
+	}
+	for f := range u.funcs {
+		if !s.isKnownFunc(f) && !slices.Contains(a.UnknownFunctions[f], relPath) {
+			a.UnknownFunctions[f] = append(a.UnknownFunctions[f], relPath)
+		}
+	}
+}
+
+// analyzeTemplate parses a template file and classifies its variables
+func analyzeTemplate(templatePath, leftDelim, rightDelim string) (*templateUsage, error) {
+	content, err := os.ReadFile(templatePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template: %w", err)
+	}
+	if IsBinary(content) {
+		return nil, ErrBinaryTemplate
+	}
+	return analyzeText(filepath.Base(templatePath), string(content), leftDelim, rightDelim)
+}
+
+// analyzeText parses template text and classifies its variables
+func analyzeText(name, text, leftDelim, rightDelim string) (*templateUsage, error) {
+	// Skip the function check so unknown functions can be reported instead of failing
+	tree := parse.New(name)
+	tree.Mode = parse.SkipFuncCheck
+	defined := make(map[string]*parse.Tree)
+	if _, err := tree.Parse(text, leftDelim, rightDelim, defined); err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	u := &templateUsage{
+		required: make(map[string][]int),
+		guarded:  make(map[string]struct{}),
+		funcs:    make(map[string]struct{}),
+		text:     text,
+		tree:     tree,
+	}
+	if tree.Root != nil {
+		u.walk(tree.Root, false)
+	}
+	// Bodies of {{define}} blocks are parsed into separate trees
+	for name, t := range defined {
+		if name != tree.Name && t.Root != nil {
+			u.walk(t.Root, false)
+		}
+	}
+	return u, nil
+}
+
+// walk records fields and function identifiers found under node
+// guarded is true when the node's value is passed to default
+func (u *templateUsage) walk(node parse.Node, guarded bool) {
+	switch n := node.(type) {
+	case *parse.FieldNode:
+		if len(n.Ident) > 0 {
+			if guarded {
+				u.guarded[n.Ident[0]] = struct{}{}
+			} else {
+				u.required[n.Ident[0]] = append(u.required[n.Ident[0]], u.line(n.Pos))
+			}
+		}
+
+	case *parse.IdentifierNode:
+		u.funcs[n.Ident] = struct{}{}
+
+	case *parse.DotNode:
+		if u.scoped == 0 {
+			u.readsAll = true
+		}
+
+	case *parse.ChainNode:
+		u.walk(n.Node, guarded)
+
+	case *parse.ListNode:
+		if n != nil {
+			for _, child := range n.Nodes {
+				u.walk(child, false)
+			}
+		}
+
+	case *parse.ActionNode:
+		u.walk(n.Pipe, false)
+
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		// In {{.x | default "y"}} the command before default is guarded
+		for i, cmd := range n.Cmds {
+			next := i+1 < len(n.Cmds) && isDefaultCommand(n.Cmds[i+1])
+			u.walk(cmd, guarded || next)
+		}
+
+	case *parse.CommandNode:
+		// In {{default "y" .x}} every argument after default is guarded
+		isDefault := isDefaultCommand(n)
+		for i, arg := range n.Args {
+			u.walk(arg, guarded || (isDefault && i > 0))
+		}
+
+	case *parse.IfNode:
+		u.walkBranch(&n.BranchNode, false)
+
+	case *parse.RangeNode:
+		u.walkBranch(&n.BranchNode, true)
+
+	case *parse.WithNode:
+		u.walkBranch(&n.BranchNode, true)
+
+	case *parse.TemplateNode:
+		// Passing the root to a named template only reads what its body reads
+		if !isDotPipe(n.Pipe) {
+			u.walk(n.Pipe, false)
+		}
+	}
+}
+
+// walkBranch walks branch nodes (if, range, with)
+// rebinds is true for range and with, whose bodies see a different "."
+func (u *templateUsage) walkBranch(branch *parse.BranchNode, rebinds bool) {
+	u.walk(branch.Pipe, false)
+	if branch.List != nil {
+		if rebinds {
+			u.scoped++
+		}
+		u.walk(branch.List, false)
+		if rebinds {
+			u.scoped--
+		}
+	}
+	if branch.ElseList != nil {
+		u.walk(branch.ElseList, false)
+	}
+}
+
+// isDotPipe reports whether pipe is just "."
+func isDotPipe(pipe *parse.PipeNode) bool {
+	if pipe == nil || len(pipe.Decl) > 0 || len(pipe.Cmds) != 1 || len(pipe.Cmds[0].Args) != 1 {
+		return false
+	}
+	_, ok := pipe.Cmds[0].Args[0].(*parse.DotNode)
+	return ok
+}
+
+// isDefaultCommand reports whether cmd invokes the default function
+func isDefaultCommand(cmd *parse.CommandNode) bool {
+	if len(cmd.Args) == 0 {
+		return false
+	}
+	ident, ok := cmd.Args[0].(*parse.IdentifierNode)
+	return ok && ident.Ident == "default"
+}
+
+// sortedKeys returns the keys of m in sorted order
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,155 @@
+package stamp
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestAnalyzeSheet_Categories tests that each analysis category is populated
+func TestAnalyzeSheet_Categories(t *testing.T) {
+	src := t.TempDir()
+	createTestFile(t, src, "main.go.stamp", "package {{.pkg}}\n// {{.owner | default \"nobody\"}}")
+	createTestFile(t, src, "README.md.stamp", "{{default \"v0\" .version}} {{shout .pkg}}")
+	createTestFile(t, src, "broken.txt.stamp", "{{.name")
+	createTestFile(t, src, "raw.txt.stamp.noop", "{{.ignored}}")
+	createTestFile(t, src, "static.txt", "{{.static}}")
+
+	a, err := AnalyzeSheet([]string{src}, ".stamp")
+	if err != nil {
+		t.Fatalf("AnalyzeSheet() failed: %v", err)
+	}
+
+	assertVarsEqual(t, a.RequiredNames(), []string{"pkg"})
+	assertVarsEqual(t, a.OptionalNames(), []string{"owner", "version"})
+
+	if _, ok := a.UnknownFunctions["shout"]; !ok {
+		t.Errorf("UnknownFunctions should contain 'shout', got %v", a.UnknownFunctions)
+	}
+	if _, ok := a.UnknownFunctions["default"]; !ok {
+		t.Errorf("UnknownFunctions should contain 'default', got %v", a.UnknownFunctions)
+	}
+	if _, ok := a.UnknownFunctions["printf"]; ok {
+		t.Error("builtin functions should not be reported as unknown")
+	}
+
+	if _, ok := a.ParseErrors["broken.txt.stamp"]; !ok {
+		t.Errorf("ParseErrors should contain 'broken.txt.stamp', got %v", a.ParseErrors)
+	}
+	if len(a.ParseErrors) != 1 {
+		t.Errorf("ParseErrors should have 1 entry, got %d", len(a.ParseErrors))
+	}
+}
+
+// TestAnalyzeSheet_GuardedAndUnguarded tests that one unguarded use makes a variable required
+func TestAnalyzeSheet_GuardedAndUnguarded(t *testing.T) {
+	src := t.TempDir()
+	createTestFile(t, src, "a.stamp", "{{.name | default \"x\"}}")
+	createTestFile(t, src, "b.stamp", "{{.name}}")
+
+	a, err := AnalyzeSheet([]string{src}, ".stamp")
+	if err != nil {
+		t.Fatalf("AnalyzeSheet() failed: %v", err)
+	}
+
+	assertVarsEqual(t, a.RequiredNames(), []string{"name"})
+	assertVarsEqual(t, a.OptionalNames(), []string{})
+}
+
+// TestAnalyzeSheet_ReadsAll tests detecting templates that may read any variable
+func TestAnalyzeSheet_ReadsAll(t *testing.T) {
+	src := t.TempDir()
+	createTestFile(t, src, "dump.stamp", "{{range $k, $v := .}}{{$k}}={{$v}}{{end}}")
+	createTestFile(t, src, "port.stamp", `{{var (printf "%s_port" .service)}}`)
+	createTestFile(t, src, "items.stamp", "{{range .items}}{{.}}{{end}}{{with .db}}{{.host}}{{end}}")
+	createTestFile(t, src, "header.stamp", `{{define "h"}}{{.org}}{{end}}{{template "h" .}}`)
+
+	a, err := AnalyzeSheet([]string{src}, ".stamp")
+	if err != nil {
+		t.Fatalf("AnalyzeSheet() failed: %v", err)
+	}
+
+	assertVarsEqual(t, a.ReadsAll, []string{"dump.stamp", "port.stamp"})
+}
+
+// TestAnalyzeSheet_MultipleDirs tests that usage is merged across sheets
+func TestAnalyzeSheet_MultipleDirs(t *testing.T) {
+	base := t.TempDir()
+	extra := t.TempDir()
+	createTestFile(t, base, "a.stamp", "{{.name}}")
+	createTestFile(t, extra, "b.stamp", "{{.name}} {{.org}}")
+
+	a, err := AnalyzeSheet([]string{base, extra}, ".stamp")
+	if err != nil {
+		t.Fatalf("AnalyzeSheet() failed: %v", err)
+	}
+
+	assertVarsEqual(t, a.RequiredNames(), []string{"name", "org"})
+	assertVarsEqual(t, a.Required["name"], []string{"a.stamp", "b.stamp"})
+}
+
+func TestAnalyzeSheet_IgnoresComments(t *testing.T) {
+	src := t.TempDir()
+	createTestFile(t, src, "a.stamp", "{{- /* TODO: use .license */ -}}\n{{.name}}")
+
+	a, err := AnalyzeSheet([]string{src}, ".stamp")
+	if err != nil {
+		t.Fatalf("AnalyzeSheet() failed: %v", err)
+	}
+	assertVarsEqual(t, a.RequiredNames(), []string{"name"})
+
+	// Execution with only the uncommented variable succeeds end to end
+	if err := New(map[string]string{"name": "x"}, ".stamp").Execute(src, t.TempDir()); err != nil {
+		t.Errorf("Execute() failed: %v", err)
+	}
+}
+
+// TestAnalyzeSheet_PathVariables tests that variables in file and directory names are required
+func TestAnalyzeSheet_PathVariables(t *testing.T) {
+	src := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(src, "{{.pkg}}"), 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	createTestFile(t, src, filepath.Join("{{.pkg}}", "main.go"), "package main")
+
+	a, err := AnalyzeSheet([]string{src}, ".stamp")
+	if err != nil {
+		t.Fatalf("AnalyzeSheet() failed: %v", err)
+	}
+
+	assertVarsEqual(t, a.RequiredNames(), []string{"pkg"})
+	assertVarsEqual(t, a.Required["pkg"], []string{"{{.pkg}}"})
+}
+
+// TestAnalyze_RequiredAtAndOnly tests the use locations validation reports, and that
+// WithOnly limits the analysis to the selected files and the directories above them
+func TestAnalyze_RequiredAtAndOnly(t *testing.T) {
+	src := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(src, "{{.pkg}}"), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	createTestFile(t, filepath.Join(src, "{{.pkg}}"), "main.go.stamp", "package {{.pkg}}\n\n// {{.owner}} {{.owner}}\n")
+	createTestFile(t, filepath.Join(src, "{{.pkg}}"), "util.go.stamp", "{{.util}}")
+	createTestFile(t, src, "README.md.stamp", "{{.readme}}")
+
+	a, err := New(nil, ".stamp").Analyze([]string{src})
+	if err != nil {
+		t.Fatalf("Analyze() failed: %v", err)
+	}
+	want := map[string][]string{
+		"pkg":    {"{{.pkg}}", "{{.pkg}}/main.go.stamp:1"},
+		"owner":  {"{{.pkg}}/main.go.stamp:3"},
+		"util":   {"{{.pkg}}/util.go.stamp:1"},
+		"readme": {"README.md.stamp:1"},
+	}
+	if !reflect.DeepEqual(a.RequiredAt, want) {
+		t.Errorf("RequiredAt = %v, want %v", a.RequiredAt, want)
+	}
+
+	a, err = New(map[string]string{"pkg": "app"}, ".stamp", WithOnly([]string{"main.go"})).Analyze([]string{src})
+	if err != nil {
+		t.Fatalf("Analyze() failed: %v", err)
+	}
+	assertVarsEqual(t, a.RequiredNames(), []string{"owner", "pkg"})
+}
@@ -0,0 +1,128 @@
+package stamp
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/monochromegane/stamp/internal/config"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// AttributesFile is the gitattributes-like file at a sheet root
+// It maps output path patterns to per-file attributes and is never written to the destination
+const AttributesFile = ".stampattributes"
+
+// attributeRule is a single pattern line from an attributes file
+type attributeRule struct {
+	pattern string
+	attrs   map[string]string
+}
+
+// attributes holds the rules of one sheet in file order
+type attributes []attributeRule
+
+// loadAttributes reads the attributes file from a sheet directory
+// A missing file yields no rules
+func loadAttributes(sheetDir string) (attributes, error) {
+	f, err := os.Open(filepath.Join(sheetDir, AttributesFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", AttributesFile, err)
+	}
+	defer f.Close()
+
+	var rules attributes
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("%s:%d: pattern %q has no attributes", AttributesFile, lineNo, fields[0])
+		}
+
+		rule := attributeRule{pattern: fields[0], attrs: make(map[string]string)}
+		for _, field := range fields[1:] {
+			key, value, _ := strings.Cut(field, "=")
+			rule.attrs[key] = value
+		}
+		rules = append(rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", AttributesFile, err)
+	}
+	return rules, nil
+}
+
+// lookup returns the value of attr for a slash-separated output path
+// As with gitattributes, later matching lines override earlier ones,
+// and patterns without a slash match the base name at any depth
+func (a attributes) lookup(relPath, attr string) (string, bool) {
+	value, found := "", false
+	for _, rule := range a {
+		v, ok := rule.attrs[attr]
+		if !ok || !config.MatchPath(rule.pattern, relPath) {
+			continue
+		}
+		value, found = v, true
+	}
+	return value, found
+}
+
+// outputEncoding returns the encoding declared for relPath, or nil for UTF-8
+func (a attributes) outputEncoding(relPath string) (encoding.Encoding, error) {
+	name, ok := a.lookup(relPath, "encoding")
+	if !ok || name == "" || strings.EqualFold(name, "utf-8") {
+		return nil, nil
+	}
+	enc, err := htmlindex.Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown encoding %q for %s", name, relPath)
+	}
+	return enc, nil
+}
+
+// Line ending policies for the eol attribute and WithLineEndings
+const (
+	LineEndingsKeep = "keep"
+	LineEndingsLF   = "lf"
+	LineEndingsCRLF = "crlf"
+)
+
+// lineEndings returns the line ending policy for relPath
+// A matching eol attribute overrides the run-wide policy
+func (a attributes) lineEndings(relPath, global string) (string, error) {
+	eol, ok := a.lookup(relPath, "eol")
+	if !ok {
+		return global, nil
+	}
+	switch strings.ToLower(eol) {
+	case LineEndingsLF, LineEndingsCRLF, LineEndingsKeep:
+		return strings.ToLower(eol), nil
+	}
+	return "", fmt.Errorf("unknown eol %q for %s: want lf, crlf or keep", eol, relPath)
+}
+
+// convertLineEndings rewrites every line ending in content to the given policy
+// Content containing a NUL byte is treated as binary and left unchanged
+func convertLineEndings(content []byte, eol string) []byte {
+	if eol != LineEndingsLF && eol != LineEndingsCRLF {
+		return content
+	}
+	if IsBinary(content) {
+		return content
+	}
+	content = bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n"))
+	if eol == LineEndingsCRLF {
+		content = bytes.ReplaceAll(content, []byte("\n"), []byte("\r\n"))
+	}
+	return content
+}
@@ -0,0 +1,155 @@
+package stamp
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestExecute_ShiftJISEncoding tests transcoding rendered output to Shift_JIS
+func TestExecute_ShiftJISEncoding(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+
+	createTestFile(t, src, AttributesFile, "*.txt encoding=Shift_JIS\n")
+	createTestFile(t, src, "greeting.txt.stamp", "{{.word}}")
+	createTestFile(t, src, "utf8.md.stamp", "{{.word}}")
+
+	stamper := New(map[string]string{"word": "こんにちは"}, ".stamp")
+	if err := stamper.Execute(src, dest); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dest, "greeting.txt"))
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	want := []byte{0x82, 0xb1, 0x82, 0xf1, 0x82, 0xc9, 0x82, 0xbf, 0x82, 0xcd}
+	if !bytes.Equal(got, want) {
+		t.Errorf("greeting.txt bytes = % x, want % x", got, want)
+	}
+
+	// Files without a matching rule stay UTF-8
+	assertFileContent(t, filepath.Join(dest, "utf8.md"), "こんにちは")
+
+	// The attributes file itself is not emitted
+	assertFileNotExists(t, filepath.Join(dest, AttributesFile))
+}
+
+// TestExecute_UnknownEncoding tests that an unknown encoding name is reported
+func TestExecute_UnknownEncoding(t *testing.T) {
+	src := t.TempDir()
+	createTestFile(t, src, AttributesFile, "*.txt encoding=klingon\n")
+	createTestFile(t, src, "a.txt.stamp", "a")
+
+	err := New(nil, ".stamp").Execute(src, t.TempDir())
+	if err == nil {
+		t.Fatal("Execute() should fail for an unknown encoding")
+	}
+}
+
+// TestAttributes_Lookup tests pattern matching and override order
+func TestAttributes_Lookup(t *testing.T) {
+	src := t.TempDir()
+	createTestFile(t, src, AttributesFile, "# comment\n*.txt encoding=euc-jp\nlegacy/*.txt encoding=shift_jis\n")
+
+	attrs, err := loadAttributes(src)
+	if err != nil {
+		t.Fatalf("loadAttributes() failed: %v", err)
+	}
+
+	tests := map[string]string{
+		"a.txt":          "euc-jp",
+		"docs/a.txt":     "euc-jp",
+		"legacy/a.txt":   "shift_jis",
+		"legacy/b/a.txt": "euc-jp",
+	}
+	for relPath, want := range tests {
+		got, ok := attrs.lookup(relPath, "encoding")
+		if !ok || got != want {
+			t.Errorf("lookup(%q) = %q, %v, want %q", relPath, got, ok, want)
+		}
+	}
+
+	if _, ok := attrs.lookup("a.md", "encoding"); ok {
+		t.Error("lookup(a.md) should not match")
+	}
+}
+
+// TestExecute_LineEndingAttributes tests per-path eol overriding the run-wide policy
+func TestExecute_LineEndingAttributes(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+
+	createTestFile(t, src, AttributesFile, "*.sh eol=lf\n*.bat eol=crlf\n")
+	createTestFile(t, src, "run.sh.stamp", "#!/bin/sh\r\necho {{.name}}\r\n")
+	createTestFile(t, src, "run.bat", "@echo off\necho hi\n")
+	createTestFile(t, src, "notes.txt", "a\nb\r\n")
+	createTestFile(t, src, "blob.bin", "a\x00\nb\n")
+
+	stamper := New(map[string]string{"name": "app"}, ".stamp", WithLineEndings(LineEndingsCRLF))
+	if err := stamper.Execute(src, dest); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	assertFileContent(t, filepath.Join(dest, "run.sh"), "#!/bin/sh\necho app\n")
+	assertFileContent(t, filepath.Join(dest, "run.bat"), "@echo off\r\necho hi\r\n")
+	// Files without a rule follow the run-wide policy; binary files are untouched
+	assertFileContent(t, filepath.Join(dest, "notes.txt"), "a\r\nb\r\n")
+	assertFileContent(t, filepath.Join(dest, "blob.bin"), "a\x00\nb\n")
+}
+
+// TestExecute_UnknownEOL tests that an invalid eol value is reported
+func TestExecute_UnknownEOL(t *testing.T) {
+	src := t.TempDir()
+	createTestFile(t, src, AttributesFile, "*.txt eol=cr\n")
+	createTestFile(t, src, "a.txt", "a")
+
+	if err := New(nil, ".stamp").Execute(src, t.TempDir()); err == nil {
+		t.Fatal("Execute() should fail for an unknown eol")
+	}
+}
+
+// TestExecute_LineEndingAttributesOutputPrefix tests that slash eol patterns match paths below the output prefix
+func TestExecute_LineEndingAttributesOutputPrefix(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+
+	createTestFile(t, src, AttributesFile, "scripts/*.bat eol=crlf\n")
+	os.MkdirAll(filepath.Join(src, "scripts"), 0755)
+	createTestFile(t, filepath.Join(src, "scripts"), "run.bat", "@echo off\n")
+	createTestFile(t, filepath.Join(src, "scripts"), "build.bat.stamp", "echo {{.name}}\n")
+
+	stamper := New(map[string]string{"name": "app"}, ".stamp", WithOutputPrefix("{{.name}}"))
+	if err := stamper.Execute(src, dest); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	assertFileContent(t, filepath.Join(dest, "app", "scripts", "run.bat"), "@echo off\r\n")
+	assertFileContent(t, filepath.Join(dest, "app", "scripts", "build.bat"), "echo app\r\n")
+}
+
+// TestExecute_EncodingOutputPrefix tests that slash encoding patterns match paths below the output prefix
+func TestExecute_EncodingOutputPrefix(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+
+	createTestFile(t, src, AttributesFile, "legacy/*.txt encoding=Shift_JIS\n")
+	os.MkdirAll(filepath.Join(src, "legacy"), 0755)
+	createTestFile(t, filepath.Join(src, "legacy"), "greeting.txt.stamp", "{{.word}}")
+
+	stamper := New(map[string]string{"word": "こんにちは"}, ".stamp", WithOutputPrefix("out"))
+	if err := stamper.Execute(src, dest); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dest, "out", "legacy", "greeting.txt"))
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	want := []byte{0x82, 0xb1, 0x82, 0xf1, 0x82, 0xc9, 0x82, 0xbf, 0x82, 0xcd}
+	if !bytes.Equal(got, want) {
+		t.Errorf("greeting.txt bytes = % x, want % x", got, want)
+	}
+}
@@ -0,0 +1,14 @@
+package stamp
+
+import (
+	"bytes"
+	"errors"
+)
+
+// ErrBinaryTemplate is returned for a file with the template extension whose content looks binary
+var ErrBinaryTemplate = errors.New("binary file has template extension")
+
+// IsBinary reports whether content looks binary, using the NUL byte heuristic of git and diff
+func IsBinary(content []byte) bool {
+	return bytes.IndexByte(content, 0) >= 0
+}
@@ -0,0 +1,84 @@
+package stamp
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var pngHeader = "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR{{"
+
+// TestExecute_BinaryTemplate tests that a binary file with the template extension
+// fails validation with a clear message before anything is written
+func TestExecute_BinaryTemplate(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+	createTestFile(t, src, "logo.png.stamp", pngHeader)
+	createTestFile(t, src, "README.md", "readme")
+
+	err := New(nil, ".stamp").Execute(src, dest)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Execute() error = %v, want ValidationError", err)
+	}
+	if got := validationErr.ParseErrors["logo.png.stamp"]; got != ErrBinaryTemplate.Error() {
+		t.Errorf("ParseErrors[logo.png.stamp] = %q, want %q", got, ErrBinaryTemplate.Error())
+	}
+	assertFileNotExists(t, filepath.Join(dest, "README.md"))
+}
+
+// TestExecute_CopyBinary tests that WithCopyBinary copies binary templates verbatim under their output name
+func TestExecute_CopyBinary(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+	createTestFile(t, src, "logo.png.stamp", pngHeader)
+	createTestFile(t, src, "name.txt.stamp", "{{.name}}")
+
+	stamper := New(map[string]string{"name": "app"}, ".stamp", WithCopyBinary(true))
+	result, err := stamper.ExecuteMultiple([]string{src}, dest)
+	if err != nil {
+		t.Fatalf("ExecuteMultiple() failed: %v", err)
+	}
+	assertFileContent(t, filepath.Join(dest, "logo.png"), pngHeader)
+	assertFileContent(t, filepath.Join(dest, "name.txt"), "app")
+	if result.Copied != 1 || result.Templated != 1 {
+		t.Errorf("result = %s, want 1 copied and 1 templated", result)
+	}
+}
+
+// TestProcessTemplate_Binary tests that processTemplate refuses binary content without parsing it
+func TestProcessTemplate_Binary(t *testing.T) {
+	src := createTestFile(t, t.TempDir(), "logo.png.stamp", pngHeader)
+	dest := t.TempDir()
+
+	stamper := New(nil, ".stamp")
+	stamper.dest = dest
+	err := stamper.processTemplate(src, filepath.Join(dest, "logo.png.stamp"))
+	if !errors.Is(err, ErrBinaryTemplate) || !strings.Contains(err.Error(), "logo.png.stamp") {
+		t.Fatalf("processTemplate() error = %v, want ErrBinaryTemplate naming the file", err)
+	}
+	assertFileNotExists(t, filepath.Join(dest, "logo.png"))
+}
+
+// TestAnalyze_BinaryTemplate tests that analysis reports binary templates unless they are copied
+func TestAnalyze_BinaryTemplate(t *testing.T) {
+	src := t.TempDir()
+	createTestFile(t, src, "logo.png.stamp", pngHeader)
+
+	analysis, err := New(nil, ".stamp").Analyze([]string{src})
+	if err != nil {
+		t.Fatalf("Analyze() failed: %v", err)
+	}
+	if !errors.Is(analysis.ParseErrors["logo.png.stamp"], ErrBinaryTemplate) {
+		t.Errorf("ParseErrors[logo.png.stamp] = %v, want ErrBinaryTemplate", analysis.ParseErrors["logo.png.stamp"])
+	}
+
+	analysis, err = New(nil, ".stamp", WithCopyBinary(true)).Analyze([]string{src})
+	if err != nil {
+		t.Fatalf("Analyze() failed: %v", err)
+	}
+	if len(analysis.ParseErrors) != 0 {
+		t.Errorf("ParseErrors = %v, want none with WithCopyBinary", analysis.ParseErrors)
+	}
+}
@@ -0,0 +1,105 @@
+package stamp
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/template/parse"
+)
+
+// DumpParseTree writes the parse tree of a template file and the variables
+// validation extracts from it, to explain why a variable is or isn't required
+// The file is parsed with the delimiters pressing would use for it
+func (s *Stamper) DumpParseTree(w io.Writer, templatePath string) error {
+	leftDelim, rightDelim := s.delimsFor(templatePath)
+	usage, err := analyzeTemplate(templatePath, leftDelim, rightDelim)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Parse tree of %s:\n", templatePath)
+	if usage.tree.Root != nil {
+		dumpNode(w, usage.tree.Root, 1)
+	}
+
+	vars := make([]string, 0, len(usage.required)+len(usage.guarded))
+	for v := range usage.required {
+		vars = append(vars, v)
+	}
+	for v := range usage.guarded {
+		if _, required := usage.required[v]; !required {
+			vars = append(vars, v)
+		}
+	}
+	sort.Strings(vars)
+
+	fmt.Fprintf(w, "Variables:\n")
+	if len(vars) == 0 {
+		fmt.Fprintf(w, "  (none)\n")
+	}
+	for _, v := range vars {
+		if _, required := usage.required[v]; !required {
+			fmt.Fprintf(w, "  %s (optional, guarded by default)\n", v)
+			continue
+		}
+		fmt.Fprintf(w, "  %s\n", v)
+	}
+	return nil
+}
+
+// dumpNode writes a node and its children indented by depth
+func dumpNode(w io.Writer, node parse.Node, depth int) {
+	if node == nil {
+		return
+	}
+	indent := strings.Repeat("  ", depth)
+	name := strings.TrimPrefix(fmt.Sprintf("%T", node), "*parse.")
+
+	switch n := node.(type) {
+	case *parse.ListNode:
+		fmt.Fprintf(w, "%s%s\n", indent, name)
+		for _, child := range n.Nodes {
+			dumpNode(w, child, depth+1)
+		}
+	case *parse.ActionNode:
+		fmt.Fprintf(w, "%s%s (line %d)\n", indent, name, n.Line)
+		dumpNode(w, n.Pipe, depth+1)
+	case *parse.PipeNode:
+		fmt.Fprintf(w, "%s%s\n", indent, name)
+		for _, decl := range n.Decl {
+			dumpNode(w, decl, depth+1)
+		}
+		for _, cmd := range n.Cmds {
+			dumpNode(w, cmd, depth+1)
+		}
+	case *parse.CommandNode:
+		fmt.Fprintf(w, "%s%s\n", indent, name)
+		for _, arg := range n.Args {
+			dumpNode(w, arg, depth+1)
+		}
+	case *parse.IfNode:
+		dumpBranch(w, name, &n.BranchNode, depth)
+	case *parse.RangeNode:
+		dumpBranch(w, name, &n.BranchNode, depth)
+	case *parse.WithNode:
+		dumpBranch(w, name, &n.BranchNode, depth)
+	case *parse.TemplateNode:
+		fmt.Fprintf(w, "%s%s %q (line %d)\n", indent, name, n.Name, n.Line)
+		dumpNode(w, n.Pipe, depth+1)
+	default:
+		fmt.Fprintf(w, "%s%s %s\n", indent, name, strings.TrimSpace(fmt.Sprintf("%q", node.String())))
+	}
+}
+
+// dumpBranch writes an if, range or with node with its branches
+func dumpBranch(w io.Writer, name string, branch *parse.BranchNode, depth int) {
+	indent := strings.Repeat("  ", depth)
+	fmt.Fprintf(w, "%s%s (line %d)\n", indent, name, branch.Line)
+	dumpNode(w, branch.Pipe, depth+1)
+	dumpNode(w, branch.List, depth+1)
+	if branch.ElseList != nil {
+		fmt.Fprintf(w, "%s  else\n", indent)
+		dumpNode(w, branch.ElseList, depth+1)
+	}
+}
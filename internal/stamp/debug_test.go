@@ -0,0 +1,56 @@
+package stamp
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestDumpParseTree tests the dump of a nested template and its variables
+func TestDumpParseTree(t *testing.T) {
+	dir := t.TempDir()
+	tmplPath := createTestFile(t, dir, "nested.stamp",
+		"{{if .enabled}}{{range .items}}{{.}}{{end}}{{else}}{{.fallback | printf \"%s\"}}{{end}}")
+
+	var buf bytes.Buffer
+	if err := New(nil, ".stamp").DumpParseTree(&buf, tmplPath); err != nil {
+		t.Fatalf("DumpParseTree() failed: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"  ListNode\n",
+		"    IfNode (line 1)\n",
+		"RangeNode (line 1)",
+		`FieldNode ".fallback"`,
+		`IdentifierNode "printf"`,
+		"Variables:\n  enabled\n  fallback\n  items\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("dump missing %q:\n%s", want, out)
+		}
+	}
+}
+
+// TestDumpParseTree_Delims tests that the dump uses the delimiters pressing would use
+func TestDumpParseTree_Delims(t *testing.T) {
+	dir := t.TempDir()
+	square := createTestFile(t, dir, "main.tf.stamp-sq", `name = "[[.name]]" {{ not a var }}`)
+	custom := createTestFile(t, dir, "page.html.stamp", "<% .title | default \"x\" %> {{.ignored}}")
+
+	var buf bytes.Buffer
+	if err := New(nil, ".stamp").DumpParseTree(&buf, square); err != nil {
+		t.Fatalf("DumpParseTree() failed: %v", err)
+	}
+	if !strings.HasSuffix(buf.String(), "Variables:\n  name\n") {
+		t.Errorf("square-bracket dump = %q, want only name", buf.String())
+	}
+
+	buf.Reset()
+	if err := New(nil, ".stamp", WithDelims("<%", "%>")).DumpParseTree(&buf, custom); err != nil {
+		t.Fatalf("DumpParseTree() failed: %v", err)
+	}
+	if !strings.HasSuffix(buf.String(), "Variables:\n  title (optional, guarded by default)\n") {
+		t.Errorf("custom delimiter dump = %q, want only title", buf.String())
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package stamp
+
+// checkWritable is not supported on this platform; write failures are reported per file
+func checkWritable(dir string) error {
+	return nil
+}
@@ -0,0 +1,10 @@
+//go:build unix
+
+package stamp
+
+import "syscall"
+
+// checkWritable reports whether the current user may create entries in dir
+func checkWritable(dir string) error {
+	return syscall.Access(dir, 0x2) // W_OK
+}
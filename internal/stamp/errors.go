@@ -0,0 +1,47 @@
+package stamp
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+)
+
+// ErrExists is returned when a destination file already exists and may not be overwritten
+var ErrExists = errors.New("destination file already exists")
+
+// ErrNotDirectory is returned when the destination, or one of its parents, exists but is not a directory
+var ErrNotDirectory = errors.New("destination is not a directory")
+
+// WriteError reports a destination path that could not be written
+// It unwraps to the underlying error, so errors.Is(err, fs.ErrPermission) works
+type WriteError struct {
+	Path string // Absolute destination path
+	Err  error
+}
+
+func (e *WriteError) Error() string {
+	// The path is already part of the message, so drop it from *fs.PathError
+	cause := e.Err
+	var pathErr *fs.PathError
+	if errors.As(cause, &pathErr) {
+		cause = pathErr.Err
+	}
+
+	if errors.Is(e.Err, fs.ErrPermission) {
+		return fmt.Sprintf("permission denied writing %s: %v (check the permissions of the file and its directory)", e.Path, cause)
+	}
+	return fmt.Sprintf("cannot write %s: %v", e.Path, cause)
+}
+
+func (e *WriteError) Unwrap() error {
+	return e.Err
+}
+
+// newWriteError wraps err with the absolute form of path
+func newWriteError(path string, err error) *WriteError {
+	if abs, absErr := filepath.Abs(path); absErr == nil {
+		path = abs
+	}
+	return &WriteError{Path: path, Err: err}
+}
@@ -0,0 +1,112 @@
+package stamp
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestExecute_ReadOnlyDestination tests that an unwritable destination fails before any work
+func TestExecute_ReadOnlyDestination(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("permission checks do not apply to root")
+	}
+
+	src := t.TempDir()
+	dest := t.TempDir()
+	createTestFile(t, src, "locked.txt", "content")
+	if err := os.Chmod(dest, 0555); err != nil {
+		t.Fatalf("failed to make dest read-only: %v", err)
+	}
+	t.Cleanup(func() { os.Chmod(dest, 0755) })
+
+	// Also reported for a destination to be created inside the read-only directory
+	for _, d := range []string{dest, filepath.Join(dest, "new", "dir")} {
+		err := New(nil, ".stamp").Execute(src, d)
+		if err == nil {
+			t.Fatalf("Execute(%s) should fail for a read-only destination", d)
+		}
+		if !errors.Is(err, fs.ErrPermission) {
+			t.Errorf("errors.Is(err, fs.ErrPermission) = false for %v", err)
+		}
+		if want := "destination " + dest + " is not writable: permission denied"; err.Error() != want {
+			t.Errorf("error = %q, want %q", err.Error(), want)
+		}
+	}
+}
+
+// TestExecute_FileDestination tests that a destination that is a file fails before any work
+func TestExecute_FileDestination(t *testing.T) {
+	src := t.TempDir()
+	createTestFile(t, src, "a.txt.stamp", "{{.name}}")
+	file := createTestFile(t, t.TempDir(), "dest", "not a directory")
+
+	for _, dest := range []string{file, filepath.Join(file, "sub")} {
+		// Missing variables are not reported; the destination is checked first
+		err := New(nil, ".stamp").Execute(src, dest)
+		if !errors.Is(err, ErrNotDirectory) {
+			t.Fatalf("Execute(%s) error = %v, want ErrNotDirectory", dest, err)
+		}
+		if want := "destination is not a directory: " + file; err.Error() != want {
+			t.Errorf("error = %q, want %q", err.Error(), want)
+		}
+	}
+}
+
+// TestExecute_WriteErrorIncludesPath tests that write failures carry the absolute path
+func TestExecute_WriteErrorIncludesPath(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+	createTestFile(t, src, "blocked.txt", "content")
+
+	// A directory where the file should go cannot be overwritten
+	if err := os.MkdirAll(filepath.Join(dest, "blocked.txt"), 0755); err != nil {
+		t.Fatalf("failed to create blocking dir: %v", err)
+	}
+
+	err := New(nil, ".stamp", WithOverwrite(OverwriteForce)).Execute(src, dest)
+	var writeErr *WriteError
+	if !errors.As(err, &writeErr) {
+		t.Fatalf("Execute() error = %v, want *WriteError", err)
+	}
+	if !filepath.IsAbs(writeErr.Path) || filepath.Base(writeErr.Path) != "blocked.txt" {
+		t.Errorf("WriteError.Path = %q, want absolute path to blocked.txt", writeErr.Path)
+	}
+}
+
+// TestExecute_KeepGoing tests that other files are written when one fails
+func TestExecute_KeepGoing(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+	createTestFile(t, src, "blocked.txt", "content")
+	createTestFile(t, src, "ok.txt", "ok")
+	if err := os.MkdirAll(filepath.Join(dest, "blocked.txt"), 0755); err != nil {
+		t.Fatalf("failed to create blocking dir: %v", err)
+	}
+
+	logger := &recordingLogger{}
+	result, err := New(nil, ".stamp", WithKeepGoing(true), WithLogger(logger), WithOverwrite(OverwriteForce)).ExecuteMultiple([]string{src}, dest)
+	if err != nil {
+		t.Fatalf("ExecuteMultiple() failed: %v", err)
+	}
+
+	assertFileContent(t, filepath.Join(dest, "ok.txt"), "ok")
+	if len(result.Failed) != 1 || filepath.Base(result.Failed[0].Path) != "blocked.txt" {
+		t.Errorf("Failed = %v, want blocked.txt", result.Failed)
+	}
+	if result.Skipped != 1 {
+		t.Errorf("Skipped = %d, want 1", result.Skipped)
+	}
+
+	var warned bool
+	for _, e := range logger.events {
+		if e.Event == EventWarning && e.Path == "blocked.txt" {
+			warned = true
+		}
+	}
+	if !warned {
+		t.Errorf("expected a warning for blocked.txt, got %+v", logger.events)
+	}
+}
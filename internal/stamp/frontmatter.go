@@ -0,0 +1,114 @@
+package stamp
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// frontMatterDelim opens and closes the front matter block at the top of a template
+const frontMatterDelim = "---"
+
+// frontMatterKeys are the keys a front matter block may set
+var frontMatterKeys = []string{"path", "skip_if"}
+
+// frontMatter is the rendered front matter of a template
+type frontMatter struct {
+	Path   string `yaml:"path"`    // Output path relative to the destination, replacing the file's own
+	SkipIf string `yaml:"skip_if"` // The file is skipped when this renders true
+}
+
+// splitFrontMatter separates the front matter block at the top of content from the body
+// The block is only recognized when every line in it sets one of frontMatterKeys, so YAML
+// templates that start with a "---" document marker are rendered as they are
+func splitFrontMatter(content []byte) (header, body []byte, ok bool) {
+	rest, found := bytes.CutPrefix(content, []byte(frontMatterDelim+"\n"))
+	if !found {
+		if rest, found = bytes.CutPrefix(content, []byte(frontMatterDelim+"\r\n")); !found {
+			return nil, content, false
+		}
+	}
+
+	for end := 0; end < len(rest); {
+		line, _, _ := bytes.Cut(rest[end:], []byte("\n"))
+		next := end + len(line) + 1
+		line = bytes.TrimSuffix(line, []byte("\r"))
+		if string(line) == frontMatterDelim {
+			return rest[:end], rest[min(next, len(rest)):], true
+		}
+		if !isFrontMatterLine(string(line)) {
+			return nil, content, false
+		}
+		end = next
+	}
+	return nil, content, false
+}
+
+// isFrontMatterLine reports whether line is blank, a comment, or sets a front matter key
+func isFrontMatterLine(line string) bool {
+	if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+		return true
+	}
+	key, _, found := strings.Cut(line, ":")
+	return found && slices.Contains(frontMatterKeys, key)
+}
+
+// readFrontMatter renders and parses the front matter of the template at srcPath
+// It returns nil when the template has none
+func (s *Stamper) readFrontMatter(srcPath string) (*frontMatter, error) {
+	content, err := os.ReadFile(srcPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template file: %w", err)
+	}
+	header, _, ok := splitFrontMatter(content)
+	if !ok {
+		return nil, nil
+	}
+
+	leftDelim, rightDelim := s.delimsFor(srcPath)
+	tmpl, err := s.newTemplate(filepath.Base(srcPath)).Delims(leftDelim, rightDelim).Parse(string(header))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse front matter of %s: %w", srcPath, err)
+	}
+	if s.strictKeys {
+		tmpl.Option("missingkey=error")
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, s.templateData); err != nil {
+		return nil, fmt.Errorf("failed to render front matter of %s: %w", srcPath, err)
+	}
+
+	front := &frontMatter{}
+	if err := yaml.UnmarshalWithOptions(buf.Bytes(), front, yaml.Strict()); err != nil {
+		return nil, fmt.Errorf("invalid front matter in %s: %w", srcPath, err)
+	}
+	return front, nil
+}
+
+// skip reports whether skip_if rendered true; an empty value never skips
+func (f *frontMatter) skip() (bool, error) {
+	value := strings.TrimSpace(f.SkipIf)
+	if value == "" {
+		return false, nil
+	}
+	skip, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("skip_if must render true or false, got %q", f.SkipIf)
+	}
+	return skip, nil
+}
+
+// skipFrontMatter records a template at destPath that its skip_if left out
+func (s *Stamper) skipFrontMatter(destPath string) {
+	relPath, _ := relSlashPath(s.dest, s.removeTemplateExtension(destPath))
+	if s.result != nil {
+		s.result.Skipped++
+	}
+	s.logger.Log(Event{Event: EventFileSkipped, Path: relPath, Sheet: s.sheet, Message: "skip_if"})
+}
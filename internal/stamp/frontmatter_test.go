@@ -0,0 +1,83 @@
+package stamp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestExecute_FrontMatterPath tests that a template relocates itself with path:
+func TestExecute_FrontMatterPath(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+	createTestFile(t, src, "main.go.stamp", "---\npath: cmd/{{.name}}/main.go\n---\npackage main // {{.name}}\n")
+
+	result, err := New(map[string]string{"name": "app"}, ".stamp").ExecuteMultiple([]string{src}, dest)
+	if err != nil {
+		t.Fatalf("ExecuteMultiple() failed: %v", err)
+	}
+	assertFileContent(t, filepath.Join(dest, "cmd", "app", "main.go"), "package main // app\n")
+	if _, err := os.Stat(filepath.Join(dest, "main.go")); !os.IsNotExist(err) {
+		t.Errorf("main.go should not be written at its own path, stat error = %v", err)
+	}
+	if len(result.Files) != 1 || result.Files[0].Path != "cmd/app/main.go" {
+		t.Errorf("result files = %v, want cmd/app/main.go", result.Files)
+	}
+
+	// The relocated path must stay inside the destination
+	createTestFile(t, src, "escape.txt.stamp", "---\npath: ../escape.txt\n---\nx")
+	if _, err := New(map[string]string{"name": "app"}, ".stamp", WithOverwrite(OverwriteForce)).ExecuteMultiple([]string{src}, t.TempDir()); err == nil || !strings.Contains(err.Error(), ErrUnsafePath.Error()) {
+		t.Errorf("ExecuteMultiple() error = %v, want ErrUnsafePath", err)
+	}
+}
+
+// TestExecute_FrontMatterSkipIf tests that skip_if leaves a template out when it renders true
+func TestExecute_FrontMatterSkipIf(t *testing.T) {
+	src := t.TempDir()
+	createTestFile(t, src, "Dockerfile.stamp", "---\nskip_if: {{eq .docker \"no\"}}\n---\nFROM {{.image}}\n")
+
+	dest := t.TempDir()
+	logger := &recordingLogger{}
+	result, err := New(map[string]string{"docker": "no", "image": "alpine"}, ".stamp", WithLogger(logger)).ExecuteMultiple([]string{src}, dest)
+	if err != nil {
+		t.Fatalf("ExecuteMultiple() failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "Dockerfile")); !os.IsNotExist(err) {
+		t.Errorf("Dockerfile should be skipped, stat error = %v", err)
+	}
+	if result.Skipped != 1 || !containsEvent(logger.events, Event{Event: EventFileSkipped, Path: "Dockerfile", Message: "skip_if"}) {
+		t.Errorf("Skipped = %d, events = %v; want a skip_if skip of Dockerfile", result.Skipped, logger.events)
+	}
+
+	dest = t.TempDir()
+	if _, err := New(map[string]string{"docker": "yes", "image": "alpine"}, ".stamp").ExecuteMultiple([]string{src}, dest); err != nil {
+		t.Fatalf("ExecuteMultiple() failed: %v", err)
+	}
+	assertFileContent(t, filepath.Join(dest, "Dockerfile"), "FROM alpine\n")
+}
+
+func TestSplitFrontMatter(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		header  string
+		body    string
+		ok      bool
+	}{
+		{"front matter", "---\npath: a.txt\nskip_if: false\n---\nbody\n", "path: a.txt\nskip_if: false\n", "body\n", true},
+		{"crlf", "---\r\npath: a.txt\r\n---\r\nbody", "path: a.txt\r\n", "body", true},
+		{"no body", "---\n# moved\npath: a.txt\n---", "# moved\npath: a.txt\n", "", true},
+		{"yaml document marker", "---\napiVersion: v1\n---\nkind: Pod\n", "", "---\napiVersion: v1\n---\nkind: Pod\n", false},
+		{"unterminated", "---\npath: a.txt\n", "", "---\npath: a.txt\n", false},
+		{"not at the top", "\n---\npath: a.txt\n---\n", "", "\n---\npath: a.txt\n---\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header, body, ok := splitFrontMatter([]byte(tt.content))
+			if string(header) != tt.header || string(body) != tt.body || ok != tt.ok {
+				t.Errorf("splitFrontMatter() = %q, %q, %v; want %q, %q, %v", header, body, ok, tt.header, tt.body, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package stamp
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+// templateFuncs returns the functions stamp provides to templates
+// Functions registered with WithFuncs are added to the builtin stamp set
+func (s *Stamper) templateFuncs() template.FuncMap {
+	funcs := template.FuncMap{
+		"var": s.lookupVar,
+	}
+	for name, fn := range s.funcs {
+		funcs[name] = fn
+	}
+	return funcs
+}
+
+// newTemplate creates an empty template with the Stamper's functions and delimiters
+func (s *Stamper) newTemplate(name string) *template.Template {
+	return template.New(name).Delims(s.leftDelim, s.rightDelim).Funcs(s.templateFuncs())
+}
+
+// WithFuncs registers additional template functions, e.g. WithFuncs(StringFuncs())
+// Functions named like a stamp function replace it
+func WithFuncs(funcs template.FuncMap) Option {
+	return func(s *Stamper) {
+		if s.funcs == nil {
+			s.funcs = make(template.FuncMap)
+		}
+		for name, fn := range funcs {
+			s.funcs[name] = fn
+		}
+	}
+}
+
+// StringFuncs returns the curated Sprig-style string helpers callers can opt into
+// Argument order follows Sprig, so {{.name | replace "-" "_"}} and {{.x | default "y"}} work
+// Typed variables such as numbers are formatted as strings first
+func StringFuncs() template.FuncMap {
+	return template.FuncMap{
+		"lower":     stringFunc(strings.ToLower),
+		"upper":     stringFunc(strings.ToUpper),
+		"title":     stringFunc(title),
+		"trim":      stringFunc(strings.TrimSpace),
+		"replace":   replace,
+		"snakecase": stringFunc(snakecase),
+		"camelcase": stringFunc(camelcase),
+		"default":   defaultValue,
+	}
+}
+
+// stringFunc adapts fn to accept any variable value, formatted as it would be rendered
+func stringFunc(fn func(string) string) func(any) string {
+	return func(v any) string {
+		return fn(fmt.Sprint(v))
+	}
+}
+
+// title upper-cases the first letter of each word
+func title(s string) string {
+	return cases.Title(language.Und, cases.NoLower).String(s)
+}
+
+// replace replaces every old in s with new
+func replace(old, new string, s any) string {
+	return strings.ReplaceAll(fmt.Sprint(s), old, new)
+}
+
+// snakecase converts s to snake_case, e.g. "HTTPServer" becomes "http_server"
+func snakecase(s string) string {
+	words := splitWords(s)
+	for i, w := range words {
+		words[i] = strings.ToLower(w)
+	}
+	return strings.Join(words, "_")
+}
+
+// camelcase converts s to CamelCase, e.g. "http_server" becomes "HttpServer"
+func camelcase(s string) string {
+	var sb strings.Builder
+	for _, w := range splitWords(s) {
+		runes := []rune(strings.ToLower(w))
+		runes[0] = unicode.ToUpper(runes[0])
+		sb.WriteString(string(runes))
+	}
+	return sb.String()
+}
+
+// defaultValue returns given unless it is absent or empty, and def otherwise
+// given is untyped so variables missing from the map reach the function as nil;
+// typed values such as false or 0 are provided and formatted as strings
+func defaultValue(def string, given any) string {
+	switch given := given.(type) {
+	case nil:
+		return def
+	case string:
+		if given == "" {
+			return def
+		}
+		return given
+	default:
+		return fmt.Sprint(given)
+	}
+}
+
+// splitWords splits s at non-alphanumeric characters and case boundaries
+// An upper-case run followed by a lower-case letter ends before its last letter, so
+// "HTTPServer" splits into "HTTP" and "Server"
+func splitWords(s string) []string {
+	var words []string
+	var current []rune
+	runes := []rune(s)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(current) > 0 {
+				words = append(words, string(current))
+				current = nil
+			}
+			continue
+		}
+		if len(current) > 0 && unicode.IsUpper(r) {
+			prev := current[len(current)-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if !unicode.IsUpper(prev) || nextLower {
+				words = append(words, string(current))
+				current = nil
+			}
+		}
+		current = append(current, r)
+	}
+	if len(current) > 0 {
+		words = append(words, string(current))
+	}
+	return words
+}
+
+// lookupVar returns the variable with a dynamically computed name
+// e.g. {{var (printf "%s_port" .service)}}
+// Because the name is only known at render time, such lookups bypass static validation
+// With WithStrictKeys a name that is not provided fails rendering, like a missing .field
+func (s *Stamper) lookupVar(name string) (string, error) {
+	value, ok := s.templateVars[name]
+	if !ok && s.strictKeys {
+		return "", fmt.Errorf("no entry for key %q", name)
+	}
+	return value, nil
+}
+
+// isKnownFunc reports whether name is a builtin or a function registered with the Stamper
+func (s *Stamper) isKnownFunc(name string) bool {
+	if _, ok := builtinFuncs[name]; ok {
+		return true
+	}
+	_, ok := s.templateFuncs()[name]
+	return ok
+}
@@ -0,0 +1,169 @@
+package stamp
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+// TestExecute_VarFunction tests resolving a dynamically named variable
+func TestExecute_VarFunction(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+	createTestFile(t, src, "port.txt.stamp", `{{var (printf "%s_port" .service)}}`)
+
+	vars := map[string]string{"service": "api", "api_port": "8080"}
+	if err := New(vars, ".stamp").Execute(src, dest); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	assertFileContent(t, filepath.Join(dest, "port.txt"), "8080")
+}
+
+// TestExecute_VarFunctionMissing tests that an absent dynamic variable renders empty
+func TestExecute_VarFunctionMissing(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+	createTestFile(t, src, "port.txt.stamp", `[{{var "db_port"}}]`)
+
+	if err := New(nil, ".stamp").Execute(src, dest); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	assertFileContent(t, filepath.Join(dest, "port.txt"), "[]")
+}
+
+// TestAnalyzeTemplate_VarFunction tests that dynamic lookups are not statically required
+func TestAnalyzeTemplate_VarFunction(t *testing.T) {
+	src := t.TempDir()
+	path := createTestFile(t, src, "port.txt.stamp", `{{var (printf "%s_port" .service)}}`)
+
+	vars, err := templateVars(path)
+	if err != nil {
+		t.Fatalf("templateVars() failed: %v", err)
+	}
+	assertVarsEqual(t, vars, []string{"service"})
+}
+
+// TestExecute_StringFuncs tests the opt-in string helpers
+func TestExecute_StringFuncs(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+	createTestFile(t, src, "out.txt.stamp",
+		`{{.name | upper}} {{.name | lower}} {{.words | title}} [{{.padded | trim}}] {{.pkg | replace "-" "_"}} {{.owner | default "nobody"}} {{.missing | default "n/a"}}`)
+
+	vars := map[string]string{"name": "Stamp", "words": "hello world", "padded": "  x  ", "pkg": "my-pkg", "owner": ""}
+	if err := New(vars, ".stamp", WithFuncs(StringFuncs())).Execute(src, dest); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	assertFileContent(t, filepath.Join(dest, "out.txt"), "STAMP stamp Hello World [x] my_pkg nobody n/a")
+}
+
+// TestExecute_StringFuncsTypedValues tests that the string helpers accept typed variables
+func TestExecute_StringFuncsTypedValues(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+	createTestFile(t, src, "out.txt.stamp",
+		`{{.debug | upper}} {{.version | replace "." "_"}} {{.port | default "80"}} {{.verbose | default "on"}}`)
+
+	vars := map[string]string{"debug": "false", "version": "1.5", "port": "8080", "verbose": "false"}
+	if err := New(vars, ".stamp", WithFuncs(StringFuncs()), WithTypedVars(true)).Execute(src, dest); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	assertFileContent(t, filepath.Join(dest, "out.txt"), "FALSE 1_5 8080 false")
+}
+
+// TestExecute_StringFuncsOptIn tests that the string helpers are not registered by default
+func TestExecute_StringFuncsOptIn(t *testing.T) {
+	src := t.TempDir()
+	createTestFile(t, src, "out.txt.stamp", "{{.name | upper}}")
+
+	if err := New(map[string]string{"name": "x"}, ".stamp").Execute(src, t.TempDir()); err == nil {
+		t.Fatal("Execute() should fail for a function that was not registered")
+	}
+}
+
+// TestExecute_WithFuncs tests registering a custom function
+func TestExecute_WithFuncs(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+	createTestFile(t, src, "out.txt.stamp", "{{shout .name}}")
+
+	funcs := template.FuncMap{"shout": func(s string) string { return s + "!" }}
+	if err := New(map[string]string{"name": "hi"}, ".stamp", WithFuncs(funcs)).Execute(src, dest); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	assertFileContent(t, filepath.Join(dest, "out.txt"), "hi!")
+}
+
+// TestCaseFuncs tests word splitting for snakecase and camelcase
+func TestCaseFuncs(t *testing.T) {
+	tests := []struct {
+		in, snake, camel string
+	}{
+		{"HTTPServer", "http_server", "HttpServer"},
+		{"firstName", "first_name", "FirstName"},
+		{"my-cool_app", "my_cool_app", "MyCoolApp"},
+		{"api v2", "api_v2", "ApiV2"},
+		{"", "", ""},
+	}
+
+	for _, tt := range tests {
+		if got := snakecase(tt.in); got != tt.snake {
+			t.Errorf("snakecase(%q) = %q, want %q", tt.in, got, tt.snake)
+		}
+		if got := camelcase(tt.in); got != tt.camel {
+			t.Errorf("camelcase(%q) = %q, want %q", tt.in, got, tt.camel)
+		}
+	}
+}
+
+// TestExecute_CustomDelims tests rendering contents, paths and validation with alternative delimiters
+func TestExecute_CustomDelims(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+	createTestFile(t, src, "[[.pkg]].yaml.stamp", "name: [[.name | upper]]\nhelm: {{ .Values.image }}\n")
+
+	vars := map[string]string{"name": "app", "pkg": "chart"}
+	stamper := New(vars, ".stamp", WithFuncs(StringFuncs()), WithDelims("[[", "]]"))
+	if err := stamper.Execute(src, dest); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	assertFileContent(t, filepath.Join(dest, "chart.yaml"), "name: APP\nhelm: {{ .Values.image }}\n")
+
+	// Validation parses with the same delimiters
+	err := New(map[string]string{"pkg": "chart"}, ".stamp", WithFuncs(StringFuncs()), WithDelims("[[", "]]")).Validate([]string{src})
+	if err == nil || !strings.Contains(err.Error(), "  - name\n") {
+		t.Errorf("Validate() error = %v, want missing name", err)
+	}
+}
+
+// TestExecute_SquareDelimsSuffix tests mixing default and [[ ]] templates in one sheet
+func TestExecute_SquareDelimsSuffix(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+	createTestFile(t, src, "main.go.stamp", "package {{.pkg}}")
+	createTestFile(t, src, "main.tf.stamp-sq", "name = \"[[.name]]\"\ntags = \"{{ .tags }}\"")
+
+	vars := map[string]string{"pkg": "app", "name": "web"}
+	if err := New(vars, ".stamp").Execute(src, dest); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	assertFileContent(t, filepath.Join(dest, "main.go"), "package app")
+	assertFileContent(t, filepath.Join(dest, "main.tf"), "name = \"web\"\ntags = \"{{ .tags }}\"")
+
+	// Each file is validated with its own delimiters, so tags is not required
+	err := New(map[string]string{"pkg": "app"}, ".stamp").Validate([]string{src})
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Validate() error = %v, want *ValidationError", err)
+	}
+	if len(validationErr.MissingVars) != 1 || validationErr.MissingVars["name"] == nil {
+		t.Errorf("MissingVars = %v, want only name", validationErr.MissingVars)
+	}
+}
@@ -0,0 +1,75 @@
+package stamp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Event names emitted during a run
+const (
+	EventSheetResolved    = "sheet_resolved"
+	EventValidationPassed = "validation_passed"
+	EventFileWritten      = "file_written"
+	EventFileSkipped      = "file_skipped"
+	EventWarning          = "warning"
+)
+
+// Event describes something significant that happened during a run
+type Event struct {
+	Event   string `json:"event"`
+	Path    string `json:"path,omitempty"`
+	Action  string `json:"action,omitempty"`
+	Sheet   string `json:"sheet,omitempty"`
+	Message string `json:"message,omitempty"`
+
+	Overwrote string `json:"overwrote,omitempty"` // Earlier sheet whose file was replaced
+}
+
+// Logger receives events from a run
+type Logger interface {
+	Log(e Event)
+}
+
+// nopLogger discards all events
+type nopLogger struct{}
+
+func (nopLogger) Log(Event) {}
+
+// TextLogger writes human-readable warnings and ignores other events
+type TextLogger struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewTextLogger creates a TextLogger writing to w
+func NewTextLogger(w io.Writer) *TextLogger {
+	return &TextLogger{w: w}
+}
+
+func (l *TextLogger) Log(e Event) {
+	if e.Event != EventWarning {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintf(l.w, "Warning: %s\n", e.Message)
+}
+
+// JSONLogger writes every event as a JSON line
+type JSONLogger struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewJSONLogger creates a JSONLogger writing to w
+func NewJSONLogger(w io.Writer) *JSONLogger {
+	return &JSONLogger{enc: json.NewEncoder(w)}
+}
+
+func (l *JSONLogger) Log(e Event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.enc.Encode(e)
+}
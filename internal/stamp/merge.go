@@ -0,0 +1,268 @@
+package stamp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// Merge strategies for a path written by more than one sheet in a run
+const (
+	MergeOverwrite = "overwrite" // The last sheet's file replaces earlier ones
+	MergeTypeAware = "merge"     // Known file types are merged, others are overwritten
+)
+
+// Mergers selectable with the merge attribute in .stampattributes
+const (
+	mergerLines     = "lines"
+	mergerJSON      = "json"
+	mergerYAML      = "yaml"
+	mergerOverwrite = "overwrite"
+)
+
+// mergeFunc combines the content written by an earlier sheet with a later one
+type mergeFunc func(base, overlay []byte) ([]byte, error)
+
+// WithMergeStrategy sets how a path written by an earlier sheet in the same run is handled
+// Files that existed before the run are never merged; the overwrite policy applies to them
+func WithMergeStrategy(strategy string) Option {
+	return func(s *Stamper) {
+		s.mergeStrategy = strategy
+	}
+}
+
+// merger returns the mergeFunc for a destination path, or nil to overwrite
+// Merging only applies to paths already written earlier in this run
+func (s *Stamper) merger(destPath, relPath string) (mergeFunc, error) {
+	// A link written by an earlier sheet is replaced, never merged through
+	if s.mergeStrategy != MergeTypeAware || s.written[destPath] == "" || isSymlink(destPath) {
+		return nil, nil
+	}
+
+	name, ok := s.attrs.lookup(relPath, "merge")
+	if !ok {
+		name = defaultMerger(relPath)
+	}
+	switch name {
+	case mergerLines:
+		return mergeLines, nil
+	case mergerJSON:
+		return mergeJSON, nil
+	case mergerYAML:
+		return mergeYAML, nil
+	case mergerOverwrite, "":
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unknown merge attribute %q for %s", name, relPath)
+}
+
+// defaultMerger picks a merger from the file name
+func defaultMerger(relPath string) string {
+	base := path.Base(relPath)
+	switch {
+	case strings.HasPrefix(base, ".") && strings.HasSuffix(base, "ignore"):
+		return mergerLines
+	case path.Ext(base) == ".json":
+		return mergerJSON
+	case path.Ext(base) == ".yaml" || path.Ext(base) == ".yml":
+		return mergerYAML
+	}
+	return mergerOverwrite
+}
+
+// mergeExisting merges content with the file already at destPath
+func mergeExisting(destPath, relPath string, content []byte, merge mergeFunc) ([]byte, error) {
+	base, err := os.ReadFile(destPath)
+	if err != nil {
+		return nil, newWriteError(destPath, err)
+	}
+	merged, err := merge(base, content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge %s: %w", relPath, err)
+	}
+	return merged, nil
+}
+
+// mergeLines keeps every line of base and appends overlay lines it does not already contain
+func mergeLines(base, overlay []byte) ([]byte, error) {
+	seen := make(map[string]bool)
+	var out bytes.Buffer
+	for _, line := range splitLines(base) {
+		seen[line] = true
+		out.WriteString(line + "\n")
+	}
+	for _, line := range splitLines(overlay) {
+		if line == "" || seen[line] {
+			continue
+		}
+		seen[line] = true
+		out.WriteString(line + "\n")
+	}
+	return out.Bytes(), nil
+}
+
+// splitLines splits data into lines without their terminators
+func splitLines(data []byte) []string {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// mergeJSON deep-merges two JSON documents, preserving key order
+func mergeJSON(base, overlay []byte) ([]byte, error) {
+	b, err := decodeOrderedJSON(base)
+	if err != nil {
+		return nil, err
+	}
+	o, err := decodeOrderedJSON(overlay)
+	if err != nil {
+		return nil, err
+	}
+
+	var compact bytes.Buffer
+	if err := encodeOrderedJSON(&compact, deepMerge(b, o)); err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, compact.Bytes(), "", "  "); err != nil {
+		return nil, err
+	}
+	out.WriteByte('\n')
+	return out.Bytes(), nil
+}
+
+// mergeYAML deep-merges two YAML documents, preserving key order
+func mergeYAML(base, overlay []byte) ([]byte, error) {
+	var b, o any
+	if err := yaml.UnmarshalWithOptions(base, &b, yaml.UseOrderedMap()); err != nil {
+		return nil, err
+	}
+	if err := yaml.UnmarshalWithOptions(overlay, &o, yaml.UseOrderedMap()); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(deepMerge(b, o))
+}
+
+// deepMerge merges overlay into base
+// Mappings are merged key by key; any other overlay value replaces the base value
+func deepMerge(base, overlay any) any {
+	b, ok := base.(yaml.MapSlice)
+	o, ok2 := overlay.(yaml.MapSlice)
+	if !ok || !ok2 {
+		return overlay
+	}
+
+	merged := append(yaml.MapSlice{}, b...)
+	for _, item := range o {
+		found := false
+		for i := range merged {
+			if merged[i].Key == item.Key {
+				merged[i].Value = deepMerge(merged[i].Value, item.Value)
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, item)
+		}
+	}
+	return merged
+}
+
+// decodeOrderedJSON decodes a JSON document, keeping objects as yaml.MapSlice
+func decodeOrderedJSON(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	v, err := decodeJSONValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after JSON document")
+	}
+	return v, nil
+}
+
+func decodeJSONValue(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		obj := yaml.MapSlice{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, yaml.MapItem{Key: key, Value: value})
+		}
+		_, err := dec.Token()
+		return obj, err
+	case json.Delim('['):
+		arr := []any{}
+		for dec.More() {
+			value, err := decodeJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, value)
+		}
+		_, err := dec.Token()
+		return arr, err
+	}
+	return tok, nil
+}
+
+// encodeOrderedJSON writes v as compact JSON, keeping yaml.MapSlice key order
+func encodeOrderedJSON(w *bytes.Buffer, v any) error {
+	switch v := v.(type) {
+	case yaml.MapSlice:
+		w.WriteByte('{')
+		for i, item := range v {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			if err := encodeOrderedJSON(w, fmt.Sprint(item.Key)); err != nil {
+				return err
+			}
+			w.WriteByte(':')
+			if err := encodeOrderedJSON(w, item.Value); err != nil {
+				return err
+			}
+		}
+		w.WriteByte('}')
+	case []any:
+		w.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			if err := encodeOrderedJSON(w, item); err != nil {
+				return err
+			}
+		}
+		w.WriteByte(']')
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		w.Write(data)
+	}
+	return nil
+}
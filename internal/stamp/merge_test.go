@@ -0,0 +1,127 @@
+package stamp
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// TestExecuteMultiple_MergeLines tests line-union of ignore files across sheets
+func TestExecuteMultiple_MergeLines(t *testing.T) {
+	base := t.TempDir()
+	extra := t.TempDir()
+	dest := t.TempDir()
+
+	createTestFile(t, base, ".gitignore", "node_modules/\n*.log\n")
+	createTestFile(t, extra, ".gitignore.stamp", "*.log\n{{.dir}}/\n")
+
+	stamper := New(map[string]string{"dir": "dist"}, ".stamp", WithMergeStrategy(MergeTypeAware))
+	if _, err := stamper.ExecuteMultiple([]string{base, extra}, dest); err != nil {
+		t.Fatalf("ExecuteMultiple() failed: %v", err)
+	}
+
+	assertFileContent(t, filepath.Join(dest, ".gitignore"), "node_modules/\n*.log\ndist/\n")
+}
+
+// TestExecuteMultiple_MergeJSON tests deep-merge of JSON files across sheets
+func TestExecuteMultiple_MergeJSON(t *testing.T) {
+	base := t.TempDir()
+	extra := t.TempDir()
+	dest := t.TempDir()
+
+	createTestFile(t, base, "package.json.stamp", `{"name": "{{.name}}", "scripts": {"build": "tsc"}, "files": ["lib"]}`)
+	createTestFile(t, extra, "package.json", `{"scripts": {"test": "jest", "build": "tsc -b"}, "files": ["dist"], "private": true}`)
+
+	stamper := New(map[string]string{"name": "app"}, ".stamp", WithMergeStrategy(MergeTypeAware))
+	if _, err := stamper.ExecuteMultiple([]string{base, extra}, dest); err != nil {
+		t.Fatalf("ExecuteMultiple() failed: %v", err)
+	}
+
+	want := `{
+  "name": "app",
+  "scripts": {
+    "build": "tsc -b",
+    "test": "jest"
+  },
+  "files": [
+    "dist"
+  ],
+  "private": true
+}
+`
+	assertFileContent(t, filepath.Join(dest, "package.json"), want)
+}
+
+// TestExecuteMultiple_MergeYAML tests deep-merge of YAML files across sheets
+func TestExecuteMultiple_MergeYAML(t *testing.T) {
+	base := t.TempDir()
+	extra := t.TempDir()
+	dest := t.TempDir()
+
+	createTestFile(t, base, "config.yaml", "server:\n  host: localhost\n  port: 80\n")
+	createTestFile(t, extra, "config.yaml", "server:\n  port: 8080\ndebug: true\n")
+
+	stamper := New(nil, ".stamp", WithMergeStrategy(MergeTypeAware))
+	if _, err := stamper.ExecuteMultiple([]string{base, extra}, dest); err != nil {
+		t.Fatalf("ExecuteMultiple() failed: %v", err)
+	}
+
+	assertFileContent(t, filepath.Join(dest, "config.yaml"), "server:\n  host: localhost\n  port: 8080\ndebug: true\n")
+}
+
+// TestExecuteMultiple_MergeFallsBackToOverwrite tests unknown types, the default strategy,
+// and files that existed before the run
+func TestExecuteMultiple_MergeFallsBackToOverwrite(t *testing.T) {
+	base := t.TempDir()
+	extra := t.TempDir()
+	dest := t.TempDir()
+
+	createTestFile(t, base, "README.md", "base\n")
+	createTestFile(t, extra, "README.md", "extra\n")
+	createTestFile(t, extra, ".dockerignore", "tmp/\n")
+	createTestFile(t, dest, ".dockerignore", "existing/\n")
+
+	stamper := New(nil, ".stamp", WithMergeStrategy(MergeTypeAware), WithOverwrite(OverwriteForce))
+	if _, err := stamper.ExecuteMultiple([]string{base, extra}, dest); err != nil {
+		t.Fatalf("ExecuteMultiple() failed: %v", err)
+	}
+	assertFileContent(t, filepath.Join(dest, "README.md"), "extra\n")
+	assertFileContent(t, filepath.Join(dest, ".dockerignore"), "tmp/\n")
+
+	createTestFile(t, base, ".gitignore", "a\n")
+	createTestFile(t, extra, ".gitignore", "b\n")
+	if _, err := New(nil, ".stamp", WithOverwrite(OverwriteForce)).ExecuteMultiple([]string{base, extra}, dest); err != nil {
+		t.Fatalf("ExecuteMultiple() failed: %v", err)
+	}
+	assertFileContent(t, filepath.Join(dest, ".gitignore"), "b\n")
+}
+
+// TestExecuteMultiple_MergeAttribute tests selecting a merger via .stampattributes
+func TestExecuteMultiple_MergeAttribute(t *testing.T) {
+	base := t.TempDir()
+	extra := t.TempDir()
+	dest := t.TempDir()
+
+	createTestFile(t, base, "requirements.txt", "requests\n")
+	createTestFile(t, extra, AttributesFile, "requirements.txt merge=lines\n")
+	createTestFile(t, extra, "requirements.txt", "pytest\nrequests\n")
+
+	stamper := New(nil, ".stamp", WithMergeStrategy(MergeTypeAware))
+	if _, err := stamper.ExecuteMultiple([]string{base, extra}, dest); err != nil {
+		t.Fatalf("ExecuteMultiple() failed: %v", err)
+	}
+	assertFileContent(t, filepath.Join(dest, "requirements.txt"), "requests\npytest\n")
+}
+
+// TestExecuteMultiple_MergeInvalidJSON tests that unparsable JSON is reported
+func TestExecuteMultiple_MergeInvalidJSON(t *testing.T) {
+	base := t.TempDir()
+	extra := t.TempDir()
+
+	createTestFile(t, base, "a.json", `{"a": 1}`)
+	createTestFile(t, extra, "a.json", `{"a": `)
+
+	stamper := New(nil, ".stamp", WithMergeStrategy(MergeTypeAware))
+	if _, err := stamper.ExecuteMultiple([]string{base, extra}, t.TempDir()); err == nil {
+		t.Fatal("ExecuteMultiple() should fail for invalid JSON")
+	}
+}
@@ -0,0 +1,89 @@
+//go:build unix
+
+package stamp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/monochromegane/stamp/internal/config"
+)
+
+// assertMode checks the permission bits of a file
+func assertMode(t *testing.T, path string, want os.FileMode) {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("failed to stat %s: %v", path, err)
+	}
+	if got := info.Mode().Perm(); got != want {
+		t.Errorf("mode of %s = %v, want %v", filepath.Base(path), got, want)
+	}
+}
+
+// TestExecute_PreservesMode tests that copied, templated and noop files keep the source permissions
+func TestExecute_PreservesMode(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+
+	createTestFile(t, src, "build.sh", "#!/bin/sh\n")
+	createTestFile(t, src, "run.sh.stamp", "#!/bin/sh\necho {{.name}}\n")
+	createTestFile(t, src, "raw.txt.stamp.noop", "{{.name}}")
+	createTestFile(t, src, "notes.txt", "notes")
+	for _, name := range []string{"build.sh", "run.sh.stamp", "raw.txt.stamp.noop"} {
+		if err := os.Chmod(filepath.Join(src, name), 0755); err != nil {
+			t.Fatalf("failed to chmod %s: %v", name, err)
+		}
+	}
+	// An existing destination file takes the source mode too
+	createTestFile(t, dest, "build.sh", "old")
+
+	if err := New(map[string]string{"name": "app"}, ".stamp", WithOverwrite(OverwriteForce)).Execute(src, dest); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	assertMode(t, filepath.Join(dest, "build.sh"), 0755)
+	assertMode(t, filepath.Join(dest, "run.sh"), 0755)
+	assertMode(t, filepath.Join(dest, "raw.txt.stamp"), 0755)
+	assertMode(t, filepath.Join(dest, "notes.txt"), 0644)
+}
+
+// TestExecute_SheetPermissions tests that permissions in the sheet settings override the source mode
+func TestExecute_SheetPermissions(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+	createTestFile(t, src, config.SheetFile, "permissions:\n  \"*.sh\": \"0755\"\n  secrets/*: 0600\n  secrets/ok.sh: \"0700\"\n")
+	createTestFile(t, src, "build.sh", "#!/bin/sh\n")
+	createTestFile(t, src, "run.sh.stamp", "#!/bin/sh\necho {{.name}}\n")
+	createTestFile(t, src, "notes.txt", "notes")
+	os.MkdirAll(filepath.Join(src, "secrets"), 0755)
+	createTestFile(t, filepath.Join(src, "secrets"), "key.txt", "key")
+	createTestFile(t, filepath.Join(src, "secrets"), "ok.sh", "#!/bin/sh\n")
+
+	if err := New(map[string]string{"name": "app"}, ".stamp").Execute(src, dest); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	// Sources are 0644; later matching patterns win
+	assertMode(t, filepath.Join(dest, "build.sh"), 0755)
+	assertMode(t, filepath.Join(dest, "run.sh"), 0755)
+	assertMode(t, filepath.Join(dest, "notes.txt"), 0644)
+	assertMode(t, filepath.Join(dest, "secrets", "key.txt"), 0600)
+	assertMode(t, filepath.Join(dest, "secrets", "ok.sh"), 0700)
+}
+
+// TestExecute_SheetPermissionsOutputPrefix tests that slash patterns match paths below the output prefix
+func TestExecute_SheetPermissionsOutputPrefix(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+	createTestFile(t, src, config.SheetFile, "permissions:\n  scripts/*.sh: \"0755\"\n")
+	os.MkdirAll(filepath.Join(src, "scripts"), 0755)
+	createTestFile(t, filepath.Join(src, "scripts"), "build.sh", "#!/bin/sh\n")
+
+	if err := New(map[string]string{"name": "app"}, ".stamp", WithOutputPrefix("{{.name}}")).Execute(src, dest); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	assertMode(t, filepath.Join(dest, "app", "scripts", "build.sh"), 0755)
+}
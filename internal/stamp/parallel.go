@@ -0,0 +1,172 @@
+package stamp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// fileTask is a group of sheet files with the same output path
+// Files in a task are processed in order by one worker, since later ones replace earlier ones
+type fileTask struct {
+	files []sheetFile
+	run   *Stamper      // Per-task copy of the Stamper that buffers results and events
+	keys  []string      // Destination paths whose written state the task may change
+	err   error         // First error of the task
+	done  chan struct{} // Closed once the task has finished or been abandoned
+}
+
+// eventBuffer is a Logger that keeps events for replay in order
+type eventBuffer struct {
+	events []Event
+}
+
+func (b *eventBuffer) Log(e Event) {
+	b.events = append(b.events, e)
+}
+
+// processFiles processes the sorted files of the sheet rooted at src with up to s.jobs workers
+// Each task works on a copy of the Stamper; results and events are committed in file order,
+// so output is the same for any number of jobs. The first error cancels the remaining tasks
+func (s *Stamper) processFiles(ctx context.Context, src string, files []sheetFile) error {
+	tasks := s.fileTasks(files)
+	jobs := s.jobs
+	if jobs < 1 {
+		jobs = runtime.GOMAXPROCS(0)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	// failed keeps the first error from any worker, so tasks interrupted by the
+	// cancellation it causes do not hide it
+	var mu sync.Mutex
+	var failed error
+	queue := make(chan *fileTask)
+	for range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range queue {
+				t.err = t.run.processTask(ctx, src, t.files)
+				if t.err != nil && !errors.Is(t.err, context.Canceled) {
+					mu.Lock()
+					if failed == nil {
+						failed = t.err
+					}
+					mu.Unlock()
+					cancel()
+				}
+				close(t.done)
+			}
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(queue)
+		for i, t := range tasks {
+			select {
+			case queue <- t:
+			case <-ctx.Done():
+				for _, t := range tasks[i:] {
+					t.err = fmt.Errorf("stamp interrupted: %w", ctx.Err())
+					close(t.done)
+				}
+				return
+			}
+		}
+	}()
+
+	for _, t := range tasks {
+		<-t.done
+		s.commitTask(t)
+		if t.err != nil {
+			mu.Lock()
+			defer mu.Unlock()
+			if errors.Is(t.err, context.Canceled) && failed != nil {
+				return failed
+			}
+			return t.err
+		}
+	}
+	return nil
+}
+
+// fileTasks groups consecutive files with the same output path into tasks
+func (s *Stamper) fileTasks(files []sheetFile) []*fileTask {
+	var tasks []*fileTask
+	for i, f := range files {
+		if i == 0 || f.sortKey != files[i-1].sortKey {
+			tasks = append(tasks, &fileTask{done: make(chan struct{})})
+		}
+		t := tasks[len(tasks)-1]
+		t.files = append(t.files, f)
+		t.keys = append(t.keys, f.destPath, s.outputRelPath(f.destPath))
+	}
+
+	// Workers only read the shared written map, which is not updated until tasks are committed
+	for _, t := range tasks {
+		run := *s
+		run.result = &Result{}
+		run.logger = &eventBuffer{}
+		run.written = make(map[string]string, len(t.keys))
+		for _, key := range t.keys {
+			if sheet := s.written[key]; sheet != "" {
+				run.written[key] = sheet
+			}
+		}
+		t.run = &run
+	}
+	return tasks
+}
+
+// processTask processes the files of a task in order
+func (s *Stamper) processTask(ctx context.Context, src string, files []sheetFile) error {
+	for _, f := range files {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("stamp interrupted: %w", err)
+		}
+		if err := s.processSheetFile(src, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// commitTask replays the events of a finished task and adds its results to the run
+func (s *Stamper) commitTask(t *fileTask) {
+	for _, e := range t.run.logger.(*eventBuffer).events {
+		s.logger.Log(e)
+	}
+	s.result.add(t.run.result)
+	for _, key := range t.keys {
+		if sheet := t.run.written[key]; sheet != "" {
+			s.written[key] = sheet
+		} else {
+			delete(s.written, key)
+		}
+	}
+}
+
+// add accumulates the file counts of r2 into r
+func (r *Result) add(r2 *Result) {
+	r.Templated += r2.Templated
+	r.Copied += r2.Copied
+	r.Linked += r2.Linked
+	r.Skipped += r2.Skipped
+	r.Unchanged += r2.Unchanged
+	r.Overwritten += r2.Overwritten
+	r.Bytes += r2.Bytes
+	r.Files = append(r.Files, r2.Files...)
+	r.Failed = append(r.Failed, r2.Failed...)
+	r.Symlinks += r2.Symlinks
+	r.ExternalSymlinks += r2.ExternalSymlinks
+}
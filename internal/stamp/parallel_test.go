@@ -0,0 +1,79 @@
+package stamp
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// createLargeSheet creates a sheet with n templates and n regular files spread over directories
+func createLargeSheet(t *testing.T, n int) string {
+	t.Helper()
+	src := t.TempDir()
+	for i := range n {
+		dir := filepath.Join(src, fmt.Sprintf("dir%02d", i%10))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		createTestFile(t, dir, fmt.Sprintf("file%03d.txt.stamp", i), fmt.Sprintf("{{.name}} %d", i))
+		createTestFile(t, dir, fmt.Sprintf("static%03d.txt", i), fmt.Sprintf("static %d", i))
+	}
+	return src
+}
+
+// TestExecuteMultiple_Jobs tests that a large sheet produces the same output, result and events with any number of jobs
+func TestExecuteMultiple_Jobs(t *testing.T) {
+	src := createLargeSheet(t, 200)
+	extra := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(extra, "dir00"), 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	createTestFile(t, extra, filepath.Join("dir00", "file000.txt"), "extra")
+
+	run := func(jobs int) (*Result, []Event, string) {
+		dest := t.TempDir()
+		logger := &recordingLogger{}
+		result, err := New(map[string]string{"name": "alice"}, ".stamp", WithJobs(jobs), WithLogger(logger)).ExecuteMultiple([]string{src, extra}, dest)
+		if err != nil {
+			t.Fatalf("ExecuteMultiple() with %d jobs failed: %v", jobs, err)
+		}
+		return result, logger.events, dest
+	}
+
+	want, wantEvents, _ := run(1)
+	if want.Written() != 401 || want.Templated != 200 {
+		t.Fatalf("Written = %d, Templated = %d, want 401, 200", want.Written(), want.Templated)
+	}
+	for range 3 {
+		got, events, dest := run(4)
+		assertFileContent(t, filepath.Join(dest, "dir00", "file000.txt"), "extra")
+		assertFileContent(t, filepath.Join(dest, "dir07", "file197.txt"), "alice 197")
+		assertFileContent(t, filepath.Join(dest, "dir03", "static123.txt"), "static 123")
+		if got.String() != want.String() || !reflect.DeepEqual(got.Files, want.Files) {
+			t.Errorf("result with 4 jobs = %s, want %s", got, want)
+		}
+		if !reflect.DeepEqual(events, wantEvents) {
+			t.Errorf("events with 4 jobs differ from events with 1 job")
+		}
+	}
+}
+
+// TestExecuteMultiple_JobsError tests that an error from one worker stops the run and is returned
+func TestExecuteMultiple_JobsError(t *testing.T) {
+	src := createLargeSheet(t, 100)
+	dest := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dest, "dir09"), 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	createTestFile(t, dest, filepath.Join("dir09", "static099.txt"), "mine")
+
+	_, err := New(map[string]string{"name": "alice"}, ".stamp", WithJobs(4)).ExecuteMultiple([]string{src}, dest)
+	if !errors.Is(err, ErrExists) || !strings.Contains(err.Error(), "static099.txt") {
+		t.Fatalf("ExecuteMultiple() error = %v, want the existing static099.txt to be reported", err)
+	}
+	assertFileContent(t, filepath.Join(dest, "dir09", "static099.txt"), "mine")
+}
@@ -0,0 +1,42 @@
+package stamp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/template"
+)
+
+// PartialsFile holds shared template definitions at a sheet root
+// Every template in the sheet can invoke its {{define}} blocks with {{template "name" .}};
+// the file itself is never written to the destination
+const PartialsFile = "_partials.tmpl"
+
+// loadPartials reads the partials file from a sheet directory
+// A missing file yields no partials
+func loadPartials(sheetDir string) (string, error) {
+	content, err := os.ReadFile(filepath.Join(sheetDir, PartialsFile))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", PartialsFile, err)
+	}
+	return string(content), nil
+}
+
+// addPartials parses the current sheet's partials into tmpl's template set
+// Partials always use the Stamper's delimiters, whatever delimiters the file itself uses
+func (s *Stamper) addPartials(tmpl *template.Template) error {
+	if s.partials == "" {
+		return nil
+	}
+	partials := s.partials
+	if s.trimBlocks {
+		partials = trimBlocks(partials, s.leftDelim, s.rightDelim)
+	}
+	if _, err := tmpl.New(PartialsFile).Delims(s.leftDelim, s.rightDelim).Parse(partials); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", PartialsFile, err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package stamp
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestExecute_Partials tests invoking shared definitions from every template in a sheet
+func TestExecute_Partials(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+	createTestFile(t, src, PartialsFile, `{{define "header"}}// Copyright {{.org}}{{end}}
+{{define "footer"}}// end of {{.name}}{{end}}`)
+	createTestFile(t, src, "main.go.stamp", "{{template \"header\" .}}\npackage {{.name}}\n{{template \"footer\" .}}")
+	createTestFile(t, src, "main.tf.stamp-sq", "[[template \"header\" .]]\nname = \"[[.name]]\"")
+
+	vars := map[string]string{"org": "acme", "name": "app"}
+	if err := New(vars, ".stamp").Execute(src, dest); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	assertFileContent(t, filepath.Join(dest, "main.go"), "// Copyright acme\npackage app\n// end of app")
+	assertFileContent(t, filepath.Join(dest, "main.tf"), "// Copyright acme\nname = \"app\"")
+	assertFileNotExists(t, filepath.Join(dest, PartialsFile))
+}
+
+// TestExecute_PartialsPerSheet tests that partials only apply to their own sheet
+func TestExecute_PartialsPerSheet(t *testing.T) {
+	base := t.TempDir()
+	overlay := t.TempDir()
+	dest := t.TempDir()
+	createTestFile(t, base, PartialsFile, `{{define "greeting"}}hello{{end}}`)
+	createTestFile(t, base, "a.txt.stamp", `{{template "greeting"}}`)
+	createTestFile(t, overlay, "b.txt.stamp", `{{template "greeting"}}`)
+
+	_, err := New(nil, ".stamp").ExecuteMultiple([]string{base, overlay}, dest)
+	if err == nil || !strings.Contains(err.Error(), `template "greeting" not defined`) {
+		t.Fatalf("ExecuteMultiple() error = %v, want undefined template error", err)
+	}
+	// The failure rolls back the file written by the first sheet
+	if _, err := os.Stat(filepath.Join(dest, "a.txt")); !os.IsNotExist(err) {
+		t.Errorf("a.txt should be rolled back, stat error = %v", err)
+	}
+}
+
+// TestValidate_Partials tests that variables and syntax errors in partials are validated
+func TestValidate_Partials(t *testing.T) {
+	src := t.TempDir()
+	createTestFile(t, src, PartialsFile, `{{define "header"}}{{.org}}{{end}}`)
+	createTestFile(t, src, "main.go.stamp", `{{template "header" .}}`)
+
+	err := New(nil, ".stamp").Validate([]string{src})
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.MissingVars["org"] == nil {
+		t.Fatalf("Validate() error = %v, want org missing", err)
+	}
+
+	createTestFile(t, src, PartialsFile, `{{define "header"}}{{.org}}`)
+	err = New(map[string]string{"org": "acme"}, ".stamp").Validate([]string{src})
+	if !errors.As(err, &validationErr) || validationErr.ParseErrors[PartialsFile] == "" {
+		t.Errorf("Validate() error = %v, want a syntax error in %s", err, PartialsFile)
+	}
+}
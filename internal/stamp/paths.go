@@ -0,0 +1,207 @@
+package stamp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/monochromegane/stamp/internal/config"
+)
+
+// ErrUnsafePath is returned when an output path would be written outside the destination
+var ErrUnsafePath = errors.New("path escapes destination")
+
+// checkDest fails fast when dest cannot hold the output
+// dest may not exist yet; then its nearest existing parent must be a writable directory
+func checkDest(dest string) error {
+	dir := dest
+	for {
+		info, err := os.Stat(dir)
+		if err == nil {
+			if !info.IsDir() {
+				return fmt.Errorf("%w: %s", ErrNotDirectory, dir)
+			}
+			if err := checkWritable(dir); err != nil {
+				return fmt.Errorf("destination %s is not writable: %w", dir, err)
+			}
+			return nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil
+		}
+		dir = parent
+	}
+}
+
+// containedPath joins relPath onto root and verifies the result stays inside root
+// This guards against sheets that use ".." (directly or via templating) to write elsewhere
+func containedPath(root, relPath string) (string, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve destination: %w", err)
+	}
+
+	rel, err := filepath.Rel(absRoot, filepath.Join(absRoot, relPath))
+	if err != nil || escapes(rel) || filepath.IsAbs(relPath) {
+		return "", fmt.Errorf("%w: %s", ErrUnsafePath, relPath)
+	}
+
+	return filepath.Join(root, rel), nil
+}
+
+// escapes reports whether a path relative to some root leaves that root
+func escapes(rel string) bool {
+	return rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// isWithin reports whether path is root or lies beneath it, comparing the paths as given
+func isWithin(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && !escapes(rel)
+}
+
+// isSymlink reports whether path itself is a symlink
+func isSymlink(path string) bool {
+	info, err := os.Lstat(path)
+	return err == nil && info.Mode()&os.ModeSymlink != 0
+}
+
+// checkLinks refuses dir when dir or one of its parents below the destination is a symlink
+// that resolves outside the destination, or does not resolve at all
+// containedPath only checks names; this stops a link recreated by an earlier sheet,
+// or left in the destination, from carrying later writes and directories elsewhere
+func (s *Stamper) checkLinks(dir string) error {
+	if s.dest == "" {
+		return nil
+	}
+	rel, err := filepath.Rel(s.dest, dir)
+	if err != nil || rel == "." || escapes(rel) {
+		return nil
+	}
+
+	realDest := ""
+	d := s.dest
+	for _, name := range strings.Split(rel, string(filepath.Separator)) {
+		d = filepath.Join(d, name)
+		// Nothing below a missing entry exists yet
+		if !exists(d) {
+			return nil
+		}
+		if !isSymlink(d) {
+			continue
+		}
+		if realDest == "" {
+			if realDest, err = filepath.EvalSymlinks(s.dest); err != nil {
+				return fmt.Errorf("failed to resolve destination: %w", err)
+			}
+		}
+		relLink, _ := relSlashPath(s.dest, d)
+		target, err := filepath.EvalSymlinks(d)
+		if err != nil || !isWithin(realDest, target) {
+			return fmt.Errorf("%w: %s is a symlink to a location outside the destination", ErrUnsafePath, relLink)
+		}
+	}
+	return nil
+}
+
+// prepareDest makes destPath safe to write: its parents are checked with checkLinks,
+// and a symlink at destPath is removed so the write replaces the link instead of following it
+// Call it after saveDest so rollback can put the link back
+func (s *Stamper) prepareDest(destPath string) error {
+	if err := s.checkLinks(filepath.Dir(destPath)); err != nil {
+		return err
+	}
+	if isSymlink(destPath) {
+		if err := os.Remove(destPath); err != nil {
+			return newWriteError(destPath, err)
+		}
+	}
+	return nil
+}
+
+// renderPath expands template actions in each segment of a sheet-relative path
+// so "{{.pkg}}/main.go.stamp" becomes "server/main.go.stamp"; the result is
+// checked by containedPath like any other path
+func (s *Stamper) renderPath(relPath string) (string, error) {
+	if !strings.Contains(relPath, s.leftDelim) {
+		return relPath, nil
+	}
+
+	segments := strings.Split(relPath, string(filepath.Separator))
+	for i, segment := range segments {
+		if !strings.Contains(segment, s.leftDelim) {
+			continue
+		}
+		tmpl, err := s.newTemplate(segment).Option("missingkey=error").Parse(segment)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse path %s: %w", relPath, err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, s.templateData); err != nil {
+			return "", fmt.Errorf("failed to render path %s: %w", relPath, err)
+		}
+		if buf.Len() == 0 {
+			return "", fmt.Errorf("path %s renders an empty name", relPath)
+		}
+		segments[i] = buf.String()
+	}
+	return filepath.Join(segments...), nil
+}
+
+// patternPath returns destPath relative to the output directory of the current run
+// Permissions and .stampattributes patterns are matched against it, so they apply
+// the same way with or without an output prefix
+func (s *Stamper) patternPath(destPath string) string {
+	relPath, err := relSlashPath(s.output, destPath)
+	if err != nil {
+		return toSlash(destPath, filepath.Separator)
+	}
+	return relPath
+}
+
+// relSlashPath returns target relative to base using forward slashes
+// Sheet-relative paths always use "/" so patterns are portable across platforms
+func relSlashPath(base, target string) (string, error) {
+	rel, err := filepath.Rel(base, target)
+	if err != nil {
+		return "", err
+	}
+	return toSlash(rel, filepath.Separator), nil
+}
+
+// toSlash replaces every sep in p with "/"
+// Unlike filepath.ToSlash the separator is a parameter, so Windows paths can be tested anywhere
+func toSlash(p string, sep rune) string {
+	if sep == '/' {
+		return p
+	}
+	return strings.ReplaceAll(p, string(sep), "/")
+}
+
+// selected reports whether a slash-separated output path matches a WithOnly pattern
+func (s *Stamper) selected(outputPath string) bool {
+	if len(s.only) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(s.only, func(pattern string) bool {
+		return config.MatchPath(pattern, outputPath)
+	})
+}
+
+// selectedSource reports whether a slash-separated sheet-relative path is selected by WithOnly
+// Paths whose variables cannot be rendered yet are matched as written in the sheet
+func (s *Stamper) selectedSource(relPath string) bool {
+	if len(s.only) == 0 {
+		return true
+	}
+	rendered, err := s.renderPath(filepath.FromSlash(relPath))
+	if err != nil {
+		return s.selected(s.outputRelPath(relPath))
+	}
+	return s.selected(s.outputRelPath(toSlash(rendered, filepath.Separator)))
+}
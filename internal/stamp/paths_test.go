@@ -0,0 +1,96 @@
+package stamp
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+// TestToSlash_WindowsSeparators tests that backslash paths are normalized for matching
+func TestToSlash_WindowsSeparators(t *testing.T) {
+	if got := toSlash(`sub\nested\file.stamp`, '\\'); got != "sub/nested/file.stamp" {
+		t.Errorf("toSlash() = %q, want %q", got, "sub/nested/file.stamp")
+	}
+}
+
+// TestRelSlashPath tests that relative paths use forward slashes
+func TestRelSlashPath(t *testing.T) {
+	base := t.TempDir()
+	rel, err := relSlashPath(base, filepath.Join(base, "a", "b", "c.stamp"))
+	if err != nil {
+		t.Fatalf("relSlashPath() failed: %v", err)
+	}
+	if rel != "a/b/c.stamp" {
+		t.Errorf("relSlashPath() = %q, want %q", rel, "a/b/c.stamp")
+	}
+}
+
+// TestContainedPath tests that paths escaping the destination are rejected
+func TestContainedPath(t *testing.T) {
+	dest := t.TempDir()
+
+	tests := []struct {
+		relPath string
+		wantErr bool
+	}{
+		{"file.txt", false},
+		{"sub/file.txt", false},
+		{"sub/../file.txt", false},
+		{".", false},
+		{"../escape", true},
+		{"sub/../../escape", true},
+		{"..", true},
+	}
+
+	for _, tt := range tests {
+		got, err := containedPath(dest, tt.relPath)
+		if tt.wantErr {
+			if !errors.Is(err, ErrUnsafePath) {
+				t.Errorf("containedPath(%q) error = %v, want ErrUnsafePath", tt.relPath, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("containedPath(%q) returned error: %v", tt.relPath, err)
+			continue
+		}
+		if want := filepath.Join(dest, tt.relPath); got != want {
+			t.Errorf("containedPath(%q) = %q, want %q", tt.relPath, got, want)
+		}
+	}
+}
+
+// TestRenderPath tests expanding variables in path segments
+func TestRenderPath(t *testing.T) {
+	s := New(map[string]string{"pkg": "server", "name": "App", "empty": ""}, ".stamp")
+
+	tests := []struct {
+		relPath string
+		want    string
+		wantErr bool
+	}{
+		{filepath.Join("cmd", "main.go"), filepath.Join("cmd", "main.go"), false},
+		{filepath.Join("{{.pkg}}", "main.go.stamp"), filepath.Join("server", "main.go.stamp"), false},
+		{filepath.Join("{{.pkg}}", `{{printf "%s_test" .name}}.go`), filepath.Join("server", "App_test.go"), false},
+		{"{{.missing}}.txt", "", true},
+		{filepath.Join("{{.empty}}", "main.go"), "", true},
+		{"{{.pkg", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := s.renderPath(tt.relPath)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("renderPath(%q) = %q, want error", tt.relPath, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("renderPath(%q) returned error: %v", tt.relPath, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("renderPath(%q) = %q, want %q", tt.relPath, got, tt.want)
+		}
+	}
+}
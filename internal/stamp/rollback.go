@@ -0,0 +1,174 @@
+package stamp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// journal records the destination entries a run changes so that a failed run can put them back
+// Entries are recorded just before their first change; later changes to the same path are ignored
+type journal struct {
+	mu      sync.Mutex
+	dir     string              // Backup directory, created when the first existing file is saved
+	saved   map[string]struct{} // Paths recorded so far
+	created []string            // Paths that did not exist before the run, in creation order
+	backups []backup            // Entries that existed before the run
+}
+
+// backup is the state of a destination entry before the run changed it
+type backup struct {
+	path    string
+	mode    fs.FileMode
+	modTime time.Time
+	copy    string // Copy of a regular file's content in the backup directory
+	target  string // Target of a symlink
+}
+
+func newJournal() *journal {
+	return &journal{saved: make(map[string]struct{})}
+}
+
+// save records path before it is written, replaced or removed
+func (j *journal) save(path string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if _, ok := j.saved[path]; ok {
+		return nil
+	}
+
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		j.saved[path] = struct{}{}
+		j.created = append(j.created, path)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+
+	b := backup{path: path, mode: info.Mode(), modTime: info.ModTime()}
+	switch {
+	case info.Mode().IsRegular():
+		if b.copy, err = j.copyAside(path); err != nil {
+			return fmt.Errorf("failed to back up %s: %w", path, err)
+		}
+	case info.Mode()&os.ModeSymlink != 0:
+		if b.target, err = os.Readlink(path); err != nil {
+			return fmt.Errorf("failed to back up %s: %w", path, err)
+		}
+	case info.IsDir():
+		// Directories are never replaced, only filled
+		j.saved[path] = struct{}{}
+		return nil
+	}
+	j.saved[path] = struct{}{}
+	j.backups = append(j.backups, b)
+	return nil
+}
+
+// saveDirs records the directories that creating dir with its parents would add
+func (j *journal) saveDirs(dir string) error {
+	var missing []string
+	for d := dir; !exists(d); d = filepath.Dir(d) {
+		missing = append(missing, d)
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+	for _, d := range slices.Backward(missing) {
+		if err := j.save(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyAside copies the regular file at path into the backup directory
+func (j *journal) copyAside(path string) (string, error) {
+	if j.dir == "" {
+		dir, err := os.MkdirTemp("", "stamp-rollback-")
+		if err != nil {
+			return "", err
+		}
+		j.dir = dir
+	}
+
+	in, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+	out, err := os.CreateTemp(j.dir, "backup-")
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return out.Name(), err
+}
+
+// rollback undoes the recorded changes and returns cause, joined with any errors restoring entries
+// Created entries are removed newest first, so directories are empty by the time they are removed
+func (j *journal) rollback(cause error) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	var errs []error
+	for _, path := range slices.Backward(j.created) {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err)
+		}
+	}
+	for _, b := range j.backups {
+		if err := b.restore(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to restore %s: %w", b.path, err))
+		}
+	}
+	j.discard()
+
+	if len(errs) > 0 {
+		return errors.Join(cause, fmt.Errorf("rollback incomplete: %w", errors.Join(errs...)))
+	}
+	return cause
+}
+
+// discard removes the backups once the run no longer needs them
+func (j *journal) discard() {
+	if j.dir != "" {
+		os.RemoveAll(j.dir)
+		j.dir = ""
+	}
+}
+
+// restore puts a saved entry back in place of whatever the run left at its path
+func (b backup) restore() error {
+	if err := os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	switch {
+	case b.mode&os.ModeSymlink != 0:
+		return os.Symlink(b.target, b.path)
+	case b.mode.IsRegular():
+		if _, err := streamWithMode(b.copy, b.path); err != nil {
+			return err
+		}
+		if err := os.Chmod(b.path, b.mode.Perm()); err != nil {
+			return err
+		}
+	default:
+		if err := RecreateSpecial(b.path, b.mode); err != nil {
+			return err
+		}
+	}
+	return os.Chtimes(b.path, time.Time{}, b.modTime)
+}
@@ -0,0 +1,94 @@
+package stamp
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestExecuteMultiple_RollbackOnRenderError tests that a template failing at render time
+// leaves the destination as it was before the run
+func TestExecuteMultiple_RollbackOnRenderError(t *testing.T) {
+	base := t.TempDir()
+	broken := t.TempDir()
+	dest := t.TempDir()
+	createTestFile(t, base, "config.yaml.stamp", "name: {{.name}}\n")
+	createTestFile(t, base, "README.md", "new readme")
+	os.MkdirAll(filepath.Join(base, "docs", "guide"), 0755)
+	createTestFile(t, filepath.Join(base, "docs", "guide"), "intro.md", "intro")
+	// Indexing past the end of the value passes validation but fails while rendering
+	createTestFile(t, broken, "z.txt.stamp", "{{index .name 10}}")
+
+	existing := createTestFile(t, dest, "config.yaml", "name: old\n")
+	if err := os.Chmod(existing, 0600); err != nil {
+		t.Fatalf("failed to chmod: %v", err)
+	}
+	modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(existing, modTime, modTime); err != nil {
+		t.Fatalf("failed to set modification time: %v", err)
+	}
+
+	stamper := New(map[string]string{"name": "app"}, ".stamp", WithOverwrite(OverwriteForce))
+	_, err := stamper.ExecuteMultiple([]string{base, broken}, dest)
+	if err == nil || !strings.Contains(err.Error(), "z.txt.stamp") {
+		t.Fatalf("ExecuteMultiple() error = %v, want render error for z.txt.stamp", err)
+	}
+
+	// The overwritten file is restored with its mode and modification time
+	assertFileContent(t, existing, "name: old\n")
+	info, err := os.Stat(existing)
+	if err != nil {
+		t.Fatalf("failed to stat config.yaml: %v", err)
+	}
+	if info.Mode().Perm() != 0600 || !info.ModTime().Equal(modTime) {
+		t.Errorf("config.yaml mode = %v, mtime = %v; want 0600, %v", info.Mode().Perm(), info.ModTime(), modTime)
+	}
+
+	// New files and directories are removed
+	entries, err := os.ReadDir(dest)
+	if err != nil {
+		t.Fatalf("failed to read destination: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "config.yaml" {
+		t.Errorf("destination entries = %v, want only config.yaml", entries)
+	}
+}
+
+// TestExecuteMultiple_RollbackHonorsOverwritePolicy tests that files written before an
+// existing file stops the run are removed again
+func TestExecuteMultiple_RollbackHonorsOverwritePolicy(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+	createTestFile(t, src, "a.txt", "a")
+	createTestFile(t, src, "b.txt", "b")
+	createTestFile(t, dest, "b.txt", "existing")
+
+	_, err := New(nil, ".stamp", WithJobs(1)).ExecuteMultiple([]string{src}, dest)
+	if !errors.Is(err, ErrExists) {
+		t.Fatalf("ExecuteMultiple() error = %v, want ErrExists", err)
+	}
+	assertFileContent(t, filepath.Join(dest, "b.txt"), "existing")
+	if _, err := os.Stat(filepath.Join(dest, "a.txt")); !os.IsNotExist(err) {
+		t.Errorf("a.txt should be rolled back, stat error = %v", err)
+	}
+}
+
+// TestExecuteMultiple_RollbackNestedDest tests that a destination created by the run is removed
+func TestExecuteMultiple_RollbackNestedDest(t *testing.T) {
+	src := t.TempDir()
+	parent := t.TempDir()
+	createTestFile(t, src, "a.txt.stamp", "{{.name}}")
+	createTestFile(t, src, "b.txt.stamp", "{{index .name 10}}")
+
+	dest := filepath.Join(parent, "new", "project")
+	_, err := New(map[string]string{"name": "app"}, ".stamp", WithOutputPrefix("out")).ExecuteMultiple([]string{src}, dest)
+	if err == nil {
+		t.Fatal("ExecuteMultiple() should fail at render time")
+	}
+	if _, err := os.Stat(filepath.Join(parent, "new")); !os.IsNotExist(err) {
+		t.Errorf("created destination should be removed, stat error = %v", err)
+	}
+}
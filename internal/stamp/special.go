@@ -0,0 +1,22 @@
+package stamp
+
+import (
+	"io/fs"
+)
+
+// IsSpecialFile reports whether mode describes a FIFO, socket, or device
+// Reading such files can block forever, so they are never read as content
+func IsSpecialFile(mode fs.FileMode) bool {
+	return mode&(fs.ModeNamedPipe|fs.ModeSocket|fs.ModeDevice|fs.ModeCharDevice|fs.ModeIrregular) != 0
+}
+
+// CanRecreateSpecial reports whether a special file of this mode can be recreated
+// Only FIFOs are supported, and only on platforms with mkfifo
+func CanRecreateSpecial(mode fs.FileMode) bool {
+	return mode&fs.ModeNamedPipe != 0 && mkfifoSupported
+}
+
+// RecreateSpecial creates an empty special file at dest matching mode
+func RecreateSpecial(dest string, mode fs.FileMode) error {
+	return mkfifo(dest, uint32(mode.Perm()))
+}
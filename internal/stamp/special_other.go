@@ -0,0 +1,14 @@
+//go:build !unix
+
+package stamp
+
+import (
+	"errors"
+)
+
+const mkfifoSupported = false
+
+// mkfifo is not supported on this platform
+func mkfifo(path string, perm uint32) error {
+	return errors.New("named pipes are not supported on this platform")
+}
@@ -0,0 +1,18 @@
+//go:build unix
+
+package stamp
+
+import (
+	"os"
+	"syscall"
+)
+
+const mkfifoSupported = true
+
+// mkfifo creates a named pipe at path, replacing any existing file
+func mkfifo(path string, perm uint32) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return syscall.Mkfifo(path, perm)
+}
@@ -0,0 +1,64 @@
+//go:build unix
+
+package stamp
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+// TestExecute_SkipsFIFO tests that a FIFO in a sheet is skipped with a warning
+func TestExecute_SkipsFIFO(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+	createTestFile(t, src, "readme.md", "content")
+	if err := syscall.Mkfifo(filepath.Join(src, "pipe.stamp"), 0644); err != nil {
+		t.Skipf("mkfifo not available: %v", err)
+	}
+
+	logger := &recordingLogger{}
+	result, err := New(nil, ".stamp", WithLogger(logger)).ExecuteMultiple([]string{src}, dest)
+	if err != nil {
+		t.Fatalf("ExecuteMultiple() failed: %v", err)
+	}
+
+	assertFileContent(t, filepath.Join(dest, "readme.md"), "content")
+	assertFileNotExists(t, filepath.Join(dest, "pipe.stamp"))
+	assertFileNotExists(t, filepath.Join(dest, "pipe"))
+	if result.Skipped != 1 {
+		t.Errorf("Skipped = %d, want 1", result.Skipped)
+	}
+
+	var warned bool
+	for _, e := range logger.events {
+		if e.Event == EventWarning && e.Path == "pipe.stamp" {
+			warned = true
+		}
+	}
+	if !warned {
+		t.Errorf("expected a warning for pipe.stamp, got %+v", logger.events)
+	}
+}
+
+// TestExecute_IncludeSpecialRecreatesFIFO tests that FIFOs are recreated on request
+func TestExecute_IncludeSpecialRecreatesFIFO(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+	if err := syscall.Mkfifo(filepath.Join(src, "pipe"), 0644); err != nil {
+		t.Skipf("mkfifo not available: %v", err)
+	}
+
+	if err := New(nil, ".stamp", WithIncludeSpecial(true)).Execute(src, dest); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	info, err := os.Lstat(filepath.Join(dest, "pipe"))
+	if err != nil {
+		t.Fatalf("expected pipe in destination: %v", err)
+	}
+	if info.Mode()&os.ModeNamedPipe == 0 {
+		t.Errorf("pipe mode = %v, want named pipe", info.Mode())
+	}
+}
@@ -1,20 +1,304 @@
 package stamp
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
+	"text/template"
+	"time"
+
+	"github.com/monochromegane/stamp/internal/config"
 )
 
 // Stamper handles directory copying with template expansion
 type Stamper struct {
 	templateVars map[string]string
-	templateExt  string // Stamp file extension (e.g., ".stamp", ".tmpl", ".tpl")
+	templateData map[string]any     // templateVars with dotted names nested, passed to templates
+	templateExt  string             // Stamp file extension (e.g., ".stamp", ".tmpl", ".tpl")
+	noopSuffix   string             // Suffix after templateExt marking files copied verbatim
+	logger       Logger             // Receives run events
+	result       *Result            // Outcome of the current run
+	dest         string             // Destination root of the current run
+	output       string             // Directory receiving the output of the current run: dest joined with the rendered prefix
+	sheet        string             // Sheet currently being processed
+	attrs        attributes         // Attributes of the sheet currently being processed
+	partials     string             // Partials of the sheet currently being processed
+	permissions  config.Permissions // Permissions of the sheet currently being processed
+	funcs        template.FuncMap   // Template functions registered with WithFuncs
+
+	includeSpecial bool     // Recreate FIFOs instead of skipping special files
+	keepGoing      bool     // Record write failures and continue with other files
+	skipEmpty      bool     // Do not write templates that render empty output
+	strictKeys     bool     // Fail rendering on keys missing from the variables
+	typedVars      bool     // Pass values that look like booleans and numbers to templates typed
+	trimBlocks     bool     // Drop the line breaks of lines holding only control actions
+	copyBinary     bool     // Copy binary files with the template extension instead of failing
+	keepExtension  bool     // Keep the template extension in output names of rendered files
+	leftDelim      string   // Opening template action delimiter
+	rightDelim     string   // Closing template action delimiter
+	outputPrefix   string   // Templated subdirectory of dest that receives all output
+	lineEndings    string   // Run-wide line ending policy, overridable per path with eol
+	only           []string // Globs selecting the output paths to write; empty selects all
+	jobs           int      // Files of a sheet processed concurrently
+
+	dereference           bool   // Copy symlink targets instead of recreating the links
+	allowExternalSymlinks bool   // Materialize symlinks whose targets are outside the sheet
+	overwrite             string // Policy for files that existed before the run
+
+	mergeStrategy string            // How paths written by an earlier sheet are handled
+	written       map[string]string // Sheet that wrote each destination path in the current run
+	journal       *journal          // Changes of the current run, undone if it fails
+}
+
+// Option configures optional Stamper behavior
+type Option func(*Stamper)
+
+// WithLogger sets the Logger that receives run events
+func WithLogger(l Logger) Option {
+	return func(s *Stamper) {
+		s.logger = l
+	}
+}
+
+// WithIncludeSpecial recreates FIFOs in the destination instead of skipping them
+// Sockets and devices are always skipped
+func WithIncludeSpecial(include bool) Option {
+	return func(s *Stamper) {
+		s.includeSpecial = include
+	}
+}
+
+// WithKeepGoing records destination write failures in the Result and continues
+// Without it the first write failure aborts the run
+func WithKeepGoing(keepGoing bool) Option {
+	return func(s *Stamper) {
+		s.keepGoing = keepGoing
+	}
+}
+
+// WithSkipEmpty skips writing templates that render empty or whitespace-only output
+// Skipped files are counted in Result.Skipped instead of producing a warning
+func WithSkipEmpty(skip bool) Option {
+	return func(s *Stamper) {
+		s.skipEmpty = skip
+	}
+}
+
+// WithStrictKeys makes templates fail on any key missing from the variables instead of rendering "<no value>"
+func WithStrictKeys(strict bool) Option {
+	return func(s *Stamper) {
+		s.strictKeys = strict
+	}
+}
+
+// WithTypedVars passes variables written exactly like booleans or numbers to templates as such,
+// so {{if .debug}} is false for debug=false; without it every value is a string
+func WithTypedVars(typed bool) Option {
+	return func(s *Stamper) {
+		s.typedVars = typed
+		s.templateData = nestVars(s.templateVars, typed)
+	}
+}
+
+// WithTrimBlocks removes lines that hold only control actions such as {{if}} or {{end}} from the output,
+// together with their indentation, instead of leaving them behind as blank lines
+func WithTrimBlocks(trim bool) Option {
+	return func(s *Stamper) {
+		s.trimBlocks = trim
+	}
+}
+
+// WithCopyBinary copies files that have the template extension but look binary, such as a
+// renamed image, as is under their output name instead of failing validation
+func WithCopyBinary(copyBinary bool) Option {
+	return func(s *Stamper) {
+		s.copyBinary = copyBinary
+	}
+}
+
+// Default template action delimiters
+const (
+	defaultLeftDelim  = "{{"
+	defaultRightDelim = "}}"
+)
+
+// defaultNoopSuffix marks stamp files that are copied without rendering
+const defaultNoopSuffix = ".noop"
+
+// WithDelims sets the template action delimiters used for file contents, paths and validation
+// An empty delimiter keeps the default "{{" or "}}"
+func WithDelims(left, right string) Option {
+	return func(s *Stamper) {
+		if left != "" {
+			s.leftDelim = left
+		}
+		if right != "" {
+			s.rightDelim = right
+		}
+	}
+}
+
+// WithOutputPrefix nests all output under a subdirectory of the destination
+// The prefix is rendered as a template with the run's variables and must stay inside the destination
+func WithOutputPrefix(prefix string) Option {
+	return func(s *Stamper) {
+		s.outputPrefix = prefix
+	}
+}
+
+// WithOnly restricts the run to files whose output path matches one of the glob patterns
+// Validation is scoped to the selected files as well
+func WithOnly(patterns []string) Option {
+	return func(s *Stamper) {
+		s.only = patterns
+	}
+}
+
+// WithKeepExtension keeps the template extension in the names of rendered files,
+// so main.go.stamp is rendered to main.go.stamp, e.g. for another templating tool
+func WithKeepExtension(keep bool) Option {
+	return func(s *Stamper) {
+		s.keepExtension = keep
+	}
+}
+
+// WithNoopSuffix sets the suffix that, after the stamp extension, marks files copied verbatim
+// The suffix is removed from the output name; an empty suffix keeps the default ".noop"
+func WithNoopSuffix(suffix string) Option {
+	return func(s *Stamper) {
+		if suffix != "" {
+			s.noopSuffix = suffix
+		}
+	}
+}
+
+// WithJobs sets how many files of a sheet are processed concurrently
+// Sheets are still applied one after another; n < 1 uses GOMAXPROCS
+func WithJobs(n int) Option {
+	return func(s *Stamper) {
+		s.jobs = n
+	}
+}
+
+// WithLineEndings converts line endings of written text files to LF or CRLF
+// The eol attribute in .stampattributes overrides it per path; binary files are left unchanged
+func WithLineEndings(eol string) Option {
+	return func(s *Stamper) {
+		s.lineEndings = eol
+	}
+}
+
+// WithDereference materializes symlinks by copying their targets instead of recreating the links
+func WithDereference(deref bool) Option {
+	return func(s *Stamper) {
+		s.dereference = deref
+	}
+}
+
+// WithAllowExternalSymlinks materializes symlinks whose targets resolve outside the sheet
+// By default such links are skipped with a warning so arbitrary host files are not copied
+// It only applies together with WithDereference
+func WithAllowExternalSymlinks(allow bool) Option {
+	return func(s *Stamper) {
+		s.allowExternalSymlinks = allow
+	}
+}
+
+// Policies for destination files that existed before the run
+const (
+	OverwriteError = "error" // Stop with ErrExists (the default)
+	OverwriteSkip  = "skip"  // Leave the existing file alone and warn
+	OverwriteForce = "force" // Replace the existing file
+
+	// OverwriteIfChanged replaces the existing file only if the new content differs
+	// Unchanged files are left untouched, keeping their modification times
+	OverwriteIfChanged = "if-changed"
+)
+
+// WithOverwrite sets how files that already exist in the destination are handled
+// Files written by an earlier sheet in the same run are always replaced or merged
+func WithOverwrite(policy string) Option {
+	return func(s *Stamper) {
+		s.overwrite = policy
+	}
+}
+
+// Actions reported for written files
+const (
+	ActionTemplated = "templated"
+	ActionCopied    = "copied"
+	ActionLinked    = "linked"
+)
+
+// Result summarizes the files produced by a stamp run
+type Result struct {
+	Templated   int           // Files rendered from templates
+	Copied      int           // Files copied as-is (including .noop files)
+	Linked      int           // Symlinks recreated as links
+	Skipped     int           // Files that were not written
+	Unchanged   int           // Existing files left alone because their content was already up to date
+	Overwritten int           // Files that replaced an existing destination file
+	Bytes       int64         // Total bytes written
+	Files       []FileResult  // Written files sorted by path
+	Failed      []*WriteError // Paths that could not be written (with keep-going)
+	OutputDir   string        // Destination joined with the rendered output prefix
+
+	Symlinks         int // Symlinks whose targets were copied
+	ExternalSymlinks int // Copied symlinks whose targets were outside the sheet
+
+	Validation time.Duration // Time spent validating template variables
+	Processing time.Duration // Time spent rendering and writing all sheets
+	Sheets     []SheetStats  // Per-sheet statistics in processing order
+}
+
+// SheetStats describes the work done for a single sheet
+type SheetStats struct {
+	Sheet    string        // Sheet name (base name of the sheet directory)
+	Files    int           // Files written from this sheet
+	Duration time.Duration // Time spent rendering and writing this sheet
+}
+
+// FileResult describes a single written file
+type FileResult struct {
+	Path   string // Slash-separated path relative to the destination
+	Action string // ActionTemplated, ActionCopied or ActionLinked
+	Sheet  string // Sheet that produced the file
+
+	Overwrote string // Earlier sheet of the run whose file this one replaced, if any
+}
+
+// Written returns the number of files written
+func (r *Result) Written() int {
+	return r.Templated + r.Copied + r.Linked
+}
+
+// String returns a one-line summary of the result
+func (r *Result) String() string {
+	linked := ""
+	if r.Linked > 0 {
+		linked = fmt.Sprintf(", %d linked", r.Linked)
+	}
+	unchanged := ""
+	if r.Unchanged > 0 {
+		unchanged = fmt.Sprintf(", %d unchanged", r.Unchanged)
+	}
+	summary := fmt.Sprintf("%d files written (%d templated, %d copied%s), %d skipped%s, %d overwritten, %d bytes",
+		r.Written(), r.Templated, r.Copied, linked, r.Skipped, unchanged, r.Overwritten, r.Bytes)
+	if r.Symlinks > 0 {
+		summary += fmt.Sprintf(", %d symlinks materialized (%d external)", r.Symlinks, r.ExternalSymlinks)
+	}
+	return summary
 }
 
 // New creates a new Stamper with provided template variables and extension
-func New(vars map[string]string, ext string) *Stamper {
+func New(vars map[string]string, ext string, opts ...Option) *Stamper {
 	templateVars := make(map[string]string)
 	for k, v := range vars {
 		templateVars[k] = v
@@ -25,37 +309,123 @@ func New(vars map[string]string, ext string) *Stamper {
 		ext = ".stamp"
 	}
 
-	return &Stamper{
+	s := &Stamper{
 		templateVars: templateVars,
+		templateData: nestVars(templateVars, false),
 		templateExt:  ext,
+		noopSuffix:   defaultNoopSuffix,
+		logger:       nopLogger{},
+		overwrite:    OverwriteError,
+		leftDelim:    defaultLeftDelim,
+		rightDelim:   defaultRightDelim,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // Execute performs the directory copy operation
 // It walks the source directory tree and processes each file
 func (s *Stamper) Execute(src, dest string) error {
-	return s.ExecuteMultiple([]string{src}, dest)
+	_, err := s.ExecuteMultiple([]string{src}, dest)
+	return err
 }
 
 // ExecuteMultiple processes multiple template directories sequentially
-// Later templates overwrite files from earlier templates
-func (s *Stamper) ExecuteMultiple(srcDirs []string, dest string) error {
+// Later templates overwrite files from earlier templates, whatever the overwrite policy
+// Returns a Result describing the files written
+func (s *Stamper) ExecuteMultiple(srcDirs []string, dest string) (*Result, error) {
+	return s.ExecuteMultipleContext(context.Background(), srcDirs, dest)
+}
+
+// ExecuteMultipleContext is ExecuteMultiple with cancellation
+// ctx is checked before each sheet and file; the returned error wraps ctx.Err()
+func (s *Stamper) ExecuteMultipleContext(ctx context.Context, srcDirs []string, dest string) (*Result, error) {
+	results, err := s.ExecuteMultipleToMany(ctx, srcDirs, []string{dest})
+	if err != nil {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+// ExecuteMultipleToMany presses the template directories into each destination in turn
+// Templates are validated once, and every destination is checked before anything is written
+// A failing destination is rolled back and stops the run; destinations before it keep their output
+// Returns one Result per destination, in order
+func (s *Stamper) ExecuteMultipleToMany(ctx context.Context, srcDirs []string, dests []string) ([]*Result, error) {
 	if len(srcDirs) == 0 {
-		return fmt.Errorf("no source directories provided")
+		return nil, fmt.Errorf("no source directories provided")
+	}
+	for _, dest := range dests {
+		if err := checkDest(dest); err != nil {
+			return nil, err
+		}
 	}
 
 	// Pre-validate ALL template variables across all templates
+	validationStart := time.Now()
 	if err := s.validateMultipleTemplateVars(srcDirs); err != nil {
-		return err
+		return nil, err
+	}
+	validation := time.Since(validationStart)
+	s.logger.Log(Event{Event: EventValidationPassed})
+
+	results := make([]*Result, 0, len(dests))
+	for _, dest := range dests {
+		result, err := s.executeValidated(ctx, srcDirs, dest)
+		if err != nil {
+			if len(dests) > 1 {
+				err = fmt.Errorf("destination %s: %w", dest, err)
+			}
+			return nil, err
+		}
+		result.Validation = validation
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// executeValidated presses validated template directories into dest
+func (s *Stamper) executeValidated(ctx context.Context, srcDirs []string, dest string) (*Result, error) {
+	// Nest output under the rendered prefix; result paths stay relative to dest
+	outputDir, err := s.outputDir(dest)
+	if err != nil {
+		return nil, err
 	}
 
+	s.result = &Result{OutputDir: outputDir}
+	s.dest = dest
+	s.output = outputDir
+	s.written = make(map[string]string)
+
+	// Every change is journaled, so a failure leaves the destination as it was
+	s.journal = newJournal()
+	defer func() { s.journal = nil }()
+	processingStart := time.Now()
+	if err := s.processSheets(ctx, srcDirs, outputDir); err != nil {
+		return nil, s.journal.rollback(err)
+	}
+	s.journal.discard()
+	s.result.Processing = time.Since(processingStart)
+
+	// Later sheets replace earlier entries for the same path; report each path once, sorted
+	s.result.Files = dedupeFiles(s.result.Files)
+	return s.result, nil
+}
+
+// processSheets creates outputDir and processes each sheet into it in order
+func (s *Stamper) processSheets(ctx context.Context, srcDirs []string, outputDir string) error {
 	// Create destination directory once
-	if err := os.MkdirAll(dest, 0755); err != nil {
+	if err := s.mkdirAll(outputDir); err != nil {
 		return fmt.Errorf("failed to create destination directory: %w", err)
 	}
 
-	// Process each template sequentially
 	for i, src := range srcDirs {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("stamp interrupted: %w", err)
+		}
+
 		// Validate source exists
 		srcInfo, err := os.Stat(src)
 		if err != nil {
@@ -66,17 +436,113 @@ func (s *Stamper) ExecuteMultiple(srcDirs []string, dest string) error {
 		}
 
 		// Walk and process this template directory
-		if err := s.processTemplateDir(src, dest); err != nil {
+		s.sheet = filepath.Base(src)
+		sheetStart, written := time.Now(), s.result.Written()
+		if err := s.processTemplateDir(ctx, src, outputDir); err != nil {
 			return fmt.Errorf("failed to process template %d (%s): %w", i+1, src, err)
 		}
+		s.result.Sheets = append(s.result.Sheets, SheetStats{
+			Sheet:    s.sheet,
+			Files:    s.result.Written() - written,
+			Duration: time.Since(sheetStart),
+		})
 	}
-
 	return nil
 }
 
+// saveDest records destPath in the journal of the current run before it is changed
+func (s *Stamper) saveDest(destPath string) error {
+	if s.journal == nil {
+		return nil
+	}
+	return s.journal.save(destPath)
+}
+
+// mkdirAll creates dir with its parents, journaling the directories it adds
+// Symlinks on the way that lead outside the destination are refused rather than followed
+func (s *Stamper) mkdirAll(dir string) error {
+	if err := s.checkLinks(dir); err != nil {
+		return err
+	}
+	if s.journal != nil {
+		if err := s.journal.saveDirs(dir); err != nil {
+			return err
+		}
+	}
+	return os.MkdirAll(dir, 0755)
+}
+
+// outputDir returns dest joined with the rendered output prefix
+func (s *Stamper) outputDir(dest string) (string, error) {
+	if s.outputPrefix == "" {
+		return dest, nil
+	}
+
+	tmpl, err := s.newTemplate("output-prefix").Option("missingkey=error").Parse(s.outputPrefix)
+	if err != nil {
+		return "", fmt.Errorf("invalid output prefix: %w", err)
+	}
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, s.templateData); err != nil {
+		return "", fmt.Errorf("failed to render output prefix: %w", err)
+	}
+	prefix := buf.String()
+	if slices.Contains(strings.Split(filepath.ToSlash(prefix), "/"), "..") {
+		return "", fmt.Errorf("%w: output prefix %s contains ..", ErrUnsafePath, prefix)
+	}
+	return containedPath(dest, prefix)
+}
+
+// outputPrefixSource names the output prefix where validation and analysis report template paths
+const outputPrefixSource = "(output prefix)"
+
+// dedupeFiles keeps the last entry for each path and sorts by path
+func dedupeFiles(files []FileResult) []FileResult {
+	last := make(map[string]int, len(files))
+	for i, f := range files {
+		last[f.Path] = i
+	}
+	result := make([]FileResult, 0, len(last))
+	for i, f := range files {
+		if last[f.Path] == i {
+			result = append(result, f)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Path < result[j].Path
+	})
+	return result
+}
+
+// sheetFile is a file found while walking a sheet directory
+type sheetFile struct {
+	srcPath   string      // Path of the source file
+	destPath  string      // Destination path before extension removal
+	sortKey   string      // Slash-separated output path used for ordering
+	mode      os.FileMode // Mode of the source file
+	relocated bool        // Moved by front matter, so its directory may not exist yet
+}
+
 // processTemplateDir walks a single template directory and processes files
-func (s *Stamper) processTemplateDir(src, dest string) error {
-	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+// Directories are created during the walk; files are then processed in
+// destination path order so logs and results are reproducible
+func (s *Stamper) processTemplateDir(ctx context.Context, src, dest string) error {
+	attrs, err := loadAttributes(src)
+	if err != nil {
+		return err
+	}
+	s.attrs = attrs
+	if s.partials, err = loadPartials(src); err != nil {
+		return err
+	}
+	sheet, err := config.LoadSheet(src)
+	if err != nil {
+		return err
+	}
+	s.permissions = sheet.Permissions
+
+	var files []sheetFile
+	err = filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -87,30 +553,187 @@ func (s *Stamper) processTemplateDir(src, dest string) error {
 			return fmt.Errorf("failed to get relative path: %w", err)
 		}
 
-		// Calculate destination path
-		destPath := filepath.Join(dest, relPath)
+		// The attributes, settings, schema and partials files configure the sheet and are never written
+		if relPath == AttributesFile || relPath == config.SheetFile || relPath == config.SchemaFile || relPath == PartialsFile {
+			return nil
+		}
+
+		// Leave out files not selected by --only
+		if !info.IsDir() && !s.selectedSource(toSlash(relPath, filepath.Separator)) {
+			return nil
+		}
+
+		// Expand variables in the path, then refuse anything outside dest
+		// With --only, directories that cannot be rendered hold no validated selected files
+		relPath, err = s.renderPath(relPath)
+		if err != nil {
+			if info.IsDir() && len(s.only) > 0 {
+				return filepath.SkipDir
+			}
+			return err
+		}
+
+		// Front matter may skip a template or move it elsewhere in dest
+		relocated := false
+		if info.Mode().IsRegular() && s.isTemplateFile(path) {
+			front, err := s.readFrontMatter(path)
+			if err != nil {
+				return err
+			}
+			if front != nil {
+				skip, err := front.skip()
+				if err != nil {
+					return fmt.Errorf("%s: %w", path, err)
+				}
+				if skip {
+					destPath, err := containedPath(dest, relPath)
+					if err != nil {
+						return err
+					}
+					s.skipFrontMatter(destPath)
+					return nil
+				}
+				if front.Path != "" {
+					relPath = filepath.FromSlash(front.Path) + s.templateSuffix(path)
+					relocated = true
+				}
+			}
+		}
 
-		// Handle directories
+		destPath, err := containedPath(dest, relPath)
+		if err != nil {
+			return err
+		}
+
+		// Handle directories; with --only they are created for selected files instead
 		if info.IsDir() {
-			return os.MkdirAll(destPath, 0755)
+			if len(s.only) > 0 {
+				return nil
+			}
+			if err := s.mkdirAll(destPath); err != nil {
+				if err := s.writeFailed(newWriteError(destPath, err)); err != nil {
+					return err
+				}
+				return filepath.SkipDir
+			}
+			return nil
 		}
 
-		// Handle files
-		return s.processFile(path, destPath)
+		files = append(files, sheetFile{
+			srcPath:   path,
+			destPath:  destPath,
+			sortKey:   s.outputRelPath(toSlash(relPath, filepath.Separator)),
+			mode:      info.Mode(),
+			relocated: relocated,
+		})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].sortKey < files[j].sortKey
 	})
+
+	// Handle files, in parallel when jobs allow
+	return s.processFiles(ctx, src, files)
 }
 
-// isTmplNoopFile checks if a file ends with the template extension plus .noop
-func (s *Stamper) isTmplNoopFile(path string) bool {
-	return strings.HasSuffix(path, s.templateExt+".noop")
+// processSheetFile writes a single file of the sheet rooted at src
+func (s *Stamper) processSheetFile(src string, f sheetFile) error {
+	if len(s.only) > 0 || f.relocated {
+		if err := s.mkdirAll(filepath.Dir(f.destPath)); err != nil {
+			return s.writeFailed(newWriteError(filepath.Dir(f.destPath), err))
+		}
+	}
+	if IsSpecialFile(f.mode) {
+		return s.processSpecial(f)
+	}
+
+	var err error
+	if f.mode&os.ModeSymlink != 0 && !s.dereference && !s.isStampFile(f.srcPath) {
+		err = s.recreateSymlink(f)
+	} else if f.mode&os.ModeSymlink != 0 && s.processSymlink(src, f) {
+		return nil
+	} else {
+		err = s.processFile(f.srcPath, f.destPath)
+	}
+	if err != nil {
+		var writeErr *WriteError
+		if !errors.As(err, &writeErr) {
+			return err
+		}
+		return s.writeFailed(writeErr)
+	}
+	return nil
+}
+
+// writeFailed records a write failure under keep-going, or returns it otherwise
+func (s *Stamper) writeFailed(err *WriteError) error {
+	if !s.keepGoing {
+		return err
+	}
+	s.result.Skipped++
+	s.result.Failed = append(s.result.Failed, err)
+	relPath, _ := relSlashPath(s.dest, err.Path)
+	s.logger.Log(Event{Event: EventFileSkipped, Path: relPath, Sheet: s.sheet, Message: "write failed"})
+	s.logger.Log(Event{Event: EventWarning, Path: relPath, Sheet: s.sheet, Message: err.Error()})
+	return nil
+}
+
+// processSpecial recreates a FIFO when allowed, and otherwise skips the file with a warning
+// Special files are never read, since reading a FIFO or device can block forever
+func (s *Stamper) processSpecial(f sheetFile) error {
+	if s.includeSpecial && CanRecreateSpecial(f.mode) {
+		if keep, err := s.keepExisting(f.destPath); keep || err != nil {
+			return err
+		}
+		overwrite := exists(f.destPath)
+		if err := s.saveDest(f.destPath); err != nil {
+			return err
+		}
+		if err := s.prepareDest(f.destPath); err != nil {
+			return err
+		}
+		if err := RecreateSpecial(f.destPath, f.mode); err != nil {
+			return fmt.Errorf("failed to create named pipe %s: %w", f.destPath, err)
+		}
+		s.recordWrite(ActionCopied, f.destPath, overwrite, 0)
+		return nil
+	}
+
+	relPath, _ := relSlashPath(s.dest, f.destPath)
+	if s.result != nil {
+		s.result.Skipped++
+	}
+	s.logger.Log(Event{Event: EventFileSkipped, Path: relPath, Sheet: s.sheet, Message: "special file"})
+	s.logger.Log(Event{Event: EventWarning, Path: relPath, Sheet: s.sheet,
+		Message: fmt.Sprintf("skipped special file %s (%s)", relPath, f.mode.Type())})
+	return nil
 }
 
-// removeNoopExtension strips .noop from the end of a path
-func removeNoopExtension(path string) string {
-	if strings.HasSuffix(path, ".noop") {
-		return strings.TrimSuffix(path, ".noop")
+// outputRelPath returns the output path for a sheet-relative source path
+func (s *Stamper) outputRelPath(relPath string) string {
+	if s.isTmplNoopFile(relPath) {
+		return s.removeNoopExtension(relPath)
 	}
-	return path
+	return s.removeTemplateExtension(relPath)
+}
+
+// isStampFile reports whether path is rendered or copied as a stamp file, including .noop files
+func (s *Stamper) isStampFile(path string) bool {
+	return s.isTemplateFile(path) || s.isTmplNoopFile(path)
+}
+
+// isTmplNoopFile checks if a file ends with the template extension plus the noop suffix
+func (s *Stamper) isTmplNoopFile(path string) bool {
+	return strings.HasSuffix(path, s.templateExt+s.noopSuffix)
+}
+
+// removeNoopExtension strips the noop suffix from the end of a path
+func (s *Stamper) removeNoopExtension(path string) string {
+	return strings.TrimSuffix(path, s.noopSuffix)
 }
 
 // processFile determines whether to template or copy a file
@@ -120,34 +743,232 @@ func (s *Stamper) processFile(srcPath, destPath string) error {
 		return s.processTmplNoop(srcPath, destPath)
 	}
 
-	// Check if file ends with custom extension
-	if strings.HasSuffix(srcPath, s.templateExt) {
+	// Check if file ends with custom extension (or its [[ ]] variant)
+	if s.isTemplateFile(srcPath) {
 		return s.processTemplate(srcPath, destPath)
 	}
 	return s.copyFile(srcPath, destPath)
 }
 
 // copyFile copies a regular file from src to dest
+// Files that are neither merged nor converted are streamed instead of read into memory
 func (s *Stamper) copyFile(src, dest string) error {
+	if keep, err := s.keepExisting(dest); keep || err != nil {
+		return err
+	}
+	overwrite := exists(dest)
+
+	relPath, _ := relSlashPath(s.dest, dest)
+	merge, err := s.merger(dest, s.patternPath(dest))
+	if err != nil {
+		return err
+	}
+	eol, err := s.attrs.lineEndings(s.patternPath(dest), s.lineEndings)
+	if err != nil {
+		return err
+	}
+	if merge == nil && eol != LineEndingsLF && eol != LineEndingsCRLF && s.overwrite != OverwriteIfChanged {
+		if err := s.saveDest(dest); err != nil {
+			return err
+		}
+		if err := s.prepareDest(dest); err != nil {
+			return err
+		}
+		n, err := streamWithMode(src, dest)
+		if err != nil {
+			return err
+		}
+		if err := s.applyPermissions(dest); err != nil {
+			return err
+		}
+		s.recordWrite(ActionCopied, dest, overwrite, n)
+		return nil
+	}
+
 	// Read source file
 	content, err := os.ReadFile(src)
 	if err != nil {
 		return fmt.Errorf("failed to read source file: %w", err)
 	}
 
-	// Write to destination with standard permissions
-	if err := os.WriteFile(dest, content, 0644); err != nil {
-		return fmt.Errorf("failed to write destination file: %w", err)
+	// Merge with the file written by an earlier sheet when the strategy allows
+	if merge != nil {
+		if content, err = mergeExisting(dest, relPath, content, merge); err != nil {
+			return err
+		}
+	}
+	content = convertLineEndings(content, eol)
+
+	if s.keepUnchanged(dest, content) {
+		return nil
+	}
+	if err := s.saveDest(dest); err != nil {
+		return err
+	}
+	if err := s.prepareDest(dest); err != nil {
+		return err
+	}
+	if err := writeWithMode(src, dest, content); err != nil {
+		return err
+	}
+	if err := s.applyPermissions(dest); err != nil {
+		return err
 	}
 
+	s.recordWrite(ActionCopied, dest, overwrite, int64(len(content)))
 	return nil
 }
 
-// processTmplNoop copies a .tmpl.noop file, removing only the .noop extension
+// recordWrite adds a written file to the current result and logs it
+func (s *Stamper) recordWrite(action, destPath string, overwrite bool, n int64) {
+	relPath, err := relSlashPath(s.dest, destPath)
+	if err != nil {
+		relPath = destPath
+	}
+	overwrote := s.written[destPath]
+	if overwrote == s.sheet {
+		overwrote = ""
+	}
+
+	if s.result != nil {
+		switch action {
+		case ActionTemplated:
+			s.result.Templated++
+		case ActionLinked:
+			s.result.Linked++
+		default:
+			s.result.Copied++
+		}
+		if overwrite {
+			s.result.Overwritten++
+		}
+		s.result.Bytes += n
+		s.result.Files = append(s.result.Files, FileResult{Path: relPath, Action: action, Sheet: s.sheet, Overwrote: overwrote})
+	}
+	if s.written != nil {
+		s.written[destPath] = s.sheet
+	}
+
+	s.logger.Log(Event{Event: EventFileWritten, Path: relPath, Action: action, Sheet: s.sheet, Overwrote: overwrote})
+}
+
+// exists reports whether a file or directory exists at path
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// keepExisting applies the overwrite policy to destPath
+// It returns true if the file must be left alone, and ErrExists under OverwriteError
+func (s *Stamper) keepExisting(destPath string) (bool, error) {
+	if s.overwrite == OverwriteForce || s.overwrite == OverwriteIfChanged || s.written[destPath] != "" || !exists(destPath) {
+		return false, nil
+	}
+
+	relPath, _ := relSlashPath(s.dest, destPath)
+	if s.overwrite != OverwriteSkip {
+		return false, fmt.Errorf("%w: %s", ErrExists, relPath)
+	}
+	if s.result != nil {
+		s.result.Skipped++
+	}
+	s.logger.Log(Event{Event: EventFileSkipped, Path: relPath, Sheet: s.sheet, Message: "exists"})
+	s.logger.Log(Event{Event: EventWarning, Path: relPath, Sheet: s.sheet,
+		Message: fmt.Sprintf("skipped existing file %s", relPath)})
+	return true, nil
+}
+
+// keepUnchanged reports whether, under OverwriteIfChanged, destPath already holds content
+// The file is then left untouched and counted as unchanged instead of written
+func (s *Stamper) keepUnchanged(destPath string, content []byte) bool {
+	if s.overwrite != OverwriteIfChanged || isSymlink(destPath) {
+		return false
+	}
+	existing, err := os.ReadFile(destPath)
+	if err != nil || !bytes.Equal(existing, content) {
+		return false
+	}
+	s.recordUnchanged(destPath)
+	return true
+}
+
+// recordUnchanged adds a file left untouched under OverwriteIfChanged to the current result
+// It still counts as written by this run so later sheets merge with or replace it
+func (s *Stamper) recordUnchanged(destPath string) {
+	relPath, _ := relSlashPath(s.dest, destPath)
+	if s.result != nil {
+		s.result.Unchanged++
+	}
+	if s.written != nil {
+		s.written[destPath] = s.sheet
+	}
+	s.logger.Log(Event{Event: EventFileSkipped, Path: relPath, Sheet: s.sheet, Message: "unchanged"})
+}
+
+// writeWithMode writes content to dest with the permission bits of src
+// The mode is applied explicitly so overwritten files pick it up as well
+func writeWithMode(src, dest string, content []byte) error {
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return fmt.Errorf("failed to stat source file: %w", err)
+	}
+	if err := os.WriteFile(dest, content, srcInfo.Mode().Perm()); err != nil {
+		return newWriteError(dest, err)
+	}
+	if err := os.Chmod(dest, srcInfo.Mode().Perm()); err != nil {
+		return newWriteError(dest, err)
+	}
+	return nil
+}
+
+// applyPermissions sets the mode the sheet's permissions declare for a written file
+// Files that no pattern matches keep the permission bits of their source
+func (s *Stamper) applyPermissions(destPath string) error {
+	mode, ok := s.permissions.Lookup(s.patternPath(destPath))
+	if !ok {
+		return nil
+	}
+	if err := os.Chmod(destPath, mode); err != nil {
+		return newWriteError(destPath, err)
+	}
+	return nil
+}
+
+// streamWithMode copies src to dest without holding the content in memory
+// and applies the permission bits of src like writeWithMode
+func streamWithMode(src, dest string) (int64, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read source file: %w", err)
+	}
+	defer in.Close()
+	srcInfo, err := in.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat source file: %w", err)
+	}
+
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, srcInfo.Mode().Perm())
+	if err != nil {
+		return 0, newWriteError(dest, err)
+	}
+	n, err := io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return n, newWriteError(dest, err)
+	}
+	if err := os.Chmod(dest, srcInfo.Mode().Perm()); err != nil {
+		return n, newWriteError(dest, err)
+	}
+	return n, nil
+}
+
+// processTmplNoop copies a .tmpl.noop file, removing only the noop suffix
 // This allows template files to be included in output without variable expansion
 func (s *Stamper) processTmplNoop(srcPath, destPath string) error {
-	// Remove .noop extension from destination (keeping .tmpl)
-	destPath = removeNoopExtension(destPath)
+	// Remove the noop suffix from destination (keeping .tmpl)
+	destPath = s.removeNoopExtension(destPath)
 
 	// Copy file as-is without template processing
 	return s.copyFile(srcPath, destPath)
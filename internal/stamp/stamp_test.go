@@ -1,10 +1,15 @@
 package stamp
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 // TestExecute_ValidDirectories tests basic directory copying
@@ -495,6 +500,46 @@ func TestExecute_TmplNoopNoValidation(t *testing.T) {
 		"{{.undefined}} {{.missing}} {{.notProvided}}")
 }
 
+// TestExecute_NoopSuffix tests a custom noop suffix is copied verbatim with only the suffix removed
+func TestExecute_NoopSuffix(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+
+	createTestFile(t, src, "config.yaml.stamp.raw", "name: {{.undefined}}")
+	createTestFile(t, src, "other.txt.stamp.noop", "name: {{.name}}")
+
+	stamper := New(map[string]string{"name": "alice"}, ".stamp", WithNoopSuffix(".raw"))
+	if err := stamper.Execute(src, dest); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	assertFileContent(t, filepath.Join(dest, "config.yaml.stamp"), "name: {{.undefined}}")
+	assertFileNotExists(t, filepath.Join(dest, "config.yaml.stamp.raw"))
+
+	// .noop is an ordinary extension once another suffix is configured
+	assertFileContent(t, filepath.Join(dest, "other.txt.stamp.noop"), "name: {{.name}}")
+}
+
+// TestExecute_KeepExtension tests rendered files keep the template extension while noop files stay unexpanded
+func TestExecute_KeepExtension(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+
+	createTestFile(t, src, "values.yaml.stamp", "name: {{.name}}")
+	createTestFile(t, src, "raw.yaml.stamp.noop", "name: {{.name}}")
+	createTestFile(t, src, "static.txt", "static")
+
+	stamper := New(map[string]string{"name": "alice"}, ".stamp", WithKeepExtension(true))
+	if err := stamper.Execute(src, dest); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	assertFileContent(t, filepath.Join(dest, "values.yaml.stamp"), "name: alice")
+	assertFileNotExists(t, filepath.Join(dest, "values.yaml"))
+	assertFileContent(t, filepath.Join(dest, "raw.yaml.stamp"), "name: {{.name}}")
+	assertFileContent(t, filepath.Join(dest, "static.txt"), "static")
+}
+
 // TestExecute_TmplNoopInSubdirectory tests nested .tmpl.noop files
 func TestExecute_TmplNoopInSubdirectory(t *testing.T) {
 	src := t.TempDir()
@@ -672,3 +717,605 @@ func TestExecute_StampExtension(t *testing.T) {
 	// .stamp.noop file should not be processed
 	assertFileContent(t, filepath.Join(dest, "template.txt.stamp"), "example: {{.value}}")
 }
+
+// TestExecuteMultiple_Result tests that the result counts each kind of write
+func TestExecuteMultiple_Result(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+
+	createTestFile(t, src, "hello.txt.stamp", "Hello {{.name}}!")
+	createTestFile(t, src, "static.txt", "static")
+	createTestFile(t, dest, "hello.txt", "old")
+
+	stamper := New(map[string]string{"name": "alice"}, ".stamp", WithOverwrite(OverwriteForce))
+	result, err := stamper.ExecuteMultiple([]string{src}, dest)
+	if err != nil {
+		t.Fatalf("ExecuteMultiple() failed: %v", err)
+	}
+
+	if result.Templated != 1 || result.Copied != 1 {
+		t.Errorf("Templated = %d, Copied = %d, want 1 and 1", result.Templated, result.Copied)
+	}
+	if result.Overwritten != 1 {
+		t.Errorf("Overwritten = %d, want 1", result.Overwritten)
+	}
+	if result.Bytes != int64(len("Hello alice!")+len("static")) {
+		t.Errorf("Bytes = %d, want %d", result.Bytes, len("Hello alice!")+len("static"))
+	}
+}
+
+// TestExecuteMultiple_FilesSortedByDestination tests that results are ordered by output path
+func TestExecuteMultiple_FilesSortedByDestination(t *testing.T) {
+	base := t.TempDir()
+	extra := t.TempDir()
+	dest := t.TempDir()
+
+	if err := os.MkdirAll(filepath.Join(base, "a"), 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	createTestFile(t, base, "a/b.txt", "b")
+	createTestFile(t, base, "a-c.txt.stamp", "c")
+	createTestFile(t, base, "shared.txt", "base")
+	createTestFile(t, extra, "shared.txt", "extra")
+
+	result, err := New(nil, ".stamp").ExecuteMultiple([]string{base, extra}, dest)
+	if err != nil {
+		t.Fatalf("ExecuteMultiple() failed: %v", err)
+	}
+
+	var paths []string
+	for _, f := range result.Files {
+		paths = append(paths, f.Path)
+	}
+	want := []string{"a-c.txt", "a/b.txt", "shared.txt"}
+	if strings.Join(paths, ",") != strings.Join(want, ",") {
+		t.Errorf("Files = %v, want %v", paths, want)
+	}
+
+	// The later sheet's entry wins for overlapping paths
+	if last := result.Files[2]; last.Sheet != filepath.Base(extra) {
+		t.Errorf("shared.txt sheet = %q, want %q", last.Sheet, filepath.Base(extra))
+	}
+}
+
+// TestExecuteMultiple_Overwrote tests that results name the earlier sheet a file replaced
+func TestExecuteMultiple_Overwrote(t *testing.T) {
+	base := t.TempDir()
+	extra := t.TempDir()
+	dest := t.TempDir()
+
+	createTestFile(t, base, "config.yaml.stamp", "name: {{.name}}")
+	createTestFile(t, base, "only.txt", "base")
+	createTestFile(t, extra, "config.yaml.stamp", "name: {{.name}}!")
+
+	result, err := New(map[string]string{"name": "alice"}, ".stamp").ExecuteMultiple([]string{base, extra}, dest)
+	if err != nil {
+		t.Fatalf("ExecuteMultiple() failed: %v", err)
+	}
+
+	want := []FileResult{
+		{Path: "config.yaml", Action: ActionTemplated, Sheet: filepath.Base(extra), Overwrote: filepath.Base(base)},
+		{Path: "only.txt", Action: ActionCopied, Sheet: filepath.Base(base)},
+	}
+	if len(result.Files) != len(want) {
+		t.Fatalf("Files = %+v, want %+v", result.Files, want)
+	}
+	for i := range want {
+		if result.Files[i] != want[i] {
+			t.Errorf("Files[%d] = %+v, want %+v", i, result.Files[i], want[i])
+		}
+	}
+	assertFileContent(t, filepath.Join(dest, "config.yaml"), "name: alice!")
+}
+
+// TestExecuteMultipleToMany tests that every destination receives the output
+// while the templates are validated once
+func TestExecuteMultipleToMany(t *testing.T) {
+	src := t.TempDir()
+	createTestFile(t, src, "config.yaml.stamp", "name: {{.name}}")
+	createTestFile(t, src, "README.md", "readme")
+	dests := []string{t.TempDir(), filepath.Join(t.TempDir(), "new")}
+
+	logger := &recordingLogger{}
+	stamper := New(map[string]string{"name": "alice"}, ".stamp", WithLogger(logger))
+	results, err := stamper.ExecuteMultipleToMany(context.Background(), []string{src}, dests)
+	if err != nil {
+		t.Fatalf("ExecuteMultipleToMany() failed: %v", err)
+	}
+	if len(results) != len(dests) {
+		t.Fatalf("len(results) = %d, want %d", len(results), len(dests))
+	}
+	for i, dest := range dests {
+		assertFileContent(t, filepath.Join(dest, "config.yaml"), "name: alice")
+		assertFileContent(t, filepath.Join(dest, "README.md"), "readme")
+		if results[i].Templated != 1 || results[i].Copied != 1 {
+			t.Errorf("results[%d] = %s, want 1 templated and 1 copied", i, results[i])
+		}
+	}
+
+	validations := 0
+	for _, e := range logger.events {
+		if e.Event == EventValidationPassed {
+			validations++
+		}
+	}
+	if validations != 1 {
+		t.Errorf("validation_passed logged %d times, want 1", validations)
+	}
+}
+
+// TestExecuteMultipleToMany_ErrorNamesDestination tests that a failure is reported
+// with the destination it happened in, after earlier destinations were written
+func TestExecuteMultipleToMany_ErrorNamesDestination(t *testing.T) {
+	src := t.TempDir()
+	createTestFile(t, src, "a.txt", "new")
+	first := t.TempDir()
+	second := t.TempDir()
+	createTestFile(t, second, "a.txt", "old")
+
+	stamper := New(nil, ".stamp")
+	_, err := stamper.ExecuteMultipleToMany(context.Background(), []string{src}, []string{first, second})
+	if !errors.Is(err, ErrExists) || !strings.Contains(err.Error(), "destination "+second) {
+		t.Fatalf("ExecuteMultipleToMany() error = %v, want ErrExists naming %s", err, second)
+	}
+	assertFileContent(t, filepath.Join(first, "a.txt"), "new")
+	assertFileContent(t, filepath.Join(second, "a.txt"), "old")
+}
+
+// recordingLogger collects events for assertions
+type recordingLogger struct {
+	events []Event
+}
+
+func (r *recordingLogger) Log(e Event) {
+	r.events = append(r.events, e)
+}
+
+// containsEvent reports whether events contains want, ignoring the sheet name
+func containsEvent(events []Event, want Event) bool {
+	for _, e := range events {
+		e.Sheet = ""
+		if e == want {
+			return true
+		}
+	}
+	return false
+}
+
+// TestExecute_EmptyOutputWarning tests that whitespace-only output is reported
+func TestExecute_EmptyOutputWarning(t *testing.T) {
+	src := t.TempDir()
+	createTestFile(t, src, "empty.txt.stamp", "{{if .on}}content{{end}}\n")
+	createTestFile(t, src, "full.txt.stamp", "on={{.on}}")
+
+	logger := &recordingLogger{}
+	stamper := New(map[string]string{"on": ""}, ".stamp", WithLogger(logger))
+	if err := stamper.Execute(src, t.TempDir()); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	var warnings []Event
+	for _, e := range logger.events {
+		if e.Event == EventWarning {
+			warnings = append(warnings, e)
+		}
+	}
+	if len(warnings) != 1 || warnings[0].Path != "empty.txt" {
+		t.Errorf("warnings = %+v, want a single warning for empty.txt", warnings)
+	}
+}
+
+// slowLogger delays every event to simulate a slow operation
+type slowLogger struct {
+	delay time.Duration
+}
+
+func (l slowLogger) Log(Event) {
+	time.Sleep(l.delay)
+}
+
+// TestExecuteMultipleContext_Timeout tests that the deadline stops file processing
+func TestExecuteMultipleContext_Timeout(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt", "c.txt", "d.txt"} {
+		createTestFile(t, src, name, name)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	stamper := New(nil, ".stamp", WithLogger(slowLogger{delay: 50 * time.Millisecond}))
+	_, err := stamper.ExecuteMultipleContext(ctx, []string{src}, dest)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("ExecuteMultipleContext() error = %v, want DeadlineExceeded", err)
+	}
+	assertFileNotExists(t, filepath.Join(dest, "d.txt"))
+}
+
+// TestExecute_RangeOverAllVariables tests dumping every variable in sorted key order
+func TestExecute_RangeOverAllVariables(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+	createTestFile(t, src, "debug.env.stamp", "{{range $k, $v := .}}{{$k}}={{$v}}\n{{end}}")
+
+	vars := map[string]string{"zeta": "3", "alpha": "1", "mid": "2"}
+	for i := 0; i < 3; i++ {
+		if err := New(vars, ".stamp", WithOverwrite(OverwriteForce)).Execute(src, dest); err != nil {
+			t.Fatalf("Execute() failed: %v", err)
+		}
+		assertFileContent(t, filepath.Join(dest, "debug.env"), "alpha=1\nmid=2\nzeta=3\n")
+	}
+}
+
+// TestExecute_SkipEmpty tests that empty rendered output produces no file
+func TestExecute_SkipEmpty(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+	createTestFile(t, src, "ci.yaml.stamp", "{{if .ci}}ci: true{{end}}\n")
+	createTestFile(t, src, "README.md.stamp", "# {{.name}}")
+
+	logger := &recordingLogger{}
+	stamper := New(map[string]string{"ci": "", "name": "app"}, ".stamp", WithSkipEmpty(true), WithLogger(logger))
+	result, err := stamper.ExecuteMultiple([]string{src}, dest)
+	if err != nil {
+		t.Fatalf("ExecuteMultiple() failed: %v", err)
+	}
+
+	assertFileNotExists(t, filepath.Join(dest, "ci.yaml"))
+	assertFileContent(t, filepath.Join(dest, "README.md"), "# app")
+	if result.Templated != 1 || result.Skipped != 1 {
+		t.Errorf("result = %s, want 1 templated and 1 skipped", result)
+	}
+	for _, e := range logger.events {
+		if e.Event == EventWarning {
+			t.Errorf("unexpected warning: %+v", e)
+		}
+	}
+}
+
+// TestExecuteMultiple_SkipEmptyKeepsEarlierFile tests that a template skipped for blank output
+// leaves a file written by an earlier sheet, even an empty one, in place and in the result
+func TestExecuteMultiple_SkipEmptyKeepsEarlierFile(t *testing.T) {
+	base := t.TempDir()
+	overlay := t.TempDir()
+	dest := t.TempDir()
+	createTestFile(t, base, ".gitkeep", "")
+	createTestFile(t, overlay, ".gitkeep.stamp", "{{if .ci}}ci: true{{end}}")
+	createTestFile(t, overlay, "ci.yaml.stamp", "{{if .ci}}ci: true{{end}}")
+
+	stamper := New(map[string]string{"ci": ""}, ".stamp", WithSkipEmpty(true))
+	result, err := stamper.ExecuteMultiple([]string{base, overlay}, dest)
+	if err != nil {
+		t.Fatalf("ExecuteMultiple() failed: %v", err)
+	}
+
+	assertFileContent(t, filepath.Join(dest, ".gitkeep"), "")
+	assertFileNotExists(t, filepath.Join(dest, "ci.yaml"))
+	if len(result.Files) != 1 || result.Files[0].Path != ".gitkeep" {
+		t.Errorf("Files = %+v, want only .gitkeep", result.Files)
+	}
+	if result.Skipped != 2 {
+		t.Errorf("Skipped = %d, want 2", result.Skipped)
+	}
+}
+
+// TestExecuteMultiple_OutputPrefix tests nesting all output under a rendered prefix
+func TestExecuteMultiple_OutputPrefix(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+	createTestFile(t, src, "README.md.stamp", "# {{.name}}")
+	if err := os.MkdirAll(filepath.Join(src, "cmd"), 0755); err != nil {
+		t.Fatalf("failed to create cmd dir: %v", err)
+	}
+	createTestFile(t, src, "cmd/main.go", "package main")
+
+	stamper := New(map[string]string{"name": "app"}, ".stamp", WithOutputPrefix("services/{{.name}}"))
+	result, err := stamper.ExecuteMultiple([]string{src}, dest)
+	if err != nil {
+		t.Fatalf("ExecuteMultiple() failed: %v", err)
+	}
+
+	assertFileContent(t, filepath.Join(dest, "services", "app", "README.md"), "# app")
+	assertFileContent(t, filepath.Join(dest, "services", "app", "cmd", "main.go"), "package main")
+	assertFileNotExists(t, filepath.Join(dest, "README.md"))
+
+	// Result paths are relative to the destination and include the prefix
+	if len(result.Files) != 2 || result.Files[0].Path != "services/app/README.md" {
+		t.Errorf("result.Files = %+v, want paths under services/app", result.Files)
+	}
+}
+
+// TestExecuteMultiple_OutputPrefixContained tests that a prefix cannot escape the destination
+func TestExecuteMultiple_OutputPrefixContained(t *testing.T) {
+	src := t.TempDir()
+	createTestFile(t, src, "a.txt", "a")
+
+	for _, prefix := range []string{"../outside", "{{.dir}}", "{{.missing}}"} {
+		stamper := New(map[string]string{"dir": "../../x"}, ".stamp", WithOutputPrefix(prefix))
+		if _, err := stamper.ExecuteMultiple([]string{src}, t.TempDir()); err == nil {
+			t.Errorf("ExecuteMultiple() with prefix %q should fail", prefix)
+		}
+	}
+}
+
+// TestExecuteMultiple_OutputPrefixDotDot tests that a prefix with .. is refused even inside the destination
+func TestExecuteMultiple_OutputPrefixDotDot(t *testing.T) {
+	src := t.TempDir()
+	createTestFile(t, src, "a.txt", "a")
+
+	stamper := New(map[string]string{"svc": "../billing"}, ".stamp", WithOutputPrefix("services/x/{{.svc}}"))
+	if _, err := stamper.ExecuteMultiple([]string{src}, t.TempDir()); !errors.Is(err, ErrUnsafePath) {
+		t.Errorf("ExecuteMultiple() error = %v, want ErrUnsafePath", err)
+	}
+}
+
+// TestExecuteMultiple_OutputPrefixValidation tests that prefix variables are validated with the sheets
+func TestExecuteMultiple_OutputPrefixValidation(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+	createTestFile(t, src, "a.txt", "a")
+
+	stamper := New(nil, ".stamp", WithOutputPrefix("services/{{.svc}}"))
+	_, err := stamper.ExecuteMultiple([]string{src}, dest)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("ExecuteMultiple() error = %v, want *ValidationError", err)
+	}
+	if paths := validationErr.MissingVars["svc"]; len(paths) != 1 || paths[0] != outputPrefixSource {
+		t.Errorf("MissingVars[svc] = %v, want the output prefix", paths)
+	}
+	assertFileNotExists(t, filepath.Join(dest, "services"))
+}
+
+// TestExecute_TemplatedPaths tests expanding variables in file and directory names
+func TestExecute_TemplatedPaths(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+
+	if err := os.MkdirAll(filepath.Join(src, "{{.pkg}}"), 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	createTestFile(t, src, filepath.Join("{{.pkg}}", "main.go.stamp"), "package {{.pkg}}\n")
+	createTestFile(t, src, "{{.name}}.md", "static\n")
+
+	stamper := New(map[string]string{"pkg": "server", "name": "README"}, ".stamp")
+	if err := stamper.Execute(src, dest); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	assertFileContent(t, filepath.Join(dest, "server", "main.go"), "package server\n")
+	assertFileContent(t, filepath.Join(dest, "README.md"), "static\n")
+	assertFileNotExists(t, filepath.Join(dest, "{{.pkg}}"))
+}
+
+// TestExecute_TemplatedPathMissingVar tests that path variables are validated before writing
+func TestExecute_TemplatedPathMissingVar(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+
+	createTestFile(t, src, "a.txt", "a")
+	createTestFile(t, src, "{{.pkg}}.txt", "static")
+
+	err := New(nil, ".stamp").Execute(src, dest)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Execute() error = %v, want ValidationError", err)
+	}
+	if paths := validationErr.MissingVars["pkg"]; len(paths) != 1 || paths[0] != "{{.pkg}}.txt" {
+		t.Errorf("MissingVars[pkg] = %v, want [{{.pkg}}.txt]", paths)
+	}
+	assertFileNotExists(t, filepath.Join(dest, "a.txt"))
+}
+
+// TestExecute_TemplatedPathEscape tests that a rendered path cannot leave the destination
+func TestExecute_TemplatedPathEscape(t *testing.T) {
+	src := t.TempDir()
+	dest := filepath.Join(t.TempDir(), "dest")
+
+	createTestFile(t, src, "{{.name}}.txt", "evil")
+
+	err := New(map[string]string{"name": "../evil"}, ".stamp").Execute(src, dest)
+	if !errors.Is(err, ErrUnsafePath) {
+		t.Fatalf("Execute() error = %v, want ErrUnsafePath", err)
+	}
+	assertFileNotExists(t, filepath.Join(filepath.Dir(dest), "evil.txt"))
+}
+
+// TestExecute_OverwritePolicy tests the error, skip and force policies for existing files
+func TestExecute_OverwritePolicy(t *testing.T) {
+	src := t.TempDir()
+	createTestFile(t, src, "a.txt", "new a")
+	createTestFile(t, src, "b.txt.stamp", "new {{.name}}")
+	createTestFile(t, src, "c.txt", "new c")
+	vars := map[string]string{"name": "b"}
+
+	setup := func() string {
+		dest := t.TempDir()
+		createTestFile(t, dest, "b.txt", "old b")
+		return dest
+	}
+
+	t.Run("error", func(t *testing.T) {
+		dest := setup()
+		_, err := New(vars, ".stamp").ExecuteMultiple([]string{src}, dest)
+		if !errors.Is(err, ErrExists) {
+			t.Fatalf("ExecuteMultiple() error = %v, want ErrExists", err)
+		}
+		if !strings.Contains(err.Error(), "b.txt") {
+			t.Errorf("error = %q, want it to name b.txt", err.Error())
+		}
+		assertFileContent(t, filepath.Join(dest, "b.txt"), "old b")
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		dest := setup()
+		logger := &recordingLogger{}
+		result, err := New(vars, ".stamp", WithOverwrite(OverwriteSkip), WithLogger(logger)).ExecuteMultiple([]string{src}, dest)
+		if err != nil {
+			t.Fatalf("ExecuteMultiple() failed: %v", err)
+		}
+		assertFileContent(t, filepath.Join(dest, "b.txt"), "old b")
+		assertFileContent(t, filepath.Join(dest, "c.txt"), "new c")
+		if result.Skipped != 1 || result.Overwritten != 0 || result.Written() != 2 {
+			t.Errorf("Skipped = %d, Overwritten = %d, Written = %d, want 1, 0, 2", result.Skipped, result.Overwritten, result.Written())
+		}
+		if !containsEvent(logger.events, Event{Event: EventWarning, Path: "b.txt", Message: "skipped existing file b.txt"}) {
+			t.Errorf("events = %+v, want a warning for b.txt", logger.events)
+		}
+		if !containsEvent(logger.events, Event{Event: EventFileSkipped, Path: "b.txt", Message: "exists"}) {
+			t.Errorf("events = %+v, want b.txt reported as skipped", logger.events)
+		}
+	})
+
+	t.Run("force", func(t *testing.T) {
+		dest := setup()
+		result, err := New(vars, ".stamp", WithOverwrite(OverwriteForce)).ExecuteMultiple([]string{src}, dest)
+		if err != nil {
+			t.Fatalf("ExecuteMultiple() failed: %v", err)
+		}
+		assertFileContent(t, filepath.Join(dest, "b.txt"), "new b")
+		if result.Overwritten != 1 {
+			t.Errorf("Overwritten = %d, want 1", result.Overwritten)
+		}
+	})
+
+	t.Run("if-changed", func(t *testing.T) {
+		dest := setup()
+		createTestFile(t, dest, "a.txt", "new a")
+		old := time.Now().Add(-time.Hour).Truncate(time.Second)
+		for _, name := range []string{"a.txt", "b.txt"} {
+			if err := os.Chtimes(filepath.Join(dest, name), old, old); err != nil {
+				t.Fatalf("Chtimes() failed: %v", err)
+			}
+		}
+
+		logger := &recordingLogger{}
+		result, err := New(vars, ".stamp", WithOverwrite(OverwriteIfChanged), WithLogger(logger)).ExecuteMultiple([]string{src}, dest)
+		if err != nil {
+			t.Fatalf("ExecuteMultiple() failed: %v", err)
+		}
+		assertFileContent(t, filepath.Join(dest, "a.txt"), "new a")
+		assertFileContent(t, filepath.Join(dest, "b.txt"), "new b")
+		assertFileContent(t, filepath.Join(dest, "c.txt"), "new c")
+		if result.Unchanged != 1 || result.Overwritten != 1 || result.Written() != 2 {
+			t.Errorf("Unchanged = %d, Overwritten = %d, Written = %d, want 1, 1, 2", result.Unchanged, result.Overwritten, result.Written())
+		}
+		if !strings.Contains(result.String(), ", 1 unchanged,") {
+			t.Errorf("String() = %q, want it to report 1 unchanged", result.String())
+		}
+		if !containsEvent(logger.events, Event{Event: EventFileSkipped, Path: "a.txt", Message: "unchanged"}) {
+			t.Errorf("events = %+v, want a.txt skipped as unchanged", logger.events)
+		}
+
+		info, err := os.Stat(filepath.Join(dest, "a.txt"))
+		if err != nil {
+			t.Fatalf("Stat() failed: %v", err)
+		}
+		if !info.ModTime().Equal(old) {
+			t.Errorf("a.txt ModTime = %v, want it untouched at %v", info.ModTime(), old)
+		}
+		if info, err := os.Stat(filepath.Join(dest, "b.txt")); err != nil || info.ModTime().Equal(old) {
+			t.Errorf("b.txt ModTime = %v (err %v), want it updated", info.ModTime(), err)
+		}
+	})
+}
+
+// TestExecuteMultiple_LaterSheetOverwritesEarlier tests that files written earlier in the run are always replaced
+func TestExecuteMultiple_LaterSheetOverwritesEarlier(t *testing.T) {
+	base := t.TempDir()
+	extra := t.TempDir()
+	createTestFile(t, base, "config.txt", "base")
+	createTestFile(t, extra, "config.txt", "extra")
+
+	for _, policy := range []string{OverwriteError, OverwriteSkip, OverwriteForce, OverwriteIfChanged} {
+		dest := t.TempDir()
+		if _, err := New(nil, ".stamp", WithOverwrite(policy)).ExecuteMultiple([]string{base, extra}, dest); err != nil {
+			t.Fatalf("ExecuteMultiple() with %s failed: %v", policy, err)
+		}
+		assertFileContent(t, filepath.Join(dest, "config.txt"), "extra")
+	}
+}
+
+// TestExecute_Only tests that only files whose output path matches are written and validated
+func TestExecute_Only(t *testing.T) {
+	src := t.TempDir()
+	for _, dir := range []string{filepath.Join("cmd", "{{.pkg}}"), "{{.docs}}"} {
+		if err := os.MkdirAll(filepath.Join(src, dir), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+	}
+	createTestFile(t, src, "main.go.stamp", "package {{.pkg}}")
+	createTestFile(t, src, filepath.Join("cmd", "{{.pkg}}", "run.go"), "package run")
+	createTestFile(t, src, "README.md.stamp", "# {{.title}}")
+	createTestFile(t, src, filepath.Join("{{.docs}}", "guide.md"), "guide")
+
+	dest := t.TempDir()
+	result, err := New(map[string]string{"pkg": "app"}, ".stamp", WithOnly([]string{"*.go"})).ExecuteMultiple([]string{src}, dest)
+	if err != nil {
+		t.Fatalf("ExecuteMultiple() failed: %v", err)
+	}
+	assertFileContent(t, filepath.Join(dest, "main.go"), "package app")
+	assertFileContent(t, filepath.Join(dest, "cmd", "app", "run.go"), "package run")
+	assertFileNotExists(t, filepath.Join(dest, "README.md"))
+	assertFileNotExists(t, filepath.Join(dest, "{{.docs}}"))
+	if result.Written() != 2 {
+		t.Errorf("Written = %d, want 2", result.Written())
+	}
+
+	// Patterns with a slash match the whole output path
+	dest = t.TempDir()
+	if _, err := New(map[string]string{"pkg": "app"}, ".stamp", WithOnly([]string{"cmd/*/run.go"})).ExecuteMultiple([]string{src}, dest); err != nil {
+		t.Fatalf("ExecuteMultiple() failed: %v", err)
+	}
+	assertFileNotExists(t, filepath.Join(dest, "main.go"))
+	assertFileContent(t, filepath.Join(dest, "cmd", "app", "run.go"), "package run")
+
+	// Variables in the directories of selected files are still required
+	var validationErr *ValidationError
+	_, err = New(nil, ".stamp", WithOnly([]string{"run.go"})).ExecuteMultiple([]string{src}, t.TempDir())
+	if !errors.As(err, &validationErr) || len(validationErr.MissingVars) != 1 || validationErr.MissingVars["pkg"] == nil {
+		t.Errorf("ExecuteMultiple() error = %v, want only pkg missing", err)
+	}
+}
+
+// TestExecute_StreamedCopy tests that streamed copies match their source byte-for-byte around buffer sizes
+func TestExecute_StreamedCopy(t *testing.T) {
+	const bufSize = 32 * 1024 // io.Copy's default buffer
+	sizes := []int{0, 1, bufSize - 1, bufSize, bufSize + 1, 5 * 1024 * 1024}
+
+	src := t.TempDir()
+	want := make(map[string][]byte)
+	for _, size := range sizes {
+		content := make([]byte, size)
+		for i := range content {
+			content[i] = byte(i*7 + i/251)
+		}
+		name := fmt.Sprintf("asset-%d.bin", size)
+		if err := os.WriteFile(filepath.Join(src, name), content, 0644); err != nil {
+			t.Fatalf("failed to create %s: %v", name, err)
+		}
+		want[name] = content
+	}
+
+	dest := t.TempDir()
+	result, err := New(nil, ".stamp").ExecuteMultiple([]string{src}, dest)
+	if err != nil {
+		t.Fatalf("ExecuteMultiple() failed: %v", err)
+	}
+
+	var total int64
+	for name, content := range want {
+		got, err := os.ReadFile(filepath.Join(dest, name))
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", name, err)
+		}
+		if !bytes.Equal(got, content) {
+			t.Errorf("%s differs from its source (%d bytes, want %d)", name, len(got), len(content))
+		}
+		total += int64(len(content))
+	}
+	if result.Copied != len(sizes) || result.Bytes != total {
+		t.Errorf("Copied = %d, Bytes = %d, want %d, %d", result.Copied, result.Bytes, len(sizes), total)
+	}
+}
@@ -0,0 +1,98 @@
+package stamp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ResolveSymlink resolves a symlink found under root
+// It reports the final target, whether that target is a directory,
+// and whether it lies outside root (after resolving symlinks in root itself)
+func ResolveSymlink(root, path string) (target string, isDir, external bool, err error) {
+	target, err = filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", false, false, err
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		return "", false, false, err
+	}
+
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", false, false, err
+	}
+	return target, info.IsDir(), !isWithin(realRoot, target), nil
+}
+
+// SymlinkSkipReason returns why a symlink under root is not materialized, or "" to copy its target,
+// and whether its target is outside root
+// Targets outside root, which rootName describes in the reason, are skipped unless allowExternal;
+// links to directories are never followed, which also rules out symlink loops
+func SymlinkSkipReason(root, path, rootName string, allowExternal bool) (string, bool) {
+	target, isDir, external, err := ResolveSymlink(root, path)
+	switch {
+	case err != nil:
+		return "broken symlink", false
+	case isDir:
+		return "symlink to directory", external
+	case external && !allowExternal:
+		return fmt.Sprintf("symlink to %s outside %s (use --allow-external-symlinks)", target, rootName), true
+	}
+	return "", external
+}
+
+// recreateSymlink creates a link at the destination with the same target as the source link
+// The target is copied verbatim and never read
+func (s *Stamper) recreateSymlink(f sheetFile) error {
+	target, err := os.Readlink(f.srcPath)
+	if err != nil {
+		return fmt.Errorf("failed to read symlink: %w", err)
+	}
+
+	if keep, err := s.keepExisting(f.destPath); keep || err != nil {
+		return err
+	}
+	if s.overwrite == OverwriteIfChanged {
+		if existing, err := os.Readlink(f.destPath); err == nil && existing == target {
+			s.recordUnchanged(f.destPath)
+			return nil
+		}
+	}
+	overwrite := exists(f.destPath)
+	if err := s.saveDest(f.destPath); err != nil {
+		return err
+	}
+	if err := s.checkLinks(filepath.Dir(f.destPath)); err != nil {
+		return err
+	}
+	if overwrite {
+		if err := os.Remove(f.destPath); err != nil {
+			return newWriteError(f.destPath, err)
+		}
+	}
+	if err := os.Symlink(target, f.destPath); err != nil {
+		return newWriteError(f.destPath, err)
+	}
+
+	s.recordWrite(ActionLinked, f.destPath, overwrite, 0)
+	return nil
+}
+
+// processSymlink counts a symlink and reports whether it should be skipped
+func (s *Stamper) processSymlink(root string, f sheetFile) bool {
+	reason, external := SymlinkSkipReason(root, f.srcPath, "the sheet", s.allowExternalSymlinks)
+	if reason != "" {
+		s.result.Skipped++
+		s.logger.Log(Event{Event: EventFileSkipped, Path: f.sortKey, Sheet: s.sheet, Message: reason})
+		s.logger.Log(Event{Event: EventWarning, Path: f.sortKey, Sheet: s.sheet,
+			Message: fmt.Sprintf("skipped %s: %s", f.sortKey, reason)})
+		return true
+	}
+	s.result.Symlinks++
+	if external {
+		s.result.ExternalSymlinks++
+	}
+	return false
+}
@@ -0,0 +1,222 @@
+//go:build unix
+
+package stamp
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestExecute_SymlinksDereference tests materializing in-sheet links and refusing external ones
+func TestExecute_SymlinksDereference(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+	outside := t.TempDir()
+
+	createTestFile(t, src, "shared.txt.stamp", "Hello {{.name}}")
+	createTestFile(t, outside, "secret.txt", "secret")
+	if err := os.Symlink("shared.txt.stamp", filepath.Join(src, "alias.txt.stamp")); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(src, "leak.txt")); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+
+	logger := &recordingLogger{}
+	stamper := New(map[string]string{"name": "alice"}, ".stamp", WithLogger(logger), WithDereference(true))
+	result, err := stamper.ExecuteMultiple([]string{src}, dest)
+	if err != nil {
+		t.Fatalf("ExecuteMultiple() failed: %v", err)
+	}
+
+	// The in-tree link is written as a regular file with rendered content
+	assertFileContent(t, filepath.Join(dest, "alias.txt"), "Hello alice")
+	if info, err := os.Lstat(filepath.Join(dest, "alias.txt")); err != nil || info.Mode()&os.ModeSymlink != 0 {
+		t.Errorf("alias.txt should be a regular file: %v", err)
+	}
+	assertFileNotExists(t, filepath.Join(dest, "leak.txt"))
+
+	if result.Symlinks != 1 || result.ExternalSymlinks != 0 || result.Skipped != 1 {
+		t.Errorf("result = %+v, want 1 materialized symlink and 1 skipped", result)
+	}
+	if !strings.Contains(result.String(), "1 symlinks materialized (0 external)") {
+		t.Errorf("summary = %q, want symlink counts", result.String())
+	}
+	var warned bool
+	for _, e := range logger.events {
+		if e.Event == EventWarning && e.Path == "leak.txt" && strings.Contains(e.Message, "outside the sheet") {
+			warned = true
+		}
+	}
+	if !warned {
+		t.Errorf("events = %+v, want a warning for leak.txt", logger.events)
+	}
+}
+
+// TestExecute_AllowExternalSymlinks tests opting in to external link targets
+func TestExecute_AllowExternalSymlinks(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+	outside := t.TempDir()
+
+	createTestFile(t, outside, "shared.txt", "shared")
+	if err := os.Symlink(filepath.Join(outside, "shared.txt"), filepath.Join(src, "shared.txt")); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+	if err := os.Symlink(outside, filepath.Join(src, "dir")); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+
+	result, err := New(nil, ".stamp", WithDereference(true), WithAllowExternalSymlinks(true)).ExecuteMultiple([]string{src}, dest)
+	if err != nil {
+		t.Fatalf("ExecuteMultiple() failed: %v", err)
+	}
+
+	assertFileContent(t, filepath.Join(dest, "shared.txt"), "shared")
+	// Links to directories are never followed
+	assertFileNotExists(t, filepath.Join(dest, "dir"))
+	if result.Symlinks != 1 || result.ExternalSymlinks != 1 {
+		t.Errorf("result = %+v, want 1 external symlink materialized", result)
+	}
+}
+
+// TestExecute_SymlinksRecreated tests that links are recreated with their original targets by default
+func TestExecute_SymlinksRecreated(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+	outside := t.TempDir()
+
+	createTestFile(t, src, "v2.txt", "v2")
+	createTestFile(t, src, "shared.txt.stamp", "Hello {{.name}}")
+	links := map[string]string{
+		"latest.txt":      "v2.txt",
+		"external.txt":    filepath.Join(outside, "secret.txt"),
+		"dir":             outside,
+		"broken.txt":      "missing.txt",
+		"alias.txt.stamp": "shared.txt.stamp",
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(src, name)); err != nil {
+			t.Fatalf("failed to create symlink: %v", err)
+		}
+	}
+
+	result, err := New(map[string]string{"name": "alice"}, ".stamp").ExecuteMultiple([]string{src}, dest)
+	if err != nil {
+		t.Fatalf("ExecuteMultiple() failed: %v", err)
+	}
+
+	for _, name := range []string{"latest.txt", "external.txt", "dir", "broken.txt"} {
+		target, err := os.Readlink(filepath.Join(dest, name))
+		if err != nil {
+			t.Errorf("%s should be a symlink: %v", name, err)
+			continue
+		}
+		if target != links[name] {
+			t.Errorf("%s -> %s, want %s", name, target, links[name])
+		}
+	}
+	assertFileContent(t, filepath.Join(dest, "latest.txt"), "v2")
+
+	// Links to stamp files are still rendered
+	assertFileContent(t, filepath.Join(dest, "alias.txt"), "Hello alice")
+	if result.Linked != 4 || result.Symlinks != 1 {
+		t.Errorf("result = %+v, want 4 linked and 1 materialized", result)
+	}
+	if !strings.Contains(result.String(), "4 linked") {
+		t.Errorf("summary = %q, want linked count", result.String())
+	}
+}
+
+// TestExecute_SymlinksOverwrite tests that existing destination entries follow the overwrite policy
+func TestExecute_SymlinksOverwrite(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+	if err := os.Symlink("v2", filepath.Join(src, "latest")); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+	if err := os.Symlink("v1", filepath.Join(dest, "latest")); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+
+	if err := New(nil, ".stamp").Execute(src, dest); !errors.Is(err, ErrExists) {
+		t.Fatalf("Execute() error = %v, want ErrExists", err)
+	}
+	if err := New(nil, ".stamp", WithOverwrite(OverwriteForce)).Execute(src, dest); err != nil {
+		t.Fatalf("Execute() with force failed: %v", err)
+	}
+	if target, err := os.Readlink(filepath.Join(dest, "latest")); err != nil || target != "v2" {
+		t.Errorf("latest -> %q (%v), want v2", target, err)
+	}
+}
+
+// TestExecuteMultiple_LinksDoNotCarryWritesOutside tests that links recreated by one sheet
+// are replaced or refused, never written through, when a later sheet writes at or under them
+func TestExecuteMultiple_LinksDoNotCarryWritesOutside(t *testing.T) {
+	root := t.TempDir()
+	dest := filepath.Join(root, "dest")
+	if err := os.Mkdir(dest, 0755); err != nil {
+		t.Fatalf("failed to create destination: %v", err)
+	}
+	victim := createTestFile(t, root, "victim.txt", "original")
+	if err := os.Mkdir(filepath.Join(root, "outside"), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+
+	a := t.TempDir()
+	for name, target := range map[string]string{"victim.txt": "../victim.txt", "outside": "../outside"} {
+		if err := os.Symlink(target, filepath.Join(a, name)); err != nil {
+			t.Fatalf("failed to create symlink: %v", err)
+		}
+	}
+
+	// A regular file replaces the link written by the earlier sheet
+	b := t.TempDir()
+	createTestFile(t, b, "victim.txt", "stamped")
+	if _, err := New(nil, ".stamp").ExecuteMultiple([]string{a, b}, dest); err != nil {
+		t.Fatalf("ExecuteMultiple() failed: %v", err)
+	}
+	assertFileContent(t, victim, "original")
+	assertFileContent(t, filepath.Join(dest, "victim.txt"), "stamped")
+	if isSymlink(filepath.Join(dest, "victim.txt")) {
+		t.Error("victim.txt should be replaced by a regular file")
+	}
+
+	// A file under a link that leads outside the destination is refused and the run rolled back
+	c := t.TempDir()
+	if err := os.Mkdir(filepath.Join(c, "outside"), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	createTestFile(t, filepath.Join(c, "outside"), "evil.txt", "evil")
+	dest2 := filepath.Join(root, "dest2")
+	_, err := New(nil, ".stamp").ExecuteMultiple([]string{a, c}, dest2)
+	if !errors.Is(err, ErrUnsafePath) {
+		t.Fatalf("ExecuteMultiple() error = %v, want ErrUnsafePath", err)
+	}
+	assertFileNotExists(t, filepath.Join(root, "outside", "evil.txt"))
+	assertFileContent(t, victim, "original")
+}
+
+// TestExecute_LinkInsideDestinationFollowed tests that directory links resolving inside the destination still work
+func TestExecute_LinkInsideDestinationFollowed(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dest, "v2"), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err := os.Symlink("v2", filepath.Join(dest, "current")); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+	if err := os.Mkdir(filepath.Join(src, "current"), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	createTestFile(t, filepath.Join(src, "current"), "a.txt", "a")
+
+	if err := New(nil, ".stamp").Execute(src, dest); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	assertFileContent(t, filepath.Join(dest, "v2", "a.txt"), "a")
+}
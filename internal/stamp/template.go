@@ -1,15 +1,17 @@
 package stamp
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
-	"text/template"
+
+	"golang.org/x/text/transform"
 )
 
 // processTemplate reads a template file, expands it, and writes to destination
-// The template extension is removed from the output filename
+// The template extension is removed from the output filename unless WithKeepExtension is set
 func (s *Stamper) processTemplate(srcPath, destPath string) error {
 	// Remove custom extension from destination
 	destPath = s.removeTemplateExtension(destPath)
@@ -19,32 +21,146 @@ func (s *Stamper) processTemplate(srcPath, destPath string) error {
 	if err != nil {
 		return fmt.Errorf("failed to read template file: %w", err)
 	}
+	// Binary content can't be a template; copy it as is when allowed
+	if IsBinary(content) {
+		if !s.copyBinary {
+			return fmt.Errorf("%s: %w", srcPath, ErrBinaryTemplate)
+		}
+		return s.copyFile(srcPath, destPath)
+	}
+	// Front matter was applied while walking the sheet; only the body is rendered
+	_, content, _ = splitFrontMatter(content)
 
-	// Parse template
-	tmpl, err := template.New(filepath.Base(srcPath)).Parse(string(content))
+	// Parse template, after the sheet's partials so the file can invoke them
+	leftDelim, rightDelim := s.delimsFor(srcPath)
+	tmpl := s.newTemplate(filepath.Base(srcPath))
+	if err := s.addPartials(tmpl); err != nil {
+		return err
+	}
+	text := string(content)
+	if s.trimBlocks {
+		text = trimBlocks(text, leftDelim, rightDelim)
+	}
+	tmpl, err = tmpl.Delims(leftDelim, rightDelim).Parse(text)
 	if err != nil {
 		return fmt.Errorf("failed to parse template: %w", err)
 	}
+	if s.strictKeys {
+		tmpl.Option("missingkey=error")
+	}
 
-	// Create destination file
-	destFile, err := os.Create(destPath)
+	// Look up the declared output encoding before creating anything
+	relPath, _ := relSlashPath(s.dest, destPath)
+	enc, err := s.attrs.outputEncoding(s.patternPath(destPath))
 	if err != nil {
-		return fmt.Errorf("failed to create destination file: %w", err)
+		return err
 	}
-	defer destFile.Close()
 
-	// Execute template
-	if err := tmpl.Execute(destFile, s.templateVars); err != nil {
+	// Render in memory so nothing is written if execution fails
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, s.templateData); err != nil {
 		return fmt.Errorf("failed to execute template: %w", err)
 	}
+	rendered := buf.Bytes()
+
+	// Empty output either skips the file or is written with a warning
+	// Skipping only concerns this template's output; a file an earlier sheet wrote at the path stays
+	blank := len(bytes.TrimSpace(rendered)) == 0
+	if blank && s.skipEmpty {
+		if s.result != nil {
+			s.result.Skipped++
+		}
+		s.logger.Log(Event{Event: EventFileSkipped, Path: relPath, Sheet: s.sheet, Message: "empty output"})
+		return nil
+	}
+
+	if keep, err := s.keepExisting(destPath); keep || err != nil {
+		return err
+	}
+	overwrite := exists(destPath)
+
+	// Merge with the file written by an earlier sheet when the strategy allows
+	// Transcoded files are always overwritten
+	merge, err := s.merger(destPath, s.patternPath(destPath))
+	if err != nil {
+		return err
+	}
+	if merge != nil && enc == nil {
+		if rendered, err = mergeExisting(destPath, relPath, rendered, merge); err != nil {
+			return err
+		}
+	}
+
+	eol, err := s.attrs.lineEndings(s.patternPath(destPath), s.lineEndings)
+	if err != nil {
+		return err
+	}
+	rendered = convertLineEndings(rendered, eol)
+
+	// Transcode from UTF-8 if an encoding is declared
+	if enc != nil {
+		if rendered, _, err = transform.Bytes(enc.NewEncoder(), rendered); err != nil {
+			return fmt.Errorf("failed to encode %s: %w", relPath, err)
+		}
+	}
 
+	if s.keepUnchanged(destPath, rendered) {
+		return nil
+	}
+	if err := s.saveDest(destPath); err != nil {
+		return err
+	}
+	if err := s.prepareDest(destPath); err != nil {
+		return err
+	}
+	if err := writeWithMode(srcPath, destPath, rendered); err != nil {
+		return err
+	}
+	if err := s.applyPermissions(destPath); err != nil {
+		return err
+	}
+
+	s.recordWrite(ActionTemplated, destPath, overwrite, int64(len(rendered)))
+	if blank {
+		s.logger.Log(Event{Event: EventWarning, Path: relPath, Sheet: s.sheet,
+			Message: fmt.Sprintf("%s rendered empty output", relPath)})
+	}
 	return nil
 }
 
 // removeTemplateExtension strips the template extension from the end of a path
+// With WithKeepExtension the path is returned unchanged
 func (s *Stamper) removeTemplateExtension(path string) string {
-	if strings.HasSuffix(path, s.templateExt) {
-		return strings.TrimSuffix(path, s.templateExt)
+	if s.keepExtension {
+		return path
+	}
+	return strings.TrimSuffix(path, s.templateSuffix(path))
+}
+
+// squareSuffix follows the template extension of files rendered with [[ ]] delimiters,
+// e.g. main.tf.stamp-sq
+const squareSuffix = "-sq"
+
+// templateSuffix returns the template extension path ends with, or "" if it is not a template
+func (s *Stamper) templateSuffix(path string) string {
+	switch {
+	case strings.HasSuffix(path, s.templateExt+squareSuffix):
+		return s.templateExt + squareSuffix
+	case strings.HasSuffix(path, s.templateExt):
+		return s.templateExt
+	}
+	return ""
+}
+
+// isTemplateFile reports whether path is rendered as a template
+func (s *Stamper) isTemplateFile(path string) bool {
+	return s.templateSuffix(path) != ""
+}
+
+// delimsFor returns the action delimiters of the template at path
+func (s *Stamper) delimsFor(path string) (string, string) {
+	if strings.HasSuffix(path, s.templateExt+squareSuffix) {
+		return "[[", "]]"
 	}
-	return path
+	return s.leftDelim, s.rightDelim
 }
@@ -0,0 +1,65 @@
+package stamp
+
+import (
+	"slices"
+	"strings"
+)
+
+// controlKeywords start actions that produce no output of their own
+var controlKeywords = []string{"if", "else", "end", "range", "with", "define", "block", "break", "continue"}
+
+// trimBlocks removes the indentation and line break of every line that holds only control actions,
+// so a template like "{{if .x}}\nbody\n{{end}}\n" renders "body\n" instead of leaving blank lines
+// Lines with output or with text next to the actions are kept as written, and so are blank lines
+func trimBlocks(text, leftDelim, rightDelim string) string {
+	lines := strings.SplitAfter(text, "\n")
+	var sb strings.Builder
+	sb.Grow(len(text))
+	for _, line := range lines {
+		content := strings.TrimRight(line, "\r\n")
+		if isControlLine(content, leftDelim, rightDelim) {
+			sb.WriteString(strings.TrimSpace(content))
+			continue
+		}
+		sb.WriteString(line)
+	}
+	return sb.String()
+}
+
+// isControlLine reports whether line consists of one or more control actions and whitespace only
+func isControlLine(line, leftDelim, rightDelim string) bool {
+	rest := strings.TrimSpace(line)
+	if rest == "" {
+		return false
+	}
+	for rest != "" {
+		inner, ok := strings.CutPrefix(rest, leftDelim)
+		if !ok {
+			return false
+		}
+		action, after, ok := strings.Cut(inner, rightDelim)
+		if !ok || !isControlAction(action) {
+			return false
+		}
+		rest = strings.TrimSpace(after)
+	}
+	return true
+}
+
+// isControlAction reports whether the text between delimiters is a control keyword,
+// a comment, or a variable declaration or assignment
+func isControlAction(action string) bool {
+	action = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(action, "-"), "-"))
+	if strings.HasPrefix(action, "/*") {
+		return true
+	}
+	fields := strings.Fields(action)
+	if len(fields) == 0 {
+		return false
+	}
+	if slices.Contains(controlKeywords, fields[0]) {
+		return true
+	}
+	// "$x := value" and "$x = value" set a variable without printing it
+	return strings.HasPrefix(fields[0], "$") && len(fields) > 1 && (fields[1] == ":=" || fields[1] == "=")
+}
@@ -0,0 +1,53 @@
+package stamp
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// TestExecute_TrimBlocks tests that lines holding only control actions are removed with WithTrimBlocks
+func TestExecute_TrimBlocks(t *testing.T) {
+	src := t.TempDir()
+	createTestFile(t, src, "config.yaml.stamp", "name: {{.name}}\n  {{if .debug}}\ndebug: true\n  {{end}}\n\n{{range .ports}}\nport: {{.}}\n{{end}}\n")
+	vars := map[string]string{"name": "app", "debug": "true", "ports.0": "80", "ports.1": "443"}
+
+	untrimmed := t.TempDir()
+	if err := New(vars, ".stamp").Execute(src, untrimmed); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	assertFileContent(t, filepath.Join(untrimmed, "config.yaml"), "name: app\n  \ndebug: true\n  \n\n\nport: 80\n\nport: 443\n\n")
+
+	trimmed := t.TempDir()
+	if err := New(vars, ".stamp", WithTrimBlocks(true)).Execute(src, trimmed); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	// The blank line written in the template is kept
+	assertFileContent(t, filepath.Join(trimmed, "config.yaml"), "name: app\ndebug: true\n\nport: 80\nport: 443\n")
+}
+
+func TestTrimBlocks(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"control lines", "{{if .a}}\nA\n{{else if .b}}\nB\n{{end}}\n", "{{if .a}}A\n{{else if .b}}B\n{{end}}"},
+		{"trim markers and comments", "  {{- if .a -}}  {{/* note */}}\r\nA\r\n", "{{- if .a -}}  {{/* note */}}A\r\n"},
+		{"variable declaration", "{{$n := .name}}\n{{$n}}\n", "{{$n := .name}}{{$n}}\n"},
+		{"output actions are kept", "{{.name}}\n{{template \"x\" .}}\n", "{{.name}}\n{{template \"x\" .}}\n"},
+		{"text next to an action is kept", "x {{if .a}}\n{{end}} y\n", "x {{if .a}}\n{{end}} y\n"},
+		{"blank lines are kept", "\n  \n", "\n  \n"},
+		{"custom delimiters", "[[if .a]]\nA\n{{end}}\n", "[[if .a]]A\n{{end}}\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			left, right := defaultLeftDelim, defaultRightDelim
+			if tt.name == "custom delimiters" {
+				left, right = "[[", "]]"
+			}
+			if got := trimBlocks(tt.text, left, right); got != tt.want {
+				t.Errorf("trimBlocks() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,95 +1,117 @@
 package stamp
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
 	"testing"
 )
 
-// TestExtractTemplateVars_SimpleVariable tests basic variable extraction
-func TestExtractTemplateVars_SimpleVariable(t *testing.T) {
+// templateVars returns the sorted variables analyzeTemplate finds in a template, guarded or not
+func templateVars(templatePath string) ([]string, error) {
+	usage, err := analyzeTemplate(templatePath, defaultLeftDelim, defaultRightDelim)
+	if err != nil {
+		return nil, err
+	}
+	vars := make([]string, 0, len(usage.required)+len(usage.guarded))
+	for v := range usage.required {
+		vars = append(vars, v)
+	}
+	for v := range usage.guarded {
+		if _, ok := usage.required[v]; !ok {
+			vars = append(vars, v)
+		}
+	}
+	sort.Strings(vars)
+	return vars, nil
+}
+
+// TestAnalyzeTemplate_SimpleVariable tests basic variable extraction
+func TestAnalyzeTemplate_SimpleVariable(t *testing.T) {
 	dir := t.TempDir()
 	tmplPath := createTestFile(t, dir, "test.tmpl", "Hello {{.name}}!")
 
-	vars, err := extractTemplateVars(tmplPath)
+	vars, err := templateVars(tmplPath)
 	if err != nil {
-		t.Fatalf("extractTemplateVars() failed: %v", err)
+		t.Fatalf("templateVars() failed: %v", err)
 	}
 
 	expected := []string{"name"}
 	assertVarsEqual(t, vars, expected)
 }
 
-// TestExtractTemplateVars_MultipleVariables tests multiple variables
-func TestExtractTemplateVars_MultipleVariables(t *testing.T) {
+// TestAnalyzeTemplate_MultipleVariables tests multiple variables
+func TestAnalyzeTemplate_MultipleVariables(t *testing.T) {
 	dir := t.TempDir()
 	tmplPath := createTestFile(t, dir, "test.tmpl",
 		"{{.name}} from {{.org}} repo {{.repo}}")
 
-	vars, err := extractTemplateVars(tmplPath)
+	vars, err := templateVars(tmplPath)
 	if err != nil {
-		t.Fatalf("extractTemplateVars() failed: %v", err)
+		t.Fatalf("templateVars() failed: %v", err)
 	}
 
 	expected := []string{"name", "org", "repo"}
 	assertVarsEqual(t, vars, expected)
 }
 
-// TestExtractTemplateVars_IfBlock tests variables in conditional blocks
-func TestExtractTemplateVars_IfBlock(t *testing.T) {
+// TestAnalyzeTemplate_IfBlock tests variables in conditional blocks
+func TestAnalyzeTemplate_IfBlock(t *testing.T) {
 	dir := t.TempDir()
 	tmplPath := createTestFile(t, dir, "test.tmpl",
 		"{{if .enabled}}{{.name}} is enabled{{end}}")
 
-	vars, err := extractTemplateVars(tmplPath)
+	vars, err := templateVars(tmplPath)
 	if err != nil {
-		t.Fatalf("extractTemplateVars() failed: %v", err)
+		t.Fatalf("templateVars() failed: %v", err)
 	}
 
 	expected := []string{"enabled", "name"}
 	assertVarsEqual(t, vars, expected)
 }
 
-// TestExtractTemplateVars_RangeBlock tests variables in range blocks
-func TestExtractTemplateVars_RangeBlock(t *testing.T) {
+// TestAnalyzeTemplate_RangeBlock tests variables in range blocks
+func TestAnalyzeTemplate_RangeBlock(t *testing.T) {
 	dir := t.TempDir()
 	tmplPath := createTestFile(t, dir, "test.tmpl",
 		"{{range .items}}{{.}}{{end}}")
 
-	vars, err := extractTemplateVars(tmplPath)
+	vars, err := templateVars(tmplPath)
 	if err != nil {
-		t.Fatalf("extractTemplateVars() failed: %v", err)
+		t.Fatalf("templateVars() failed: %v", err)
 	}
 
 	expected := []string{"items"}
 	assertVarsEqual(t, vars, expected)
 }
 
-// TestExtractTemplateVars_WithBlock tests variables in with blocks
-func TestExtractTemplateVars_WithBlock(t *testing.T) {
+// TestAnalyzeTemplate_WithBlock tests variables in with blocks
+func TestAnalyzeTemplate_WithBlock(t *testing.T) {
 	dir := t.TempDir()
 	tmplPath := createTestFile(t, dir, "test.tmpl",
 		"{{with .config}}{{.value}}{{end}}")
 
-	vars, err := extractTemplateVars(tmplPath)
+	vars, err := templateVars(tmplPath)
 	if err != nil {
-		t.Fatalf("extractTemplateVars() failed: %v", err)
+		t.Fatalf("templateVars() failed: %v", err)
 	}
 
 	expected := []string{"config", "value"}
 	assertVarsEqual(t, vars, expected)
 }
 
-// TestExtractTemplateVars_ChainedFields tests chained field access
-func TestExtractTemplateVars_ChainedFields(t *testing.T) {
+// TestAnalyzeTemplate_ChainedFields tests chained field access
+func TestAnalyzeTemplate_ChainedFields(t *testing.T) {
 	dir := t.TempDir()
 	tmplPath := createTestFile(t, dir, "test.tmpl",
 		"{{.user.name}} {{.user.email}}")
 
-	vars, err := extractTemplateVars(tmplPath)
+	vars, err := templateVars(tmplPath)
 	if err != nil {
-		t.Fatalf("extractTemplateVars() failed: %v", err)
+		t.Fatalf("templateVars() failed: %v", err)
 	}
 
 	// Should only extract top-level field "user", not "name" or "email"
@@ -97,14 +119,14 @@ func TestExtractTemplateVars_ChainedFields(t *testing.T) {
 	assertVarsEqual(t, vars, expected)
 }
 
-// TestExtractTemplateVars_NoVariables tests template without variables
-func TestExtractTemplateVars_NoVariables(t *testing.T) {
+// TestAnalyzeTemplate_NoVariables tests template without variables
+func TestAnalyzeTemplate_NoVariables(t *testing.T) {
 	dir := t.TempDir()
 	tmplPath := createTestFile(t, dir, "test.tmpl", "Static content only")
 
-	vars, err := extractTemplateVars(tmplPath)
+	vars, err := templateVars(tmplPath)
 	if err != nil {
-		t.Fatalf("extractTemplateVars() failed: %v", err)
+		t.Fatalf("templateVars() failed: %v", err)
 	}
 
 	if len(vars) != 0 {
@@ -112,26 +134,57 @@ func TestExtractTemplateVars_NoVariables(t *testing.T) {
 	}
 }
 
-// TestExtractTemplateVars_InvalidTemplate tests handling of invalid templates
-func TestExtractTemplateVars_InvalidTemplate(t *testing.T) {
+// TestAnalyzeTemplate_RootDot tests that ranging over or printing the root dot requires no variable
+func TestAnalyzeTemplate_RootDot(t *testing.T) {
+	dir := t.TempDir()
+	tmplPath := createTestFile(t, dir, "test.tmpl", "{{range $k, $v := .}}{{$k}}={{$v}}\n{{end}}{{.}}")
+
+	vars, err := templateVars(tmplPath)
+	if err != nil {
+		t.Fatalf("templateVars() failed: %v", err)
+	}
+
+	if len(vars) != 0 {
+		t.Errorf("expected no variables, got %v", vars)
+	}
+}
+
+// TestAnalyzeTemplate_Comments tests that fields mentioned only in comments are not required
+func TestAnalyzeTemplate_Comments(t *testing.T) {
+	dir := t.TempDir()
+	tmplPath := createTestFile(t, dir, "test.tmpl",
+		"{{/* uses .secret */}}{{- /* and {{.other}} */ -}}\nHello {{.name}} {{/* .trailing */}}")
+
+	vars, err := templateVars(tmplPath)
+	if err != nil {
+		t.Fatalf("templateVars() failed: %v", err)
+	}
+
+	if len(vars) != 1 || vars[0] != "name" {
+		t.Errorf("expected [name], got %v", vars)
+	}
+}
+
+// TestAnalyzeTemplate_InvalidTemplate tests handling of invalid templates
+func TestAnalyzeTemplate_InvalidTemplate(t *testing.T) {
 	dir := t.TempDir()
 	tmplPath := createTestFile(t, dir, "test.tmpl", "Invalid {{.name")
 
-	_, err := extractTemplateVars(tmplPath)
+	_, err := templateVars(tmplPath)
 	if err == nil {
-		t.Fatal("extractTemplateVars() should return error for invalid template")
+		t.Fatal("templateVars() should return error for invalid template")
 	}
 }
 
-// TestExtractTemplateVars_DuplicateVariables tests variables used multiple times
-func TestExtractTemplateVars_DuplicateVariables(t *testing.T) {
+// TestAnalyzeTemplate_DuplicateVariables tests variables used multiple times
+func TestAnalyzeTemplate_DuplicateVariables(t *testing.T) {
 	dir := t.TempDir()
 	tmplPath := createTestFile(t, dir, "test.tmpl",
 		"{{.name}} and {{.name}} again")
 
-	vars, err := extractTemplateVars(tmplPath)
+	vars, err := templateVars(tmplPath)
 	if err != nil {
-		t.Fatalf("extractTemplateVars() failed: %v", err)
+		t.Fatalf("templateVars() failed: %v", err)
 	}
 
 	// Should deduplicate
@@ -139,8 +192,8 @@ func TestExtractTemplateVars_DuplicateVariables(t *testing.T) {
 	assertVarsEqual(t, vars, expected)
 }
 
-// TestExtractTemplateVars_ComplexNesting tests complex nested structures
-func TestExtractTemplateVars_ComplexNesting(t *testing.T) {
+// TestAnalyzeTemplate_ComplexNesting tests complex nested structures
+func TestAnalyzeTemplate_ComplexNesting(t *testing.T) {
 	dir := t.TempDir()
 	tmplPath := createTestFile(t, dir, "test.tmpl", `
 {{if .enabled}}
@@ -153,9 +206,9 @@ func TestExtractTemplateVars_ComplexNesting(t *testing.T) {
   {{.fallback}}
 {{end}}`)
 
-	vars, err := extractTemplateVars(tmplPath)
+	vars, err := templateVars(tmplPath)
 	if err != nil {
-		t.Fatalf("extractTemplateVars() failed: %v", err)
+		t.Fatalf("templateVars() failed: %v", err)
 	}
 
 	expected := []string{"config", "enabled", "fallback", "items", "value"}
@@ -346,8 +399,8 @@ func TestValidateTemplateVars_PartialProvision(t *testing.T) {
 	}
 }
 
-// TestValidateTemplateVars_InvalidTemplateIgnored tests invalid templates are skipped
-func TestValidateTemplateVars_InvalidTemplateIgnored(t *testing.T) {
+// TestValidateTemplateVars_InvalidTemplateReported tests that parse errors are reported alongside missing variables
+func TestValidateTemplateVars_InvalidTemplateReported(t *testing.T) {
 	src := t.TempDir()
 	createTestFile(t, src, "invalid.tmpl", "Invalid {{.name")
 	createTestFile(t, src, "valid.tmpl", "Valid {{.org}}")
@@ -355,16 +408,47 @@ func TestValidateTemplateVars_InvalidTemplateIgnored(t *testing.T) {
 	stamper := New(map[string]string{}, ".tmpl")
 	err := stamper.validateTemplateVars(src)
 
-	// Should not fail due to invalid template, should check valid ones
-	if err == nil {
-		t.Fatal("validateTemplateVars() should fail due to missing 'org'")
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("validateTemplateVars() error = %v, want *ValidationError", err)
+	}
+	if len(validationErr.ParseErrors) != 1 || !strings.Contains(validationErr.ParseErrors["invalid.tmpl"], "invalid.tmpl:1") {
+		t.Errorf("ParseErrors = %v, want only invalid.tmpl with the parser message", validationErr.ParseErrors)
+	}
+	if _, ok := validationErr.MissingVars["org"]; !ok || len(validationErr.MissingVars) != 1 {
+		t.Errorf("MissingVars = %v, want only org", validationErr.MissingVars)
 	}
 
 	errMsg := err.Error()
-	// Should only complain about missing org from valid.tmpl
-	if !strings.Contains(errMsg, "org") {
-		t.Errorf("error should mention missing variable 'org', got: %v", err)
+	for _, want := range []string{"template syntax errors:\n\n  - invalid.tmpl\n    template: invalid.tmpl:1:", "missing required template variables:\n\n  - org"} {
+		if !strings.Contains(errMsg, want) {
+			t.Errorf("error = %q, want it to contain %q", errMsg, want)
+		}
+	}
+}
+
+// TestValidateTemplateVars_InvalidNoopIgnored tests that .noop files are not parsed
+func TestValidateTemplateVars_InvalidNoopIgnored(t *testing.T) {
+	src := t.TempDir()
+	createTestFile(t, src, "raw.tmpl.noop", "Invalid {{.name")
+
+	if err := New(nil, ".tmpl").validateTemplateVars(src); err != nil {
+		t.Errorf("validateTemplateVars() returned error: %v", err)
+	}
+}
+
+// TestExecute_SyntaxErrorFailsBeforeCopy tests that a broken template stops the run before anything is written
+func TestExecute_SyntaxErrorFailsBeforeCopy(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+	createTestFile(t, src, "a.txt", "static")
+	createTestFile(t, src, "z.txt.tmpl", "{{if .name}}")
+
+	err := New(map[string]string{"name": "x"}, ".tmpl").Execute(src, dest)
+	if err == nil || !strings.Contains(err.Error(), "z.txt.tmpl") {
+		t.Fatalf("Execute() error = %v, want syntax error naming z.txt.tmpl", err)
 	}
+	assertFileNotExists(t, filepath.Join(dest, "a.txt"))
 }
 
 // Test helpers
@@ -444,3 +528,50 @@ func TestValidateTemplateVars_OnlyTmplNoop(t *testing.T) {
 		t.Errorf("validateTemplateVars() should pass with only .tmpl.noop files, got: %v", err)
 	}
 }
+
+// TestValidateTemplateVars_DefaultGuarded tests that variables guarded by default are optional
+func TestValidateTemplateVars_DefaultGuarded(t *testing.T) {
+	src := t.TempDir()
+	createTestFile(t, src, "a.stamp", `{{.owner | default "nobody"}} {{default "main" .branch}} {{.name}}`)
+
+	stamper := New(map[string]string{"name": "app"}, ".stamp", WithFuncs(StringFuncs()))
+	if err := stamper.validateTemplateVars(src); err != nil {
+		t.Errorf("validateTemplateVars() failed: %v", err)
+	}
+
+	stamper = New(nil, ".stamp", WithFuncs(StringFuncs()))
+	err := stamper.validateTemplateVars(src)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("validateTemplateVars() error = %v, want ValidationError", err)
+	}
+	if len(validationErr.MissingVars) != 1 || validationErr.MissingVars["name"] == nil {
+		t.Errorf("MissingVars = %v, want only name", validationErr.MissingVars)
+	}
+}
+
+// TestValidateTemplateVars_MissingVarLines tests that missing variables are reported with
+// the line of every use, including uses nested in conditional branches
+func TestValidateTemplateVars_MissingVarLines(t *testing.T) {
+	src := t.TempDir()
+	createTestFile(t, src, "a.stamp", "name: {{.name}}\n{{if .debug}}\n  {{- if .verbose}}\n    level: {{.level}}\n  {{- else}}\n    level: {{.level}} {{.level}}\n  {{- end}}\n{{end}}\n")
+
+	stamper := New(map[string]string{"name": "app"}, ".stamp")
+	err := stamper.validateTemplateVars(src)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("validateTemplateVars() error = %v, want ValidationError", err)
+	}
+
+	want := map[string][]string{
+		"debug":   {"a.stamp:2"},
+		"verbose": {"a.stamp:3"},
+		"level":   {"a.stamp:4", "a.stamp:6"},
+	}
+	if !reflect.DeepEqual(validationErr.MissingVars, want) {
+		t.Errorf("MissingVars = %v, want %v", validationErr.MissingVars, want)
+	}
+	if !strings.Contains(err.Error(), "      - a.stamp:4\n") {
+		t.Errorf("error should list a.stamp:4, got: %v", err)
+	}
+}
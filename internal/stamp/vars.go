@@ -0,0 +1,117 @@
+package stamp
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// nestVars turns dotted variable names into nested maps, so db.host=x can be read as {{.db.host}}
+// With typed set, values are typed with typedValue, so {{if .debug}} is false for debug=false.
+// Validation only sees top-level names: db is provided as soon as any db.* variable is.
+// A plain variable wins over nested ones with the same prefix, and names with empty
+// segments are kept as they are. Nested names numbered 0 to n-1, such as hosts.0 and
+// hosts.1, become a list so templates can range over them in order
+func nestVars(vars map[string]string, typed bool) map[string]any {
+	// Shorter names first, so plain values are placed before nested ones
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		di, dj := strings.Count(names[i], "."), strings.Count(names[j], ".")
+		if di != dj {
+			return di < dj
+		}
+		return names[i] < names[j]
+	})
+
+	value := func(name string) any {
+		if typed {
+			return typedValue(vars[name])
+		}
+		return vars[name]
+	}
+
+	data := make(map[string]any, len(vars))
+	for _, name := range names {
+		segments := strings.Split(name, ".")
+		if len(segments) == 1 || hasEmpty(segments) {
+			data[name] = value(name)
+			continue
+		}
+
+		m := data
+		for _, segment := range segments[:len(segments)-1] {
+			child, ok := m[segment].(map[string]any)
+			if !ok {
+				if _, taken := m[segment]; taken {
+					m = nil
+					break
+				}
+				child = make(map[string]any)
+				m[segment] = child
+			}
+			m = child
+		}
+		if m != nil {
+			m[segments[len(segments)-1]] = value(name)
+		}
+	}
+	for name, value := range data {
+		data[name] = nestLists(value)
+	}
+	return data
+}
+
+// nestLists replaces nested maps whose keys are exactly 0 to n-1 with lists in index order
+func nestLists(value any) any {
+	m, ok := value.(map[string]any)
+	if !ok {
+		return value
+	}
+	for name, child := range m {
+		m[name] = nestLists(child)
+	}
+
+	list := make([]any, len(m))
+	for name, child := range m {
+		i, err := strconv.Atoi(name)
+		if err != nil || i < 0 || i >= len(m) || strconv.Itoa(i) != name {
+			return m
+		}
+		list[i] = child
+	}
+	return list
+}
+
+// typedValue returns value as a bool, int or float64 when it is written exactly as Go prints one,
+// and as the string otherwise, so conditionals see booleans while rendered text is unchanged
+// Values such as "007", "1.0" or "True" stay strings
+func typedValue(value string) any {
+	switch value {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	if i, err := strconv.Atoi(value); err == nil && strconv.Itoa(i) == value {
+		return i
+	}
+	if f, err := strconv.ParseFloat(value, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) &&
+		strconv.FormatFloat(f, 'g', -1, 64) == value {
+		return f
+	}
+	return value
+}
+
+// hasEmpty reports whether any segment is empty
+func hasEmpty(segments []string) bool {
+	for _, segment := range segments {
+		if segment == "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,197 @@
+package stamp
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestNestVars tests turning dotted names into nested maps
+func TestNestVars(t *testing.T) {
+	got := nestVars(map[string]string{
+		"name":      "app",
+		"db.host":   "localhost",
+		"db.port":   "5432",
+		"a.b.c":     "deep",
+		"plain":     "x",
+		"plain.sub": "ignored",
+		"odd..name": "kept",
+	}, true)
+
+	want := map[string]any{
+		"name":      "app",
+		"db":        map[string]any{"host": "localhost", "port": 5432},
+		"a":         map[string]any{"b": map[string]any{"c": "deep"}},
+		"plain":     "x",
+		"odd..name": "kept",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("nestVars() = %v, want %v", got, want)
+	}
+}
+
+// TestNestVars_Lists tests that names numbered from 0 become lists
+func TestNestVars_Lists(t *testing.T) {
+	got := nestVars(map[string]string{
+		"items.0":         "a",
+		"items.1":         "b",
+		"items.2":         "c",
+		"users.0.name":    "alice",
+		"users.1.name":    "bob",
+		"users.1.roles.0": "admin",
+		"gaps.0":          "x",
+		"gaps.2":          "z",
+		"padded.00":       "kept",
+	}, false)
+
+	want := map[string]any{
+		"items": []any{"a", "b", "c"},
+		"users": []any{
+			map[string]any{"name": "alice"},
+			map[string]any{"name": "bob", "roles": []any{"admin"}},
+		},
+		"gaps":   map[string]any{"0": "x", "2": "z"},
+		"padded": map[string]any{"00": "kept"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("nestVars() = %v, want %v", got, want)
+	}
+}
+
+// TestExecute_RangeOverList tests that templates range over list variables in order
+func TestExecute_RangeOverList(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+	createTestFile(t, src, "list.txt.stamp", "{{range $i, $item := .items}}{{$i}}={{$item}}\n{{end}}")
+
+	vars := map[string]string{"items.0": "a", "items.1": "b", "items.2": "c"}
+	stamper := New(vars, ".stamp")
+	if err := stamper.Validate([]string{src}); err != nil {
+		t.Fatalf("Validate() failed: %v", err)
+	}
+	if err := stamper.Execute(src, dest); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	assertFileContent(t, filepath.Join(dest, "list.txt"), "0=a\n1=b\n2=c\n")
+}
+
+// TestTypedValue tests which values are typed and which stay strings
+func TestTypedValue(t *testing.T) {
+	tests := []struct {
+		value string
+		want  any
+	}{
+		{"true", true},
+		{"false", false},
+		{"8080", 8080},
+		{"-3", -3},
+		{"1.5", 1.5},
+		{"True", "True"},
+		{"007", "007"},
+		{"1.0", "1.0"},
+		{"1e6", "1e6"},
+		{"NaN", "NaN"},
+		{"", ""},
+		{"app", "app"},
+	}
+	for _, tt := range tests {
+		if got := typedValue(tt.value); got != tt.want {
+			t.Errorf("typedValue(%q) = %#v, want %#v", tt.value, got, tt.want)
+		}
+	}
+}
+
+// TestExecute_UntypedVariables tests that values stay strings unless typing is requested
+func TestExecute_UntypedVariables(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+	createTestFile(t, src, "version.txt.stamp", `{{if eq .major "1"}}v1{{end}} {{printf "%s" .major}} {{.db.port}}`)
+
+	vars := map[string]string{"major": "1", "db.port": "5432"}
+	if err := New(vars, ".stamp").Execute(src, dest); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	assertFileContent(t, filepath.Join(dest, "version.txt"), "v1 1 5432")
+}
+
+// TestExecute_TypedVariables tests that conditionals see booleans and text renders unchanged
+func TestExecute_TypedVariables(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+	createTestFile(t, src, "debug.txt.stamp", "{{if .debug}}debug: true{{end}}")
+	createTestFile(t, src, "values.txt.stamp", "{{.debug}} {{.port}} {{.ratio}} {{.zip}}{{if gt .port 1024}} unprivileged{{end}}")
+
+	vars := map[string]string{"debug": "false", "port": "8080", "ratio": "0.5", "zip": "01234"}
+	if err := New(vars, ".stamp", WithTypedVars(true)).Execute(src, dest); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	assertFileContent(t, filepath.Join(dest, "debug.txt"), "")
+	assertFileContent(t, filepath.Join(dest, "values.txt"), "false 8080 0.5 01234 unprivileged")
+}
+
+// TestExecute_NestedVariables tests that dotted variables are readable as nested fields
+func TestExecute_NestedVariables(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+	createTestFile(t, src, "db.conf.stamp", "{{.db.host}}:{{.db.port}}")
+
+	vars := map[string]string{"db.host": "localhost", "db.port": "5432"}
+	if err := New(vars, ".stamp").Execute(src, dest); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	assertFileContent(t, filepath.Join(dest, "db.conf"), "localhost:5432")
+}
+
+// TestValidateVariables_NestedVariables tests that nested names satisfy their top-level variable
+func TestValidateVariables_NestedVariables(t *testing.T) {
+	src := t.TempDir()
+	createTestFile(t, src, "db.conf.stamp", "{{.db.host}}")
+
+	stamper := New(map[string]string{"db.host": "localhost"}, ".stamp")
+	if err := stamper.Validate([]string{src}); err != nil {
+		t.Errorf("Validate() returned error: %v", err)
+	}
+
+	stamper = New(map[string]string{"name": "app"}, ".stamp")
+	if err := stamper.Validate([]string{src}); err == nil {
+		t.Error("Validate() succeeded without any db variable")
+	}
+}
+
+// TestExecute_StrictKeys tests that unprovided keys fail rendering only under strict mode
+func TestExecute_StrictKeys(t *testing.T) {
+	src := t.TempDir()
+	createTestFile(t, src, "db.conf.stamp", "{{.db.host}}:{{.db.port}}")
+	vars := map[string]string{"db.host": "localhost"}
+
+	dest := t.TempDir()
+	if err := New(vars, ".stamp").Execute(src, dest); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	assertFileContent(t, filepath.Join(dest, "db.conf"), "localhost:<no value>")
+
+	// Names computed for var are held to the same rule
+	varSrc := t.TempDir()
+	createTestFile(t, varSrc, "user.conf.stamp", `{{var "db.host"}}:{{var "db.user"}}`)
+	dest = t.TempDir()
+	if err := New(vars, ".stamp").Execute(varSrc, dest); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	assertFileContent(t, filepath.Join(dest, "user.conf"), "localhost:")
+	err := New(vars, ".stamp", WithStrictKeys(true)).Execute(varSrc, t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), `no entry for key "db.user"`) {
+		t.Fatalf("Execute() error = %v, want missing key error naming db.user", err)
+	}
+
+	dest = t.TempDir()
+	err = New(vars, ".stamp", WithStrictKeys(true)).Execute(src, dest)
+	if err == nil || !strings.Contains(err.Error(), `no entry for key "port"`) {
+		t.Fatalf("Execute() error = %v, want missing key error naming port", err)
+	}
+	assertFileNotExists(t, filepath.Join(dest, "db.conf"))
+}
@@ -0,0 +1,140 @@
+// Package stamp renders stamp sheets from Go programs without running the stamp command
+// It is a stable entry point over the internal implementation used by the CLI
+package stamp
+
+import (
+	"fmt"
+	"maps"
+
+	"github.com/monochromegane/stamp/internal/config"
+	"github.com/monochromegane/stamp/internal/stamp"
+)
+
+// ValidationError reports templates that fail to parse and required variables that are not provided
+// Render returns it before writing any file
+type ValidationError = stamp.ValidationError
+
+var (
+	// ErrExists is returned when a destination file already exists under OverwriteError
+	ErrExists = stamp.ErrExists
+	// ErrUnsafePath is returned when a rendered path would leave the destination
+	ErrUnsafePath = stamp.ErrUnsafePath
+)
+
+// Policies for destination files that existed before the render
+const (
+	OverwriteError     = stamp.OverwriteError     // Stop with ErrExists (the default)
+	OverwriteSkip      = stamp.OverwriteSkip      // Leave the existing file alone
+	OverwriteForce     = stamp.OverwriteForce     // Replace the existing file
+	OverwriteIfChanged = stamp.OverwriteIfChanged // Replace the existing file only if the content differs
+)
+
+// DefaultExt is the stamp file extension used unless WithExt is given
+const DefaultExt = ".stamp"
+
+// Option configures Render
+type Option func(*options)
+
+type options struct {
+	ext        string
+	configFile string
+	stamper    []stamp.Option
+}
+
+// WithExt sets the stamp file extension
+func WithExt(ext string) Option {
+	return func(o *options) {
+		o.ext = ext
+	}
+}
+
+// WithConfigFile loads a global config file (YAML, JSON or TOML) beneath the variables passed to Render
+func WithConfigFile(path string) Option {
+	return func(o *options) {
+		o.configFile = path
+	}
+}
+
+// WithOverwrite sets how files that already exist in the destination are handled
+func WithOverwrite(policy string) Option {
+	return func(o *options) {
+		o.stamper = append(o.stamper, stamp.WithOverwrite(policy))
+	}
+}
+
+// WithStrictKeys makes reading a key that is not provided a render error instead of <no value>
+func WithStrictKeys(strict bool) Option {
+	return func(o *options) {
+		o.stamper = append(o.stamper, stamp.WithStrictKeys(strict))
+	}
+}
+
+// WithTypedVars passes values written exactly like booleans or numbers to templates as such
+func WithTypedVars(typed bool) Option {
+	return func(o *options) {
+		o.stamper = append(o.stamper, stamp.WithTypedVars(typed))
+	}
+}
+
+// WithSkipEmpty skips files whose template renders empty or whitespace-only output
+func WithSkipEmpty(skip bool) Option {
+	return func(o *options) {
+		o.stamper = append(o.stamper, stamp.WithSkipEmpty(skip))
+	}
+}
+
+// WithTrimBlocks removes lines holding only control actions such as {{if}} or {{end}} from the output
+func WithTrimBlocks(trim bool) Option {
+	return func(o *options) {
+		o.stamper = append(o.stamper, stamp.WithTrimBlocks(trim))
+	}
+}
+
+// WithCopyBinary copies files with the template extension that look binary as is instead of failing
+func WithCopyBinary(copyBinary bool) Option {
+	return func(o *options) {
+		o.stamper = append(o.stamper, stamp.WithCopyBinary(copyBinary))
+	}
+}
+
+// WithDelims sets the template action delimiters
+func WithDelims(left, right string) Option {
+	return func(o *options) {
+		o.stamper = append(o.stamper, stamp.WithDelims(left, right))
+	}
+}
+
+// WithOutputPrefix nests all output under a subdirectory of the destination, which may use template variables
+func WithOutputPrefix(prefix string) Option {
+	return func(o *options) {
+		o.stamper = append(o.stamper, stamp.WithOutputPrefix(prefix))
+	}
+}
+
+// Render stamps the sheet in sheetDir into dest, as the press command does
+// Variables are merged with the CLI's priority: vars > config file > sheet defaults
+// All templates are validated first; a *ValidationError is returned before anything is written
+func Render(sheetDir, dest string, vars map[string]string, opts ...Option) error {
+	o := options{ext: DefaultExt}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	sheet, err := config.LoadSheet(sheetDir)
+	if err != nil {
+		return err
+	}
+	merged := config.MergeDefaults([]*config.Sheet{sheet})
+	if o.configFile != "" {
+		configVars, err := config.Load(o.configFile)
+		if err != nil {
+			return fmt.Errorf("config error: %w", err)
+		}
+		maps.Copy(merged, configVars)
+	}
+	maps.Copy(merged, vars)
+
+	stamperOpts := append([]stamp.Option{stamp.WithFuncs(stamp.StringFuncs())}, o.stamper...)
+	_, err = stamp.New(merged, o.ext, stamperOpts...).ExecuteMultiple([]string{sheetDir}, dest)
+	return err
+}
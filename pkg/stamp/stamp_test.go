@@ -0,0 +1,119 @@
+package stamp_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/monochromegane/stamp/pkg/stamp"
+)
+
+// writeFile creates a file under dir, including parent directories
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+}
+
+// assertContent verifies that the file at path has the expected content
+func assertContent(t *testing.T, path, want string) {
+	t.Helper()
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", path, err)
+	}
+	if string(got) != want {
+		t.Errorf("%s = %q, want %q", path, got, want)
+	}
+}
+
+func TestRender(t *testing.T) {
+	sheet := t.TempDir()
+	writeFile(t, sheet, ".stampsheet.yaml", "defaults:\n  license: MIT\n  branch: main\n")
+	writeFile(t, sheet, "{{.name}}/README.md.stamp", "# {{.name | upper}}\n{{.org}} {{.license}} {{.branch}}\n")
+	writeFile(t, sheet, "LICENSE", "plain")
+	configFile := filepath.Join(t.TempDir(), "work.yaml")
+	writeFile(t, filepath.Dir(configFile), "work.yaml", "org: acme\nbranch: trunk\n")
+
+	// vars win over the config file, which wins over sheet defaults
+	dest := t.TempDir()
+	vars := map[string]string{"name": "app", "org": "cli"}
+	if err := stamp.Render(sheet, dest, vars, stamp.WithConfigFile(configFile)); err != nil {
+		t.Fatalf("Render() failed: %v", err)
+	}
+	assertContent(t, filepath.Join(dest, "app", "README.md"), "# APP\ncli MIT trunk\n")
+	assertContent(t, filepath.Join(dest, "LICENSE"), "plain")
+
+	// Existing files are an error unless an overwrite policy says otherwise
+	if err := stamp.Render(sheet, dest, vars); !errors.Is(err, stamp.ErrExists) {
+		t.Errorf("Render() error = %v, want ErrExists", err)
+	}
+	if err := stamp.Render(sheet, dest, map[string]string{"name": "app", "org": "acme"}, stamp.WithOverwrite(stamp.OverwriteForce)); err != nil {
+		t.Fatalf("Render() with OverwriteForce failed: %v", err)
+	}
+	assertContent(t, filepath.Join(dest, "app", "README.md"), "# APP\nacme MIT main\n")
+}
+
+func TestRender_Options(t *testing.T) {
+	sheet := t.TempDir()
+	writeFile(t, sheet, "chart.yaml.tmpl", "name: [[.name]]\nref: ${{ github.ref }}\n")
+	writeFile(t, sheet, "empty.txt.tmpl", "[[if .debug]]debug[[end]]")
+
+	dest := t.TempDir()
+	err := stamp.Render(sheet, dest, map[string]string{"name": "app", "debug": ""},
+		stamp.WithExt(".tmpl"),
+		stamp.WithDelims("[[", "]]"),
+		stamp.WithSkipEmpty(true),
+		stamp.WithOutputPrefix("charts/[[.name]]"),
+	)
+	if err != nil {
+		t.Fatalf("Render() failed: %v", err)
+	}
+	assertContent(t, filepath.Join(dest, "charts", "app", "chart.yaml"), "name: app\nref: ${{ github.ref }}\n")
+	if _, err := os.Stat(filepath.Join(dest, "charts", "app", "empty.txt")); !os.IsNotExist(err) {
+		t.Errorf("empty.txt should be skipped, stat error = %v", err)
+	}
+}
+
+func TestRender_ValidationError(t *testing.T) {
+	sheet := t.TempDir()
+	writeFile(t, sheet, "a.txt.stamp", "{{.name}}\n{{if .debug}}\n{{.level}}\n{{end}}\n")
+	writeFile(t, sheet, "b.txt", "copied")
+
+	dest := t.TempDir()
+	err := stamp.Render(sheet, dest, map[string]string{"name": "app"})
+	var validationErr *stamp.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Render() error = %v, want *ValidationError", err)
+	}
+	want := map[string][]string{"debug": {"a.txt.stamp:2"}, "level": {"a.txt.stamp:3"}}
+	if !reflect.DeepEqual(validationErr.MissingVars, want) {
+		t.Errorf("MissingVars = %v, want %v", validationErr.MissingVars, want)
+	}
+
+	// Nothing is written when validation fails
+	entries, err := os.ReadDir(dest)
+	if err != nil {
+		t.Fatalf("failed to read destination: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("destination has %d entries, want none", len(entries))
+	}
+}
+
+func TestRender_ConfigFileNotFound(t *testing.T) {
+	sheet := t.TempDir()
+	writeFile(t, sheet, "a.txt", "a")
+
+	err := stamp.Render(sheet, t.TempDir(), nil, stamp.WithConfigFile(filepath.Join(t.TempDir(), "missing.yaml")))
+	if err == nil {
+		t.Error("Render() should fail for a missing config file")
+	}
+}